@@ -16,8 +16,12 @@ import (
 	"github.com/redis/go-redis/v9"
 
 	"github.com/simonkvalheim/hm9-banking/internal/auth"
+	"github.com/simonkvalheim/hm9-banking/internal/bootstrap"
 	"github.com/simonkvalheim/hm9-banking/internal/handler"
+	"github.com/simonkvalheim/hm9-banking/internal/mail"
 	appMiddleware "github.com/simonkvalheim/hm9-banking/internal/middleware"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/pii"
 	"github.com/simonkvalheim/hm9-banking/internal/processor"
 	"github.com/simonkvalheim/hm9-banking/internal/queue"
 	"github.com/simonkvalheim/hm9-banking/internal/repository"
@@ -35,6 +39,13 @@ func main() {
 	defer db.Close()
 	log.Println("Connected to database")
 
+	// Ensure system accounts exist for every configured currency (fails fast if not migrated)
+	systemAccounts, err := bootstrap.Initialize(context.Background(), db, cfg.SystemCurrencies)
+	if err != nil {
+		log.Fatalf("Failed to initialize system accounts: %v", err)
+	}
+	log.Printf("System accounts ready for currencies: %v", systemAccounts.Currencies())
+
 	// Defer Redis cleanup (will be set if async mode enabled)
 	var redisCleanup func()
 	defer func() {
@@ -43,14 +54,35 @@ func main() {
 		}
 	}()
 
+	// Encryption for national ID numbers and dates of birth (PII_KEYS, PII_ACTIVE_KEY_ID, PII_INDEX_KEY)
+	piiCipher, err := pii.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to set up PII encryption: %v", err)
+	}
+
 	// Initialize repositories
 	accountRepo := repository.NewAccountRepository(db)
 	txRepo := repository.NewTransactionRepository(db)
-	customerRepo := repository.NewCustomerRepository(db)
+	customerRepo := repository.NewCustomerRepository(db, piiCipher)
+	ledgerRepo := repository.NewLedgerRepository(db)
+	limitRepo := repository.NewLimitRepository(db)
+	scheduledRepo := repository.NewScheduledTransferRepository(db)
+	feeRepo := repository.NewFeeRepository(db)
+	interestRepo := repository.NewInterestRepository(db)
 
 	// Initialize auth service
 	authConfig := auth.DefaultConfig(cfg.JWTSecret)
-	authService := auth.NewService(authConfig, customerRepo)
+	authConfig.StepUpThresholds = cfg.StepUpThresholds
+	authConfig.AppBaseURL = cfg.AppBaseURL
+	authConfig.RequireVerifiedEmail = cfg.RequireVerifiedEmail
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	verificationTokenRepo := repository.NewVerificationTokenRepository(db)
+	mailer, err := newMailer(cfg.MailDir)
+	if err != nil {
+		log.Fatalf("Failed to set up mailer: %v", err)
+	}
+	authService := auth.NewService(authConfig, customerRepo, refreshTokenRepo, sessionRepo, verificationTokenRepo, mailer)
 
 	// Initialize processor
 	transferProcessor := processor.NewTransferProcessor(db)
@@ -77,9 +109,13 @@ func main() {
 	}
 
 	// Initialize handlers
-	accountHandler := handler.NewAccountHandler(accountRepo)
-	transferHandler := handler.NewTransferHandler(txRepo, accountRepo, transferProcessor, publisher)
+	accountHandler := handler.NewAccountHandler(accountRepo, ledgerRepo, limitRepo)
+	transferHandler := handler.NewTransferHandler(txRepo, accountRepo, scheduledRepo, feeRepo, transferProcessor, publisher, systemAccounts, authService)
 	authHandler := handler.NewAuthHandler(authService)
+	sessionHandler := handler.NewSessionHandler(authService)
+	mfaHandler := handler.NewMFAHandler(authService)
+	profileHandler := handler.NewProfileHandler(customerRepo, authService)
+	adminHandler := handler.NewAdminHandler(accountRepo, txRepo, limitRepo, scheduledRepo, feeRepo, interestRepo)
 
 	// Initialize auth middleware
 	authMiddleware := appMiddleware.NewAuthMiddleware(authService)
@@ -103,10 +139,24 @@ func main() {
 		// Apply auth middleware to all /v1 routes
 		r.Use(authMiddleware.RequireAuth)
 
+		authHandler.RegisterProtectedRoutes(r)
+		sessionHandler.RegisterRoutes(r)
+		mfaHandler.RegisterRoutes(r)
+		profileHandler.RegisterRoutes(r)
 		accountHandler.RegisterRoutes(r)
 		transferHandler.RegisterRoutes(r)
 	})
 
+	// Back-office routes (require X-Admin-Token); disabled unless ADMIN_API_TOKEN is set
+	if cfg.AdminAPIToken != "" {
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(appMiddleware.RequireAdminToken(cfg.AdminAPIToken))
+			adminHandler.RegisterRoutes(r)
+		})
+	} else {
+		log.Println("Admin API disabled (set ADMIN_API_TOKEN to enable)")
+	}
+
 	// Start server
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%s", cfg.Port),
@@ -147,6 +197,16 @@ type Config struct {
 	RedisPassword string
 	AsyncMode     bool   // If true, use Redis queue for async processing
 	JWTSecret     string // Secret for signing JWT tokens
+
+	StepUpThresholds map[string]model.Money // Per currency, transfers at or above this need a two-factor code; empty disables
+
+	AppBaseURL           string // Frontend URL used in email links
+	MailDir              string // If set, emails are written here as .eml files instead of logged
+	RequireVerifiedEmail bool   // If true, transfers are blocked until the customer verifies their email
+
+	AdminAPIToken string // Shared secret for /admin routes; empty disables them
+
+	SystemCurrencies []string // Currencies that get a bank equity account
 }
 
 // loadConfig reads configuration from environment variables
@@ -180,6 +240,35 @@ func loadConfig() Config {
 		log.Println("WARNING: Using default JWT_SECRET for development. Set JWT_SECRET environment variable in production!")
 	}
 
+	// Currencies for system accounts, e.g. SYSTEM_CURRENCIES=NOK,EUR,USD
+	systemCurrencies, err := bootstrap.ParseCurrencies(os.Getenv("SYSTEM_CURRENCIES"))
+	if err != nil {
+		log.Fatalf("Invalid SYSTEM_CURRENCIES: %v", err)
+	}
+
+	// Transfers at or above the threshold of their currency require a two-factor code,
+	// e.g. MFA_STEP_UP_THRESHOLDS=NOK:10000,EUR:1000,USD:1000
+	stepUpThresholds, err := auth.ParseStepUpThresholds(os.Getenv("MFA_STEP_UP_THRESHOLDS"))
+	if err != nil {
+		log.Fatalf("Invalid MFA_STEP_UP_THRESHOLDS: %v", err)
+	}
+	if len(stepUpThresholds) > 0 {
+		for _, currency := range systemCurrencies {
+			if _, ok := stepUpThresholds[currency]; !ok {
+				log.Fatalf("Invalid MFA_STEP_UP_THRESHOLDS: no threshold for %s", currency)
+			}
+		}
+	}
+	if os.Getenv("MFA_STEP_UP_THRESHOLD") != "" {
+		log.Fatalf("MFA_STEP_UP_THRESHOLD is replaced by MFA_STEP_UP_THRESHOLDS, e.g. NOK:10000,EUR:1000")
+	}
+
+	// Email links (verification, password reset) point at the frontend
+	appBaseURL := os.Getenv("APP_BASE_URL")
+	if appBaseURL == "" {
+		appBaseURL = "http://localhost:5173"
+	}
+
 	return Config{
 		Port:          port,
 		DatabaseURL:   dbURL,
@@ -187,7 +276,27 @@ func loadConfig() Config {
 		RedisPassword: redisPassword,
 		AsyncMode:     asyncMode,
 		JWTSecret:     jwtSecret,
+
+		StepUpThresholds: stepUpThresholds,
+
+		AppBaseURL:           appBaseURL,
+		MailDir:              os.Getenv("MAIL_DIR"),
+		RequireVerifiedEmail: os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true",
+
+		AdminAPIToken: os.Getenv("ADMIN_API_TOKEN"),
+
+		SystemCurrencies: systemCurrencies,
+	}
+}
+
+// newMailer returns a mailer that writes to dir, or logs emails if dir is empty
+func newMailer(dir string) (mail.Mailer, error) {
+	if dir == "" {
+		log.Println("Emails are logged (set MAIL_DIR to write them to files)")
+		return mail.NewLogMailer(), nil
 	}
+	log.Printf("Emails are written to %s", dir)
+	return mail.NewFileMailer(dir)
 }
 
 // connectDB creates a connection pool to PostgreSQL
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/simonkvalheim/hm9-banking/internal/queue"
+)
+
+const usage = `Usage: queueadmin <command> [arguments]
+
+Commands:
+  stats                     Show the number of messages in each queue stage
+  dead [-limit N]           List messages in the dead-letter queue
+  requeue <transaction-id>  Move a dead message back onto the pending queue
+  requeue all               Move every dead message back onto the pending queue
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		redisURL = "localhost:6379"
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisURL,
+		Password: os.Getenv("REDIS_PASSWORD"),
+		DB:       0,
+	})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var err error
+	switch os.Args[1] {
+	case "stats":
+		err = stats(ctx, client)
+	case "dead":
+		err = listDead(ctx, client, os.Args[2:])
+	case "requeue":
+		err = requeue(ctx, client, os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// stats prints the size of every queue stage
+func stats(ctx context.Context, client *redis.Client) error {
+	s, err := queue.GetQueueStats(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("pending:    %d\n", s.Pending)
+	fmt.Printf("processing: %d\n", s.Processing)
+	fmt.Printf("delayed:    %d\n", s.Delayed)
+	fmt.Printf("dead:       %d\n", s.Dead)
+	return nil
+}
+
+// listDead prints the dead-letter queue, oldest first
+func listDead(ctx context.Context, client *redis.Client, args []string) error {
+	fs := flag.NewFlagSet("dead", flag.ExitOnError)
+	limit := fs.Int64("limit", 100, "maximum number of messages to list")
+	fs.Parse(args)
+
+	messages, err := queue.ListDead(ctx, client, *limit)
+	if err != nil {
+		return err
+	}
+
+	if len(messages) == 0 {
+		fmt.Println("Dead-letter queue is empty")
+		return nil
+	}
+
+	for _, msg := range messages {
+		failedAt := "-"
+		if msg.FailedAt != nil {
+			failedAt = msg.FailedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%s  %-10s  attempts=%d  failed_at=%s  error=%s\n",
+			msg.TransactionID, msg.Type, msg.Attempts, failedAt, msg.LastError)
+	}
+	return nil
+}
+
+// requeue moves one or all dead messages back onto the pending queue
+func requeue(ctx context.Context, client *redis.Client, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("requeue needs a transaction ID or \"all\"")
+	}
+
+	transactionID := uuid.Nil
+	if args[0] != "all" {
+		id, err := uuid.Parse(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid transaction ID: %w", err)
+		}
+		transactionID = id
+	}
+
+	count, err := queue.RequeueDead(ctx, client, transactionID)
+	if err != nil {
+		return err
+	}
+	if count == 0 && transactionID != uuid.Nil {
+		return fmt.Errorf("transaction %s is not in the dead-letter queue", transactionID)
+	}
+
+	fmt.Printf("Requeued %d message(s)\n", count)
+	return nil
+}
@@ -6,14 +6,21 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/simonkvalheim/hm9-banking/internal/bootstrap"
+	"github.com/simonkvalheim/hm9-banking/internal/interest"
 	"github.com/simonkvalheim/hm9-banking/internal/processor"
 	"github.com/simonkvalheim/hm9-banking/internal/queue"
+	"github.com/simonkvalheim/hm9-banking/internal/reconcile"
+	"github.com/simonkvalheim/hm9-banking/internal/repository"
+	"github.com/simonkvalheim/hm9-banking/internal/scheduler"
+	"github.com/simonkvalheim/hm9-banking/internal/sweeper"
 )
 
 func main() {
@@ -21,18 +28,25 @@ func main() {
 	cfg := loadConfig()
 
 	// Connect to database
-	db, err := connectDB(cfg.DatabaseURL)
+	db, err := connectDB(cfg.DatabaseURL, cfg.Worker.Concurrency)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 	log.Println("Connected to database")
 
+	// Ensure system accounts exist for every configured currency (fails fast if not migrated)
+	if _, err := bootstrap.Initialize(context.Background(), db, cfg.SystemCurrencies); err != nil {
+		log.Fatalf("Failed to initialize system accounts: %v", err)
+	}
+
 	// Connect to Redis
+	// Every consumer holds a connection while blocked on BLMOVE, so leave headroom for the rest
 	redisClient := redis.NewClient(&redis.Options{
 		Addr:     cfg.RedisURL,
 		Password: cfg.RedisPassword,
 		DB:       0,
+		PoolSize: cfg.Worker.Concurrency + 10,
 	})
 	defer redisClient.Close()
 
@@ -45,7 +59,7 @@ func main() {
 
 	// Initialize processor and worker
 	transferProcessor := processor.NewTransferProcessor(db)
-	worker := queue.NewWorker(redisClient, transferProcessor)
+	worker := queue.NewWorker(redisClient, transferProcessor, cfg.Worker)
 
 	// Create context that cancels on shutdown signal
 	ctx, cancel := context.WithCancel(context.Background())
@@ -56,11 +70,41 @@ func main() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 		<-quit
-		log.Println("Shutdown signal received, stopping worker...")
+		log.Printf("Shutdown signal received, draining worker (timeout: %s)...", cfg.Worker.ShutdownTimeout)
+		if err := worker.Shutdown(cfg.Worker.ShutdownTimeout); err != nil {
+			log.Printf("Worker shutdown: %v", err)
+		}
 		cancel()
-		worker.Stop()
 	}()
 
+	// Start the balance reconciler alongside the queue worker
+	if cfg.ReconcileInterval > 0 {
+		reconciler := reconcile.NewReconciler(repository.NewBalanceRepository(db), cfg.ReconcileInterval, cfg.ReconcileRepair)
+		go reconciler.Start(ctx)
+	}
+
+	// Start the outbox relay so transactions that were never queued still reach the worker
+	relay := queue.NewOutboxRelay(repository.NewOutboxRepository(db), queue.NewPublisher(redisClient), cfg.OutboxInterval)
+	go relay.Start(ctx)
+
+	// Start the sweeper that re-enqueues transactions stuck in pending
+	if cfg.SweepInterval > 0 {
+		txSweeper := sweeper.NewSweeper(repository.NewTransactionRepository(db), queue.NewPublisher(redisClient), cfg.SweepInterval, cfg.SweepMaxAge)
+		go txSweeper.Start(ctx)
+	}
+
+	// Start the scheduler that turns due standing orders into transfers
+	if cfg.SchedulerInterval > 0 {
+		transferScheduler := scheduler.NewScheduler(repository.NewScheduledTransferRepository(db), cfg.SchedulerInterval)
+		go transferScheduler.Start(ctx)
+	}
+
+	// Start the interest engine that accrues interest daily and pays it into accounts monthly
+	if cfg.InterestInterval > 0 {
+		interestEngine := interest.NewEngine(repository.NewInterestRepository(db), repository.NewLedgerRepository(db), cfg.InterestInterval)
+		go interestEngine.Start(ctx)
+	}
+
 	// Start the worker
 	log.Println("Starting transaction worker...")
 	worker.Start(ctx)
@@ -73,6 +117,22 @@ type Config struct {
 	DatabaseURL   string
 	RedisURL      string
 	RedisPassword string
+
+	SystemCurrencies []string // Currencies that get a bank equity account
+
+	ReconcileInterval time.Duration // How often to check account_balances against the ledger (0 disables)
+	ReconcileRepair   bool          // If true, rebuild drifted balances from the ledger
+
+	OutboxInterval time.Duration // How often the outbox relay polls for unsent transactions
+
+	Worker queue.WorkerConfig // Retry and redelivery settings
+
+	SweepInterval time.Duration // How often to look for stuck transactions (0 disables)
+	SweepMaxAge   time.Duration // How long a transaction may stay pending before it is swept
+
+	SchedulerInterval time.Duration // How often to materialize due scheduled transfers (0 disables)
+
+	InterestInterval time.Duration // How often to accrue and capitalize interest (0 disables)
 }
 
 // loadConfig reads configuration from environment variables
@@ -89,19 +149,125 @@ func loadConfig() Config {
 
 	redisPassword := os.Getenv("REDIS_PASSWORD")
 
+	systemCurrencies, err := bootstrap.ParseCurrencies(os.Getenv("SYSTEM_CURRENCIES"))
+	if err != nil {
+		log.Fatalf("Invalid SYSTEM_CURRENCIES: %v", err)
+	}
+
+	reconcileInterval := time.Hour
+	if v := os.Getenv("RECONCILE_INTERVAL"); v != "" {
+		reconcileInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid RECONCILE_INTERVAL: %v", err)
+		}
+	}
+
+	reconcileRepair := os.Getenv("RECONCILE_REPAIR") == "true"
+
+	outboxInterval := time.Second
+	if v := os.Getenv("OUTBOX_INTERVAL"); v != "" {
+		outboxInterval, err = time.ParseDuration(v)
+		if err != nil || outboxInterval <= 0 {
+			log.Fatalf("Invalid OUTBOX_INTERVAL: %q", v)
+		}
+	}
+
+	workerCfg := queue.DefaultWorkerConfig()
+	if v := os.Getenv("WORKER_CONCURRENCY"); v != "" {
+		workerCfg.Concurrency, err = strconv.Atoi(v)
+		if err != nil || workerCfg.Concurrency < 1 {
+			log.Fatalf("Invalid WORKER_CONCURRENCY: %q", v)
+		}
+	}
+	if v := os.Getenv("WORKER_MAX_ATTEMPTS"); v != "" {
+		workerCfg.MaxAttempts, err = strconv.Atoi(v)
+		if err != nil || workerCfg.MaxAttempts < 1 {
+			log.Fatalf("Invalid WORKER_MAX_ATTEMPTS: %q", v)
+		}
+	}
+	if v := os.Getenv("WORKER_VISIBILITY_TIMEOUT"); v != "" {
+		workerCfg.VisibilityTimeout, err = time.ParseDuration(v)
+		if err != nil || workerCfg.VisibilityTimeout <= 0 {
+			log.Fatalf("Invalid WORKER_VISIBILITY_TIMEOUT: %q", v)
+		}
+	}
+	if v := os.Getenv("WORKER_SHUTDOWN_TIMEOUT"); v != "" {
+		workerCfg.ShutdownTimeout, err = time.ParseDuration(v)
+		if err != nil || workerCfg.ShutdownTimeout <= 0 {
+			log.Fatalf("Invalid WORKER_SHUTDOWN_TIMEOUT: %q", v)
+		}
+	}
+
+	sweepInterval := time.Minute
+	if v := os.Getenv("SWEEP_INTERVAL"); v != "" {
+		sweepInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid SWEEP_INTERVAL: %v", err)
+		}
+	}
+
+	sweepMaxAge := 15 * time.Minute
+	if v := os.Getenv("SWEEP_MAX_AGE"); v != "" {
+		sweepMaxAge, err = time.ParseDuration(v)
+		if err != nil || sweepMaxAge <= 0 {
+			log.Fatalf("Invalid SWEEP_MAX_AGE: %q", v)
+		}
+	}
+
+	schedulerInterval := time.Minute
+	if v := os.Getenv("SCHEDULER_INTERVAL"); v != "" {
+		schedulerInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid SCHEDULER_INTERVAL: %v", err)
+		}
+	}
+
+	interestInterval := time.Hour
+	if v := os.Getenv("INTEREST_INTERVAL"); v != "" {
+		interestInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid INTEREST_INTERVAL: %v", err)
+		}
+	}
+
 	return Config{
 		DatabaseURL:   dbURL,
 		RedisURL:      redisURL,
 		RedisPassword: redisPassword,
+
+		SystemCurrencies: systemCurrencies,
+
+		ReconcileInterval: reconcileInterval,
+		ReconcileRepair:   reconcileRepair,
+
+		OutboxInterval: outboxInterval,
+
+		Worker: workerCfg,
+
+		SweepInterval: sweepInterval,
+		SweepMaxAge:   sweepMaxAge,
+
+		SchedulerInterval: schedulerInterval,
+
+		InterestInterval: interestInterval,
 	}
 }
 
 // connectDB creates a connection pool to PostgreSQL
-func connectDB(databaseURL string) (*pgxpool.Pool, error) {
+// The pool is sized so every concurrent consumer can hold a connection alongside the background jobs
+func connectDB(databaseURL string, concurrency int) (*pgxpool.Pool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	pool, err := pgxpool.New(ctx, databaseURL)
+	poolCfg, err := pgxpool.ParseConfig(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse database URL: %w", err)
+	}
+	if minConns := int32(concurrency + 4); poolCfg.MaxConns < minConns {
+		poolCfg.MaxConns = minConns
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create connection pool: %w", err)
 	}
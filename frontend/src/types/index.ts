@@ -18,9 +18,11 @@ export interface Account {
   id: string;
   customer_id?: string;
   account_number: string;
+  iban?: string;
   account_type: 'checking' | 'savings' | 'loan';
   currency: string;
   status: 'active' | 'frozen' | 'closed';
+  overdraft_limit: string;
   created_at: string;
   updated_at: string;
 }
@@ -28,6 +30,8 @@ export interface Account {
 export interface AccountBalance {
   account_id: string;
   balance: string;
+  available_balance: string;
+  overdraft_limit: string;
   currency: string;
   as_of: string;
 }
@@ -35,12 +39,15 @@ export interface AccountBalance {
 export interface Transaction {
   id: string;
   type: string;
-  status: 'pending' | 'completed' | 'failed';
+  status: 'pending' | 'processing' | 'completed' | 'failed' | 'cancelled' | 'reversed';
   amount: string;
+  fee_amount?: string;
   currency: string;
   reference?: string;
   created_at: string;
   completed_at?: string;
+  reversal_of?: string;
+  reversed_by?: string;
 }
 
 export interface LoginResponse {
@@ -0,0 +1,187 @@
+// Package accountnumber builds and validates Norwegian account numbers (BBAN) and their IBANs
+package accountnumber
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// BankCode is the bank's four-digit register number, the first part of every BBAN it issues
+// Migration 000014 uses the same value when renumbering existing accounts
+const BankCode = "9710"
+
+const (
+	// BBANLength is the length of a Norwegian account number: bank code, account group, customer number, check digit
+	BBANLength = 11
+	// IBANLength is the length of a Norwegian IBAN: NO, two check digits and the BBAN
+	IBANLength = 15
+	// CountryCode prefixes Norwegian IBANs
+	CountryCode = "NO"
+
+	// MaxSerial is the largest serial that fits between the bank code and the check digit
+	MaxSerial = 999999
+)
+
+var (
+	ErrInvalidBBAN        = errors.New("invalid account number: must be 11 digits with a valid check digit")
+	ErrInvalidIBAN        = errors.New("invalid IBAN")
+	ErrUnsupportedCountry = errors.New("only Norwegian (NO) IBANs are supported")
+	ErrNoCheckDigit       = errors.New("serial has no valid MOD11 check digit")
+	ErrSerialOutOfRange   = errors.New("account number serial out of range")
+)
+
+// mod11Weights are the weights of the first ten BBAN digits
+var mod11Weights = [BBANLength - 1]int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}
+
+// CheckDigit returns the MOD11 check digit for the first ten digits of a BBAN
+// Some prefixes have no check digit (the remainder would need to be 10); they return ErrNoCheckDigit
+func CheckDigit(first10 string) (byte, error) {
+	if len(first10) != BBANLength-1 || !isDigits(first10) {
+		return 0, ErrInvalidBBAN
+	}
+
+	sum := 0
+	for i, w := range mod11Weights {
+		sum += int(first10[i]-'0') * w
+	}
+	check := 11 - sum%11
+	switch check {
+	case 11:
+		return '0', nil
+	case 10:
+		return 0, ErrNoCheckDigit
+	}
+	return byte('0' + check), nil
+}
+
+// FromSerial builds the BBAN with the bank code and a serial number
+// Returns ErrNoCheckDigit for the roughly one in eleven serials that cannot be used
+func FromSerial(serial int64) (string, error) {
+	if serial < 0 || serial > MaxSerial {
+		return "", ErrSerialOutOfRange
+	}
+
+	first10 := fmt.Sprintf("%s%06d", BankCode, serial)
+	check, err := CheckDigit(first10)
+	if err != nil {
+		return "", err
+	}
+	return first10 + string(check), nil
+}
+
+// ValidateBBAN checks an 11-digit account number and its MOD11 check digit
+func ValidateBBAN(bban string) error {
+	if len(bban) != BBANLength || !isDigits(bban) {
+		return ErrInvalidBBAN
+	}
+	check, err := CheckDigit(bban[:BBANLength-1])
+	if err != nil || check != bban[BBANLength-1] {
+		return ErrInvalidBBAN
+	}
+	return nil
+}
+
+// IBAN returns the Norwegian IBAN for a valid BBAN
+func IBAN(bban string) (string, error) {
+	if err := ValidateBBAN(bban); err != nil {
+		return "", err
+	}
+	return CountryCode + ibanCheckDigits(CountryCode, bban) + bban, nil
+}
+
+// ValidateIBAN checks an IBAN's ISO 7064 MOD 97-10 check digits and that it holds a valid Norwegian BBAN
+func ValidateIBAN(iban string) error {
+	if len(iban) < 4 || !isLetters(iban[:2]) || !isDigits(iban[2:4]) {
+		return ErrInvalidIBAN
+	}
+	if iban[:2] != CountryCode {
+		return ErrUnsupportedCountry
+	}
+	if len(iban) != IBANLength {
+		return ErrInvalidIBAN
+	}
+	if iban[2:4] != ibanCheckDigits(iban[:2], iban[4:]) {
+		return ErrInvalidIBAN
+	}
+	if ValidateBBAN(iban[4:]) != nil {
+		return ErrInvalidIBAN
+	}
+	return nil
+}
+
+// Parse accepts a BBAN or an IBAN as typed by a person and returns the BBAN
+// Spaces and dots are ignored and letters may be lowercase, so "9710.00.00001",
+// "no30 9710 0000 001" and "97100000001" all return "97100000001".
+func Parse(s string) (string, error) {
+	n := Normalize(s)
+	if n == "" {
+		return "", ErrInvalidBBAN
+	}
+	if isDigits(n) {
+		if err := ValidateBBAN(n); err != nil {
+			return "", err
+		}
+		return n, nil
+	}
+	if err := ValidateIBAN(n); err != nil {
+		return "", err
+	}
+	return n[4:], nil
+}
+
+// Normalize removes spaces and dots and uppercases letters
+func Normalize(s string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", ".", "").Replace(strings.TrimSpace(s)))
+}
+
+// Format groups a BBAN the way Norwegian banks print it: 9710.00.00018
+func Format(bban string) string {
+	if len(bban) != BBANLength {
+		return bban
+	}
+	return bban[:4] + "." + bban[4:6] + "." + bban[6:]
+}
+
+// ibanCheckDigits computes the two check digits for a country code and BBAN
+// The BBAN, country code and "00" are read as one number, letters counting as 10-35,
+// and the check digits are 98 minus that number mod 97.
+func ibanCheckDigits(country, bban string) string {
+	var digits strings.Builder
+	for _, c := range bban + country + "00" {
+		switch {
+		case c >= '0' && c <= '9':
+			digits.WriteRune(c)
+		case c >= 'A' && c <= 'Z':
+			fmt.Fprintf(&digits, "%d", c-'A'+10)
+		default:
+			return ""
+		}
+	}
+
+	n, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return ""
+	}
+	mod := new(big.Int).Mod(n, big.NewInt(97)).Int64()
+	return fmt.Sprintf("%02d", 98-mod)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package accountnumber
+
+import "testing"
+
+func TestValidateBBAN(t *testing.T) {
+	valid := []string{"86011117947", "12345678903", "97100000001", "97100000028"}
+	for _, bban := range valid {
+		if err := ValidateBBAN(bban); err != nil {
+			t.Errorf("ValidateBBAN(%q) error = %v, want nil", bban, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"86011117948",  // wrong check digit
+		"8601111794",   // too short
+		"860111179470", // too long
+		"8601111794a",  // not digits
+		"97100000010",  // serial 1 has no valid check digit
+	}
+	for _, bban := range invalid {
+		if err := ValidateBBAN(bban); err != ErrInvalidBBAN {
+			t.Errorf("ValidateBBAN(%q) error = %v, want ErrInvalidBBAN", bban, err)
+		}
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	if got, err := CheckDigit("8601111794"); err != nil || got != '7' {
+		t.Errorf("CheckDigit(8601111794) = %c, %v; want 7", got, err)
+	}
+	// Weighted sum is a multiple of 11, so the check digit is 0
+	if got, err := CheckDigit("9710999999"); err != nil || got != '0' {
+		t.Errorf("CheckDigit(9710999999) = %c, %v; want 0", got, err)
+	}
+	if _, err := CheckDigit("9710000001"); err != ErrNoCheckDigit {
+		t.Errorf("CheckDigit(9710000001) error = %v, want ErrNoCheckDigit", err)
+	}
+}
+
+func TestFromSerial(t *testing.T) {
+	tests := []struct {
+		serial int64
+		want   string
+	}{
+		{0, "97100000001"},
+		{2, "97100000028"},
+		{999999, "97109999990"},
+	}
+	for _, tt := range tests {
+		got, err := FromSerial(tt.serial)
+		if err != nil || got != tt.want {
+			t.Errorf("FromSerial(%d) = %q, %v; want %q", tt.serial, got, err, tt.want)
+		}
+		if err := ValidateBBAN(got); err != nil {
+			t.Errorf("FromSerial(%d) produced an invalid BBAN: %v", tt.serial, err)
+		}
+	}
+
+	if _, err := FromSerial(1); err != ErrNoCheckDigit {
+		t.Errorf("FromSerial(1) error = %v, want ErrNoCheckDigit", err)
+	}
+	for _, serial := range []int64{-1, MaxSerial + 1} {
+		if _, err := FromSerial(serial); err != ErrSerialOutOfRange {
+			t.Errorf("FromSerial(%d) error = %v, want ErrSerialOutOfRange", serial, err)
+		}
+	}
+}
+
+func TestIBAN(t *testing.T) {
+	// Example Norwegian IBAN from the SWIFT IBAN registry
+	got, err := IBAN("86011117947")
+	if err != nil || got != "NO9386011117947" {
+		t.Errorf("IBAN(86011117947) = %q, %v; want NO9386011117947", got, err)
+	}
+
+	if _, err := IBAN("86011117948"); err != ErrInvalidBBAN {
+		t.Errorf("IBAN(invalid BBAN) error = %v, want ErrInvalidBBAN", err)
+	}
+}
+
+func TestValidateIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		want error
+	}{
+		{"NO9386011117947", nil},
+		{"NO3097100000001", nil},
+		{"NO9486011117947", ErrInvalidIBAN},  // wrong check digits
+		{"NO938601111794", ErrInvalidIBAN},   // too short
+		{"NO93860111179470", ErrInvalidIBAN}, // too long
+		{"GB29NWBK60161331926819", ErrUnsupportedCountry},
+		{"N", ErrInvalidIBAN},
+		{"1234", ErrInvalidIBAN},
+	}
+	for _, tt := range tests {
+		if err := ValidateIBAN(tt.iban); err != tt.want {
+			t.Errorf("ValidateIBAN(%q) error = %v, want %v", tt.iban, err, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, in := range []string{
+		"86011117947",
+		"8601.11.17947",
+		"8601 11 17947",
+		"NO9386011117947",
+		"no93 8601 1117 947",
+		" NO93 8601 1117 947 ",
+	} {
+		got, err := Parse(in)
+		if err != nil || got != "86011117947" {
+			t.Errorf("Parse(%q) = %q, %v; want 86011117947", in, got, err)
+		}
+	}
+
+	for _, in := range []string{"", "   ", "86011117948", "NO9486011117947", "GB29NWBK60161331926819", "BANK-EQUITY-NOK"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", in)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := Format("86011117947"); got != "8601.11.17947" {
+		t.Errorf("Format() = %q, want 8601.11.17947", got)
+	}
+	if got := Format("BANK-EQUITY-NOK"); got != "BANK-EQUITY-NOK" {
+		t.Errorf("Format() = %q, want input unchanged", got)
+	}
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/mail"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// RequestEmailVerification sends a new verification link to a registered, unverified address
+// Unknown and already verified addresses are silently ignored so the endpoint
+// cannot be used to find out which emails are registered
+func (s *Service) RequestEmailVerification(ctx context.Context, email string) error {
+	customer, err := s.customerRepo.GetByEmail(ctx, email)
+	if err != nil || customer.EmailVerified {
+		return nil
+	}
+	return s.sendEmailVerification(ctx, customer)
+}
+
+// VerifyEmail consumes an email verification token and marks the address verified
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	if _, err := s.parseVerificationToken(token, model.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	_, err := s.tokenRepo.VerifyEmail(ctx, hashToken(token))
+	return err
+}
+
+// RequestPasswordReset sends a password reset link to a registered address
+// Unknown addresses are silently ignored, like RequestEmailVerification
+func (s *Service) RequestPasswordReset(ctx context.Context, email string) error {
+	customer, err := s.customerRepo.GetByEmail(ctx, email)
+	if err != nil || customer.Status == model.CustomerStatusClosed {
+		return nil
+	}
+
+	token, err := s.issueVerificationToken(ctx, customer, model.TokenPurposePasswordReset, s.config.PasswordResetExpiry)
+	if err != nil {
+		return err
+	}
+
+	link := s.link("/reset-password", token)
+	return s.mailer.Send(ctx, mail.Message{
+		To:      customer.Email,
+		Subject: "Reset your Fjord Bank password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for your Fjord Bank account. "+
+			"Open this link within %s to choose a new one:\n\n%s\n\n"+
+			"If you did not ask for this, you can ignore this email and your password stays the same.\n",
+			customer.FirstName, formatExpiry(s.config.PasswordResetExpiry), link),
+	})
+}
+
+// ResetPassword sets a new password using a reset token
+// Every session is revoked, so the customer must log in again on all devices
+func (s *Service) ResetPassword(ctx context.Context, req model.ResetPasswordRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.parseVerificationToken(req.Token, model.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	hash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	stored, err := s.tokenRepo.ResetPassword(ctx, hashToken(req.Token), hash)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Password reset for customer %s: all sessions revoked", stored.CustomerID)
+	return nil
+}
+
+// CheckEmailVerified returns ErrEmailNotVerified if verified email is required and the customer has not verified
+func (s *Service) CheckEmailVerified(ctx context.Context, customerID uuid.UUID) error {
+	if !s.config.RequireVerifiedEmail {
+		return nil
+	}
+
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if !customer.EmailVerified {
+		return model.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// sendEmailVerification issues a verification token and emails the link to the customer
+func (s *Service) sendEmailVerification(ctx context.Context, customer *model.Customer) error {
+	token, err := s.issueVerificationToken(ctx, customer, model.TokenPurposeEmailVerification, s.config.EmailVerificationExpiry)
+	if err != nil {
+		return err
+	}
+
+	link := s.link("/verify-email", token)
+	return s.mailer.Send(ctx, mail.Message{
+		To:      customer.Email,
+		Subject: "Verify your email for Fjord Bank",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm this is your email address by opening this link within %s:\n\n%s\n",
+			customer.FirstName, formatExpiry(s.config.EmailVerificationExpiry), link),
+	})
+}
+
+// issueVerificationToken creates and stores a signed, single-use token
+// The token is a JWT whose type is the purpose, so a reset token cannot verify an email or vice versa;
+// only its hash is stored, and the stored record is what makes it single-use
+func (s *Service) issueVerificationToken(ctx context.Context, customer *model.Customer, purpose model.TokenPurpose, expiry time.Duration) (string, error) {
+	now := time.Now()
+	record := &model.VerificationToken{
+		ID:         uuid.New(),
+		CustomerID: customer.ID,
+		Purpose:    purpose,
+		Email:      customer.Email,
+		ExpiresAt:  now.Add(expiry),
+		CreatedAt:  now,
+	}
+
+	signed, err := s.signVerificationToken(record)
+	if err != nil {
+		return "", err
+	}
+	record.TokenHash = hashToken(signed)
+
+	if err := s.tokenRepo.Create(ctx, record); err != nil {
+		return "", err
+	}
+	return signed, nil
+}
+
+// signVerificationToken creates the JWT for a verification token record
+func (s *Service) signVerificationToken(record *model.VerificationToken) (string, error) {
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        record.ID.String(),
+			Subject:   record.CustomerID.String(),
+			IssuedAt:  jwt.NewNumericDate(record.CreatedAt),
+			ExpiresAt: jwt.NewNumericDate(record.ExpiresAt),
+			Issuer:    "fjord-bank",
+		},
+		CustomerID: record.CustomerID,
+		Email:      record.Email,
+		TokenType:  string(record.Purpose),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.config.JWTSecret)
+}
+
+// parseVerificationToken checks a verification token's signature, expiry and purpose
+// Forged or mistyped tokens are rejected here without a database lookup
+func (s *Service) parseVerificationToken(token string, purpose model.TokenPurpose) (*Claims, error) {
+	claims, err := s.ValidateToken(token)
+	if err != nil || claims.TokenType != string(purpose) {
+		return nil, model.ErrVerificationTokenInvalid
+	}
+	return claims, nil
+}
+
+// link builds a frontend URL carrying a token as a query parameter
+func (s *Service) link(path, token string) string {
+	return strings.TrimRight(s.config.AppBaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// formatExpiry describes a link lifetime for an email, e.g. "1 hour" or "24 hours"
+func formatExpiry(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if h := int(d / time.Hour); h != 1 {
+			return fmt.Sprintf("%d hours", h)
+		}
+		return "1 hour"
+	}
+	return fmt.Sprintf("%d minutes", int(d/time.Minute))
+}
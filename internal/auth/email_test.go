@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func TestVerificationToken_PurposeIsEnforced(t *testing.T) {
+	s := NewService(DefaultConfig("test-secret"), nil, nil, nil, nil, nil)
+	now := time.Now()
+	record := &model.VerificationToken{
+		ID:         uuid.New(),
+		CustomerID: uuid.New(),
+		Purpose:    model.TokenPurposePasswordReset,
+		Email:      "test@example.com",
+		ExpiresAt:  now.Add(time.Hour),
+		CreatedAt:  now,
+	}
+
+	token, err := s.signVerificationToken(record)
+	if err != nil {
+		t.Fatalf("signVerificationToken() error = %v", err)
+	}
+
+	claims, err := s.parseVerificationToken(token, model.TokenPurposePasswordReset)
+	if err != nil {
+		t.Fatalf("parseVerificationToken() error = %v", err)
+	}
+	if claims.ID != record.ID.String() || claims.CustomerID != record.CustomerID {
+		t.Errorf("claims = (%s, %s), want (%s, %s)", claims.ID, claims.CustomerID, record.ID, record.CustomerID)
+	}
+
+	// A reset token must not verify an email, and neither can be used as an access token
+	if _, err := s.parseVerificationToken(token, model.TokenPurposeEmailVerification); !errors.Is(err, model.ErrVerificationTokenInvalid) {
+		t.Errorf("parse as email verification: error = %v, want ErrVerificationTokenInvalid", err)
+	}
+	if claims.TokenType == "access" || claims.TokenType == "refresh" {
+		t.Errorf("TokenType = %q, must differ from session tokens", claims.TokenType)
+	}
+
+	other := NewService(DefaultConfig("other-secret"), nil, nil, nil, nil, nil)
+	if _, err := other.parseVerificationToken(token, model.TokenPurposePasswordReset); !errors.Is(err, model.ErrVerificationTokenInvalid) {
+		t.Errorf("token signed with another secret: error = %v, want ErrVerificationTokenInvalid", err)
+	}
+}
+
+func TestVerificationToken_Expired(t *testing.T) {
+	s := NewService(DefaultConfig("test-secret"), nil, nil, nil, nil, nil)
+	past := time.Now().Add(-2 * time.Hour)
+	record := &model.VerificationToken{
+		ID:         uuid.New(),
+		CustomerID: uuid.New(),
+		Purpose:    model.TokenPurposeEmailVerification,
+		ExpiresAt:  past.Add(time.Hour),
+		CreatedAt:  past,
+	}
+
+	token, _ := s.signVerificationToken(record)
+	if _, err := s.parseVerificationToken(token, model.TokenPurposeEmailVerification); !errors.Is(err, model.ErrVerificationTokenInvalid) {
+		t.Errorf("error = %v, want ErrVerificationTokenInvalid", err)
+	}
+}
+
+func TestLink(t *testing.T) {
+	config := DefaultConfig("test-secret")
+	config.AppBaseURL = "https://bank.example/"
+	s := NewService(config, nil, nil, nil, nil, nil)
+
+	got := s.link("/reset-password", "a.b+c")
+	u, err := url.Parse(got)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) error = %v", got, err)
+	}
+	if u.Host != "bank.example" || u.Path != "/reset-password" {
+		t.Errorf("link = %q, want https://bank.example/reset-password?...", got)
+	}
+	if u.Query().Get("token") != "a.b+c" {
+		t.Errorf("token = %q, want %q", u.Query().Get("token"), "a.b+c")
+	}
+}
+
+func TestFormatExpiry(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:        "1 hour",
+		24 * time.Hour:   "24 hours",
+		30 * time.Minute: "30 minutes",
+		90 * time.Minute: "90 minutes",
+	}
+	for d, want := range tests {
+		if got := formatExpiry(d); got != want {
+			t.Errorf("formatExpiry(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// TOTPEnrollment is returned when a customer starts two-factor enrollment
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`      // Base32 secret for manual entry
+	URI    string `json:"otpauth_uri"` // otpauth:// URI, usually shown as a QR code
+}
+
+// MFAStatus describes a customer's two-factor setup
+type MFAStatus struct {
+	TOTPEnabled            bool `json:"totp_enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// GetMFAStatus returns whether two-factor is enabled and how many recovery codes are left
+func (s *Service) GetMFAStatus(ctx context.Context, customerID uuid.UUID) (*MFAStatus, error) {
+	state, err := s.customerRepo.GetTOTP(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{TOTPEnabled: state.Enabled}
+	if state.Enabled {
+		status.RecoveryCodesRemaining = state.RecoveryCodesRemaining
+	}
+	return status, nil
+}
+
+// EnrollTOTP generates a new TOTP secret for the customer
+// The secret is inactive until ConfirmTOTP is called with a code from it;
+// starting again before confirming replaces the pending secret.
+func (s *Service) EnrollTOTP(ctx context.Context, customerID uuid.UUID) (*TOTPEnrollment, error) {
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.TOTPEnabled {
+		return nil, model.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.customerRepo.SetPendingTOTP(ctx, customerID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totpURI(s.config.TOTPIssuer, customer.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor once the customer proves their authenticator works
+// Returns the recovery codes; they are only stored hashed, so this is the only time they are shown
+func (s *Service) ConfirmTOTP(ctx context.Context, customerID uuid.UUID, code string) ([]string, error) {
+	state, err := s.customerRepo.GetTOTP(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if state.Enabled {
+		return nil, model.ErrMFAAlreadyEnabled
+	}
+	if state.Secret == "" {
+		return nil, model.ErrMFANotEnrolled
+	}
+
+	step, ok := verifyTOTP(state.Secret, code, time.Now())
+	if !ok {
+		return nil, model.ErrInvalidMFACode
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = hashToken(c)
+	}
+
+	if err := s.customerRepo.EnableTOTP(ctx, customerID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor off; a current TOTP or recovery code is required
+func (s *Service) DisableTOTP(ctx context.Context, customerID uuid.UUID, code string) error {
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if !customer.TOTPEnabled {
+		return model.ErrMFANotEnabled
+	}
+
+	if err := s.checkMFACode(ctx, customer, code); err != nil {
+		return err
+	}
+	return s.customerRepo.DisableTOTP(ctx, customerID)
+}
+
+// CompleteMFALogin finishes a login started by Login for a customer with two-factor enabled
+// A wrong code counts as a failed login attempt, so guessing eventually locks the account
+func (s *Service) CompleteMFALogin(ctx context.Context, req model.MFALoginRequest, client model.ClientInfo) (*TokenPair, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	claims, err := s.ValidateToken(req.MFAToken)
+	if err != nil || claims.TokenType != "mfa" {
+		return nil, model.ErrInvalidMFAToken
+	}
+
+	customer, err := s.customerRepo.GetByID(ctx, claims.CustomerID)
+	if err != nil {
+		return nil, model.ErrInvalidMFAToken
+	}
+	if !customer.TOTPEnabled {
+		// Two-factor was disabled after the challenge was issued; log in again
+		return nil, model.ErrInvalidMFAToken
+	}
+
+	if err := s.checkMFACode(ctx, customer, req.Code); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, customer, client)
+}
+
+// RequiresStepUp reports whether a transfer of this amount needs a two-factor code
+// Each currency has its own threshold. Once any threshold is set, a currency without one
+// requires step-up for every transfer rather than silently skipping it.
+func (s *Service) RequiresStepUp(amount model.Money) bool {
+	if len(s.config.StepUpThresholds) == 0 {
+		return false
+	}
+
+	threshold, ok := s.config.StepUpThresholds[amount.Currency()]
+	if !ok {
+		return true
+	}
+	cmp, err := amount.Cmp(threshold)
+	return err != nil || cmp >= 0
+}
+
+// ParseStepUpThresholds parses step-up thresholds in the form "NOK:10000,EUR:1000,JPY:150000"
+// Each amount is parsed in its own currency, so it may not have more decimals than the currency
+// has, and must be positive. An empty string disables step-up.
+func ParseStepUpThresholds(s string) (map[string]model.Money, error) {
+	thresholds := make(map[string]model.Money)
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		currency, amount, ok := strings.Cut(entry, ":")
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !ok || currency == "" {
+			return nil, fmt.Errorf("invalid threshold %q: expected currency:amount", entry)
+		}
+		if !model.IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("%w: %s", model.ErrUnsupportedCurrency, currency)
+		}
+		if _, dup := thresholds[currency]; dup {
+			return nil, fmt.Errorf("duplicate threshold for %s", currency)
+		}
+		threshold, err := model.ParseMoney(strings.TrimSpace(amount), currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s threshold %q: %w", currency, amount, err)
+		}
+		if !threshold.IsPositive() {
+			return nil, fmt.Errorf("%s threshold must be positive", currency)
+		}
+		thresholds[currency] = threshold
+	}
+
+	return thresholds, nil
+}
+
+// VerifyStepUp checks the two-factor code supplied with a high-value operation
+// Returns ErrStepUpNotAvailable if the customer has not enabled two-factor
+// and ErrStepUpRequired if no code was supplied
+func (s *Service) VerifyStepUp(ctx context.Context, customerID uuid.UUID, code string) error {
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if !customer.TOTPEnabled {
+		return model.ErrStepUpNotAvailable
+	}
+	if code == "" {
+		return model.ErrStepUpRequired
+	}
+
+	return s.checkMFACode(ctx, customer, code)
+}
+
+// checkMFACode verifies a TOTP or recovery code for a customer with two-factor enabled
+// Wrong codes are counted like wrong passwords and lock the account at the same limit
+func (s *Service) checkMFACode(ctx context.Context, customer *model.Customer, code string) error {
+	if !customer.CanLogin() {
+		if customer.IsLocked() {
+			return model.ErrAccountLocked
+		}
+		return model.ErrAccountSuspended
+	}
+
+	err := s.verifyMFACode(ctx, customer.ID, code)
+	if errors.Is(err, model.ErrInvalidMFACode) {
+		s.handleFailedLogin(ctx, customer)
+	}
+	return err
+}
+
+// verifyMFACode accepts a current TOTP code or one of the customer's unused recovery codes
+// TOTP codes are single-use: a code whose time step was already accepted is rejected
+func (s *Service) verifyMFACode(ctx context.Context, customerID uuid.UUID, code string) error {
+	if code == "" {
+		return model.ErrMFACodeRequired
+	}
+
+	state, err := s.customerRepo.GetTOTP(ctx, customerID)
+	if err != nil {
+		return err
+	}
+	if !state.Enabled {
+		return model.ErrMFANotEnabled
+	}
+
+	if step, ok := verifyTOTP(state.Secret, code, time.Now()); ok {
+		fresh, err := s.customerRepo.RecordTOTPStep(ctx, customerID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return model.ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.customerRepo.UseRecoveryCode(ctx, customerID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return model.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// generateMFAToken creates the short-lived challenge token returned by Login when a second factor is needed
+// It is only accepted by CompleteMFALogin; the auth middleware rejects it as it is not an access token
+func (s *Service) generateMFAToken(customer *model.Customer) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.config.MFAChallengeExpiry)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   customer.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			Issuer:    "fjord-bank",
+		},
+		CustomerID: customer.ID,
+		Email:      customer.Email,
+		TokenType:  "mfa",
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.config.JWTSecret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
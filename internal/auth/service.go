@@ -2,24 +2,36 @@ package auth
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/simonkvalheim/hm9-banking/internal/mail"
 	"github.com/simonkvalheim/hm9-banking/internal/model"
 	"github.com/simonkvalheim/hm9-banking/internal/repository"
 )
 
 // Config holds authentication configuration
 type Config struct {
-	JWTSecret          []byte        // Secret key for signing tokens
-	AccessTokenExpiry  time.Duration // How long access tokens are valid
-	RefreshTokenExpiry time.Duration // How long refresh tokens are valid
-	MaxFailedAttempts  int           // Lock account after this many failures
-	LockDuration       time.Duration // How long to lock account
+	JWTSecret          []byte                 // Secret key for signing tokens
+	AccessTokenExpiry  time.Duration          // How long access tokens are valid
+	RefreshTokenExpiry time.Duration          // How long refresh tokens are valid
+	MaxFailedAttempts  int                    // Lock account after this many failures
+	LockDuration       time.Duration          // How long to lock account
+	MFAChallengeExpiry time.Duration          // How long a two-factor login challenge is valid
+	TOTPIssuer         string                 // Issuer shown in authenticator apps
+	StepUpThresholds   map[string]model.Money // Per currency, transfers at or above this need a two-factor code; empty disables
+
+	AppBaseURL              string        // Frontend URL that email links point to
+	EmailVerificationExpiry time.Duration // How long an email verification link is valid
+	PasswordResetExpiry     time.Duration // How long a password reset link is valid
+	RequireVerifiedEmail    bool          // Block transfers until the customer has verified their email
 }
 
 // DefaultConfig returns sensible defaults
@@ -30,6 +42,12 @@ func DefaultConfig(jwtSecret string) Config {
 		RefreshTokenExpiry: 7 * 24 * time.Hour,
 		MaxFailedAttempts:  5,
 		LockDuration:       15 * time.Minute,
+		MFAChallengeExpiry: 5 * time.Minute,
+		TOTPIssuer:         "Fjord Bank",
+
+		AppBaseURL:              "http://localhost:5173",
+		EmailVerificationExpiry: 24 * time.Hour,
+		PasswordResetExpiry:     time.Hour,
 	}
 }
 
@@ -38,20 +56,29 @@ type Claims struct {
 	jwt.RegisteredClaims
 	CustomerID uuid.UUID `json:"customer_id"`
 	Email      string    `json:"email"`
-	TokenType  string    `json:"token_type"` // "access" or "refresh"
+	SessionID  uuid.UUID `json:"sid"`        // Login session the token belongs to
+	TokenType  string    `json:"token_type"` // "access", "refresh", "mfa", or a verification token purpose
 }
 
 // Service handles authentication operations
 type Service struct {
 	config       Config
 	customerRepo *repository.CustomerRepository
+	refreshRepo  *repository.RefreshTokenRepository
+	sessionRepo  *repository.SessionRepository
+	tokenRepo    *repository.VerificationTokenRepository
+	mailer       mail.Mailer
 }
 
 // NewService creates a new auth service
-func NewService(config Config, customerRepo *repository.CustomerRepository) *Service {
+func NewService(config Config, customerRepo *repository.CustomerRepository, refreshRepo *repository.RefreshTokenRepository, sessionRepo *repository.SessionRepository, tokenRepo *repository.VerificationTokenRepository, mailer mail.Mailer) *Service {
 	return &Service{
 		config:       config,
 		customerRepo: customerRepo,
+		refreshRepo:  refreshRepo,
+		sessionRepo:  sessionRepo,
+		tokenRepo:    tokenRepo,
+		mailer:       mailer,
 	}
 }
 
@@ -95,11 +122,32 @@ func (s *Service) Register(ctx context.Context, req model.CreateCustomerRequest)
 		UpdatedAt:         time.Now(),
 	}
 
-	return s.customerRepo.Create(ctx, customer)
+	created, err := s.customerRepo.Create(ctx, customer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registration succeeds even if the email cannot be sent; the customer can ask for another
+	if err := s.sendEmailVerification(ctx, created); err != nil {
+		log.Printf("Failed to send verification email to customer %s: %v", created.ID, err)
+	}
+
+	return created, nil
 }
 
-// Login authenticates a customer and returns tokens
-func (s *Service) Login(ctx context.Context, req model.LoginRequest) (*TokenPair, error) {
+// LoginResult is the outcome of a password login
+// Customers with two-factor enabled get an MFA challenge instead of tokens,
+// which they exchange for tokens with CompleteMFALogin.
+type LoginResult struct {
+	Tokens       *TokenPair
+	MFARequired  bool
+	MFAToken     string
+	MFAExpiresAt time.Time
+}
+
+// Login authenticates a customer and returns tokens, or an MFA challenge if two-factor is enabled
+// client identifies the device, shown to the customer in their session list
+func (s *Service) Login(ctx context.Context, req model.LoginRequest, client model.ClientInfo) (*LoginResult, error) {
 	// Validate request
 	if err := req.Validate(); err != nil {
 		return nil, err
@@ -128,16 +176,62 @@ func (s *Service) Login(ctx context.Context, req model.LoginRequest) (*TokenPair
 		return nil, model.ErrInvalidCredentials
 	}
 
+	// Password is correct but the second factor is still outstanding;
+	// failed attempts are only reset once the login completes
+	if customer.TOTPEnabled {
+		token, expiresAt, err := s.generateMFAToken(customer)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFARequired: true, MFAToken: token, MFAExpiresAt: expiresAt}, nil
+	}
+
+	pair, err := s.startSession(ctx, customer, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: pair}, nil
+}
+
+// startSession completes a login: it resets failed attempts and issues tokens for a new session
+func (s *Service) startSession(ctx context.Context, customer *model.Customer, client model.ClientInfo) (*TokenPair, error) {
 	// Success - reset failed attempts and update last login
 	s.customerRepo.ResetFailedAttempts(ctx, customer.ID)
 	s.customerRepo.UpdateLastLogin(ctx, customer.ID)
 
-	// Generate tokens
-	return s.generateTokenPair(customer)
+	return s.createSession(ctx, customer, client)
 }
 
-// RefreshTokens generates new tokens using a valid refresh token
-func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error) {
+// createSession issues tokens for a new session on the client's device
+func (s *Service) createSession(ctx context.Context, customer *model.Customer, client model.ClientInfo) (*TokenPair, error) {
+	// Generate tokens for a new session; its ID is the refresh token family
+	pair, refresh, err := s.generateTokenPair(customer, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+
+	client = client.Truncated()
+	session := &model.Session{
+		ID:         refresh.FamilyID,
+		CustomerID: customer.ID,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		CreatedAt:  refresh.CreatedAt,
+		LastUsedAt: refresh.CreatedAt,
+		ExpiresAt:  refresh.ExpiresAt,
+	}
+	if err := s.sessionRepo.Create(ctx, session, refresh); err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair
+// Refresh tokens are single-use: the presented token is marked used and replaced.
+// Presenting a token that was already used means it was copied, so the whole
+// family (every token descended from the same login) is revoked.
+func (s *Service) RefreshTokens(ctx context.Context, refreshToken string, client model.ClientInfo) (*TokenPair, error) {
 	// Parse and validate the refresh token
 	claims, err := s.ValidateToken(refreshToken)
 	if err != nil {
@@ -149,6 +243,22 @@ func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (*Toke
 		return nil, errors.New("invalid token type")
 	}
 
+	// Look up the server-side record; a signed token we never stored is not accepted
+	stored, err := s.refreshRepo.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored.CustomerID != claims.CustomerID {
+		return nil, model.ErrRefreshTokenInvalid
+	}
+	if stored.IsUsed() {
+		s.revokeReusedFamily(ctx, stored)
+		return nil, model.ErrRefreshTokenReused
+	}
+	if stored.IsRevoked() || stored.IsExpired(time.Now()) {
+		return nil, model.ErrRefreshTokenInvalid
+	}
+
 	// Fetch customer to ensure they still exist and are active
 	customer, err := s.customerRepo.GetByID(ctx, claims.CustomerID)
 	if err != nil {
@@ -159,8 +269,121 @@ func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (*Toke
 		return nil, model.ErrAccountSuspended
 	}
 
-	// Generate new token pair
-	return s.generateTokenPair(customer)
+	// Generate new token pair in the same family and retire the presented token
+	pair, next, err := s.generateTokenPair(customer, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshRepo.Rotate(ctx, stored.ID, next, client); err != nil {
+		if errors.Is(err, model.ErrRefreshTokenReused) {
+			// Lost a race with another refresh using the same token
+			s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// ChangePassword sets a new password after re-verifying the current one
+// Every session is revoked, including the one making the request, so tokens issued
+// before the change stop working everywhere; the caller gets tokens for a fresh session.
+// A wrong current password counts as a failed login attempt.
+func (s *Service) ChangePassword(ctx context.Context, customerID uuid.UUID, req model.ChangePasswordRequest, client model.ClientInfo) (*TokenPair, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if !customer.CanLogin() {
+		if customer.IsLocked() {
+			return nil, model.ErrAccountLocked
+		}
+		return nil, model.ErrAccountSuspended
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(customer.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		s.handleFailedLogin(ctx, customer)
+		return nil, model.ErrIncorrectPassword
+	}
+
+	hash, err := HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, errors.New("failed to hash password")
+	}
+	if err := s.customerRepo.ChangePassword(ctx, customerID, hash); err != nil {
+		return nil, err
+	}
+	s.customerRepo.ResetFailedAttempts(ctx, customerID)
+
+	return s.createSession(ctx, customer, client)
+}
+
+// Logout revokes the session the given refresh token belongs to
+// Unknown or invalid tokens are ignored so logout always succeeds for the client
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.refreshRepo.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, model.ErrRefreshTokenInvalid) {
+			return nil
+		}
+		return err
+	}
+
+	return s.sessionRepo.Revoke(ctx, stored.FamilyID)
+}
+
+// LogoutAll revokes every session the customer has
+// Returns the number of sessions revoked
+func (s *Service) LogoutAll(ctx context.Context, customerID uuid.UUID) (int64, error) {
+	return s.sessionRepo.RevokeAllForCustomer(ctx, customerID)
+}
+
+// ListSessions returns the customer's active sessions
+// currentSessionID marks the session making the request
+func (s *Service) ListSessions(ctx context.Context, customerID, currentSessionID uuid.UUID) ([]model.Session, error) {
+	sessions, err := s.sessionRepo.ListActive(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one of the customer's sessions
+// Its refresh tokens stop working and its access tokens are rejected immediately
+func (s *Service) RevokeSession(ctx context.Context, customerID, sessionID uuid.UUID) error {
+	return s.sessionRepo.RevokeForCustomer(ctx, customerID, sessionID)
+}
+
+// ValidateSession returns ErrSessionRevoked unless the session is active
+// Called on every authenticated request so revocation takes effect before access tokens expire
+func (s *Service) ValidateSession(ctx context.Context, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		return model.ErrSessionRevoked
+	}
+	active, err := s.sessionRepo.Touch(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return model.ErrSessionRevoked
+	}
+	return nil
+}
+
+// revokeReusedFamily revokes a session after one of its used refresh tokens was presented again
+func (s *Service) revokeReusedFamily(ctx context.Context, token *model.RefreshToken) {
+	if err := s.sessionRepo.Revoke(ctx, token.FamilyID); err != nil {
+		log.Printf("Failed to revoke session %s after refresh token reuse: %v", token.FamilyID, err)
+		return
+	}
+	log.Printf("Refresh token reuse detected for customer %s: revoked session %s", token.CustomerID, token.FamilyID)
 }
 
 // ValidateToken parses and validates a JWT token
@@ -186,8 +409,10 @@ func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
 }
 
 // generateTokenPair creates access and refresh tokens for a customer
-func (s *Service) generateTokenPair(customer *model.Customer) (*TokenPair, error) {
+// It also returns the server-side record for the refresh token, which the caller must store
+func (s *Service) generateTokenPair(customer *model.Customer, familyID uuid.UUID) (*TokenPair, *model.RefreshToken, error) {
 	now := time.Now()
+	refreshID := uuid.New()
 	accessExpiry := now.Add(s.config.AccessTokenExpiry)
 	refreshExpiry := now.Add(s.config.RefreshTokenExpiry)
 
@@ -201,12 +426,15 @@ func (s *Service) generateTokenPair(customer *model.Customer) (*TokenPair, error
 		},
 		CustomerID: customer.ID,
 		Email:      customer.Email,
+		SessionID:  familyID,
 		TokenType:  "access",
 	}
 
 	// Refresh token claims
+	// The unique ID makes every refresh token distinct, even when issued in the same second
 	refreshClaims := Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        refreshID.String(),
 			Subject:   customer.ID.String(),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(refreshExpiry),
@@ -214,6 +442,7 @@ func (s *Service) generateTokenPair(customer *model.Customer) (*TokenPair, error
 		},
 		CustomerID: customer.ID,
 		Email:      customer.Email,
+		SessionID:  familyID,
 		TokenType:  "refresh",
 	}
 
@@ -221,20 +450,37 @@ func (s *Service) generateTokenPair(customer *model.Customer) (*TokenPair, error
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
 	accessSigned, err := accessToken.SignedString(s.config.JWTSecret)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
 	refreshSigned, err := refreshToken.SignedString(s.config.JWTSecret)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return &TokenPair{
+	pair := &TokenPair{
 		AccessToken:  accessSigned,
 		RefreshToken: refreshSigned,
 		ExpiresAt:    accessExpiry,
-	}, nil
+	}
+
+	record := &model.RefreshToken{
+		ID:         refreshID,
+		CustomerID: customer.ID,
+		FamilyID:   familyID,
+		TokenHash:  hashToken(refreshSigned),
+		ExpiresAt:  refreshExpiry,
+		CreatedAt:  now,
+	}
+
+	return pair, record, nil
+}
+
+// hashToken returns the hex SHA-256 of a token, the only form in which refresh tokens are stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // handleFailedLogin increments failed attempts and locks if necessary
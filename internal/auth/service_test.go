@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func TestGenerateTokenPair_RefreshRecord(t *testing.T) {
+	s := NewService(DefaultConfig("test-secret"), nil, nil, nil, nil, nil)
+	customer := &model.Customer{ID: uuid.New(), Email: "test@example.com"}
+	familyID := uuid.New()
+
+	pair, record, err := s.generateTokenPair(customer, familyID)
+	if err != nil {
+		t.Fatalf("generateTokenPair() error = %v", err)
+	}
+
+	if record.CustomerID != customer.ID {
+		t.Errorf("record.CustomerID = %s, want %s", record.CustomerID, customer.ID)
+	}
+	if record.FamilyID != familyID {
+		t.Errorf("record.FamilyID = %s, want %s", record.FamilyID, familyID)
+	}
+	if record.TokenHash != hashToken(pair.RefreshToken) {
+		t.Error("record.TokenHash does not match the issued refresh token")
+	}
+	if record.TokenHash == pair.RefreshToken {
+		t.Error("refresh token must not be stored in plain text")
+	}
+	if got := record.ExpiresAt.Sub(record.CreatedAt); got != 7*24*time.Hour {
+		t.Errorf("refresh lifetime = %v, want 168h", got)
+	}
+
+	claims, err := s.ValidateToken(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if claims.ID != record.ID.String() {
+		t.Errorf("refresh token jti = %q, want %q", claims.ID, record.ID)
+	}
+	if claims.SessionID != familyID {
+		t.Errorf("refresh token sid = %s, want %s", claims.SessionID, familyID)
+	}
+
+	accessClaims, err := s.ValidateToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken(access) error = %v", err)
+	}
+	if accessClaims.SessionID != familyID {
+		t.Errorf("access token sid = %s, want %s", accessClaims.SessionID, familyID)
+	}
+}
+
+func TestGenerateTokenPair_UniqueRefreshTokens(t *testing.T) {
+	s := NewService(DefaultConfig("test-secret"), nil, nil, nil, nil, nil)
+	customer := &model.Customer{ID: uuid.New(), Email: "test@example.com"}
+	familyID := uuid.New()
+
+	first, _, err := s.generateTokenPair(customer, familyID)
+	if err != nil {
+		t.Fatalf("generateTokenPair() error = %v", err)
+	}
+	second, _, err := s.generateTokenPair(customer, familyID)
+	if err != nil {
+		t.Fatalf("generateTokenPair() error = %v", err)
+	}
+
+	// Issued within the same second, but rotation relies on every token being distinct
+	if first.RefreshToken == second.RefreshToken {
+		t.Error("refresh tokens issued in the same second must differ")
+	}
+}
+
+func TestRefreshToken_State(t *testing.T) {
+	now := time.Now()
+	token := &model.RefreshToken{ExpiresAt: now.Add(time.Hour)}
+
+	if token.IsUsed() || token.IsRevoked() || token.IsExpired(now) {
+		t.Error("new token should be usable")
+	}
+	if !token.IsExpired(now.Add(time.Hour)) {
+		t.Error("token should be expired at its expiry time")
+	}
+
+	token.UsedAt = &now
+	if !token.IsUsed() {
+		t.Error("IsUsed() = false after UsedAt set")
+	}
+	token.RevokedAt = &now
+	if !token.IsRevoked() {
+		t.Error("IsRevoked() = false after RevokedAt set")
+	}
+}
+
+func TestValidateSession_MissingSessionID(t *testing.T) {
+	s := NewService(DefaultConfig("test-secret"), nil, nil, nil, nil, nil)
+
+	// Access tokens issued before sessions existed carry no session ID
+	if err := s.ValidateSession(context.Background(), uuid.Nil); !errors.Is(err, model.ErrSessionRevoked) {
+		t.Errorf("ValidateSession(uuid.Nil) error = %v, want ErrSessionRevoked", err)
+	}
+}
+
+func TestGenerateMFAToken(t *testing.T) {
+	s := NewService(DefaultConfig("test-secret"), nil, nil, nil, nil, nil)
+	customer := &model.Customer{ID: uuid.New(), Email: "test@example.com"}
+
+	token, expiresAt, err := s.generateMFAToken(customer)
+	if err != nil {
+		t.Fatalf("generateMFAToken() error = %v", err)
+	}
+	if got := time.Until(expiresAt); got > 5*time.Minute || got < 4*time.Minute {
+		t.Errorf("challenge lifetime = %v, want about 5m", got)
+	}
+
+	claims, err := s.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	// The middleware only accepts "access" tokens, so a challenge cannot be used as one
+	if claims.TokenType != "mfa" {
+		t.Errorf("TokenType = %q, want %q", claims.TokenType, "mfa")
+	}
+	if claims.SessionID != uuid.Nil {
+		t.Errorf("SessionID = %s, want none before the login completes", claims.SessionID)
+	}
+	if claims.CustomerID != customer.ID {
+		t.Errorf("CustomerID = %s, want %s", claims.CustomerID, customer.ID)
+	}
+}
+
+func TestCompleteMFALogin_RejectsOtherTokenTypes(t *testing.T) {
+	s := NewService(DefaultConfig("test-secret"), nil, nil, nil, nil, nil)
+	customer := &model.Customer{ID: uuid.New(), Email: "test@example.com"}
+
+	pair, _, err := s.generateTokenPair(customer, uuid.New())
+	if err != nil {
+		t.Fatalf("generateTokenPair() error = %v", err)
+	}
+
+	for name, token := range map[string]string{"access": pair.AccessToken, "refresh": pair.RefreshToken, "garbage": "not-a-jwt"} {
+		req := model.MFALoginRequest{MFAToken: token, Code: "123456"}
+		if _, err := s.CompleteMFALogin(context.Background(), req, model.ClientInfo{}); !errors.Is(err, model.ErrInvalidMFAToken) {
+			t.Errorf("%s token: error = %v, want ErrInvalidMFAToken", name, err)
+		}
+	}
+}
+
+func TestRequiresStepUp(t *testing.T) {
+	config := DefaultConfig("test-secret")
+	thresholds, err := ParseStepUpThresholds("NOK:10000, EUR:1000, JPY:150000")
+	if err != nil {
+		t.Fatalf("ParseStepUpThresholds() error = %v", err)
+	}
+	config.StepUpThresholds = thresholds
+	s := NewService(config, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		amount   string
+		currency string
+		want     bool
+	}{
+		{"9999.99", "NOK", false},
+		{"10000.00", "NOK", true},
+		{"999.99", "EUR", false},
+		{"1000.00", "EUR", true},
+		{"149999", "JPY", false},
+		{"150000", "JPY", true},
+		// No threshold set for USD: every transfer needs a code
+		{"0.01", "USD", true},
+	}
+
+	for _, tt := range tests {
+		amount, err := model.ParseMoney(tt.amount, tt.currency)
+		if err != nil {
+			t.Fatalf("ParseMoney(%s %s) error = %v", tt.amount, tt.currency, err)
+		}
+		if got := s.RequiresStepUp(amount); got != tt.want {
+			t.Errorf("RequiresStepUp(%s %s) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+
+	disabled := NewService(DefaultConfig("test-secret"), nil, nil, nil, nil, nil)
+	large, _ := model.ParseMoney("1000000", "NOK")
+	if disabled.RequiresStepUp(large) {
+		t.Error("RequiresStepUp with no threshold = true, want false")
+	}
+}
+
+func TestParseStepUpThresholds(t *testing.T) {
+	got, err := ParseStepUpThresholds("nok:10000.50,JPY:150000")
+	if err != nil {
+		t.Fatalf("ParseStepUpThresholds() error = %v", err)
+	}
+	if len(got) != 2 || got["NOK"].String() != "10000.50" || got["JPY"].String() != "150000" {
+		t.Errorf("ParseStepUpThresholds() = %v, want NOK 10000.50 and JPY 150000", got)
+	}
+
+	if got, err := ParseStepUpThresholds(""); err != nil || len(got) != 0 {
+		t.Errorf("ParseStepUpThresholds(\"\") = %v, %v, want no thresholds", got, err)
+	}
+
+	for _, value := range []string{
+		"10000",           // no currency
+		"XXX:100",         // unsupported currency
+		"JPY:10000.50",    // more decimals than the currency has
+		"NOK:0",           // not positive
+		"NOK:-100",        // not positive
+		"NOK:100,NOK:200", // duplicate
+		"NOK:100,EUR:abc", // not an amount
+	} {
+		if _, err := ParseStepUpThresholds(value); err == nil {
+			t.Errorf("ParseStepUpThresholds(%q) error = nil, want an error", value)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key from RFC 6238 Appendix B ("12345678901234567890") in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// RFC 6238 lists 8-digit codes; a 6-digit code is the last six digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := totpCode(rfc6238Secret, totpStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("totpCode(%d) error = %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	current := totpStep(now)
+
+	code, _ := totpCode(rfc6238Secret, current)
+	step, ok := verifyTOTP(rfc6238Secret, code, now)
+	if !ok || step != current {
+		t.Errorf("verifyTOTP(current) = (%d, %v), want (%d, true)", step, ok, current)
+	}
+
+	// One step of clock drift either way is tolerated
+	previous, _ := totpCode(rfc6238Secret, current-1)
+	if step, ok := verifyTOTP(rfc6238Secret, previous, now); !ok || step != current-1 {
+		t.Errorf("verifyTOTP(previous) = (%d, %v), want (%d, true)", step, ok, current-1)
+	}
+
+	stale, _ := totpCode(rfc6238Secret, current-2)
+	if _, ok := verifyTOTP(rfc6238Secret, stale, now); ok {
+		t.Error("verifyTOTP accepted a code two steps old")
+	}
+
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := verifyTOTP(rfc6238Secret, bad, now); ok {
+			t.Errorf("verifyTOTP(%q) = true, want false", bad)
+		}
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generateTOTPSecret() error = %v", err)
+	}
+
+	key, err := base32NoPad.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret is not valid base32: %v", err)
+	}
+	if len(key) != totpSecretBytes {
+		t.Errorf("len(key) = %d, want %d", len(key), totpSecretBytes)
+	}
+
+	other, _ := generateTOTPSecret()
+	if other == secret {
+		t.Error("generateTOTPSecret returned the same secret twice")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI("Fjord Bank", "kari@example.com", rfc6238Secret)
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" {
+		t.Errorf("uri = %s, want otpauth://totp/...", uri)
+	}
+	if u.Path != "/Fjord Bank:kari@example.com" {
+		t.Errorf("label = %q, want %q", u.Path, "/Fjord Bank:kari@example.com")
+	}
+
+	q := u.Query()
+	if q.Get("secret") != rfc6238Secret {
+		t.Errorf("secret = %q, want %q", q.Get("secret"), rfc6238Secret)
+	}
+	if q.Get("issuer") != "Fjord Bank" {
+		t.Errorf("issuer = %q, want %q", q.Get("issuer"), "Fjord Bank")
+	}
+	if q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("digits/period = %s/%s, want 6/30", q.Get("digits"), q.Get("period"))
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("len(codes) = %d, want %d", len(codes), recoveryCodeCount)
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if strings.ContainsAny(code, "0o1li") {
+			t.Errorf("code %q contains an ambiguous character", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+
+		if normalizeRecoveryCode(code) != code {
+			t.Errorf("normalizeRecoveryCode(%q) changed a valid code", code)
+		}
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := map[string]string{
+		"abcde-fghjk":   "abcde-fghjk",
+		"ABCDE-FGHJK":   "abcde-fghjk",
+		" abcde fghjk ": "abcde-fghjk",
+		"abcdefghjk":    "abcde-fghjk",
+	}
+
+	for in, want := range tests {
+		if got := normalizeRecoveryCode(in); got != want {
+			t.Errorf("normalizeRecoveryCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
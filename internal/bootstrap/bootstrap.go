@@ -5,64 +5,184 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/simonkvalheim/hm9-banking/internal/model"
 )
 
-// Initialize ensures all required system accounts exist
+// DefaultCurrencies is used when no system currencies are configured
+const DefaultCurrencies = "NOK,EUR,USD"
+
+// requiredTables must exist before the services can start
+// A missing table means migrations have not been run
+var requiredTables = []string{
+	"accounts",
+	"transactions",
+	"ledger_entries",
+	"transaction_parties",
+	"customers",
+	"account_balances",
+	"transaction_outbox",
+	"refresh_tokens",
+	"sessions",
+	"verification_tokens",
+	"account_status_history",
+	"transfer_limits",
+	"customer_transfer_limits",
+	"scheduled_transfers",
+	"scheduled_transfer_occurrences",
+	"fee_schedules",
+	"interest_rates",
+	"interest_accruals",
+	"interest_capitalizations",
+}
+
+// ErrSystemAccountNotFound is returned when no system account exists for a currency
+var ErrSystemAccountNotFound = errors.New("system account not found for currency")
+
+// SystemAccounts is the set of system accounts guaranteed to exist after Initialize
+type SystemAccounts struct {
+	equity map[string]*model.Account
+}
+
+// EquityAccount returns the bank equity account for a currency
+func (s *SystemAccounts) EquityAccount(currency string) (*model.Account, error) {
+	account, ok := s.equity[currency]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSystemAccountNotFound, currency)
+	}
+	return account, nil
+}
+
+// Currencies returns the currencies that have system accounts, sorted
+func (s *SystemAccounts) Currencies() []string {
+	currencies := make([]string, 0, len(s.equity))
+	for currency := range s.equity {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// ParseCurrencies parses a comma-separated currency list such as "NOK,EUR,USD"
+// Every currency must be supported by model.Money
+func ParseCurrencies(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		value = DefaultCurrencies
+	}
+
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, part := range strings.Split(value, ",") {
+		currency := strings.ToUpper(strings.TrimSpace(part))
+		if currency == "" || seen[currency] {
+			continue
+		}
+		if !model.IsSupportedCurrency(currency) {
+			return nil, fmt.Errorf("%w: %s", model.ErrUnsupportedCurrency, currency)
+		}
+		seen[currency] = true
+		currencies = append(currencies, currency)
+	}
+
+	if len(currencies) == 0 {
+		return nil, errors.New("at least one system currency is required")
+	}
+
+	return currencies, nil
+}
+
+// Initialize ensures all required system accounts exist: an equity and a revenue account per currency
 // This should be called on server startup after database connection is established
-func Initialize(ctx context.Context, db *pgxpool.Pool) error {
-	if err := ensureEquityAccount(ctx, db); err != nil {
-		return fmt.Errorf("failed to ensure equity account: %w", err)
+// Fails fast if the schema has not been migrated
+func Initialize(ctx context.Context, db *pgxpool.Pool, currencies []string) (*SystemAccounts, error) {
+	if err := checkSchema(ctx, db); err != nil {
+		return nil, err
 	}
 
-	return nil
+	accounts := &SystemAccounts{equity: make(map[string]*model.Account)}
+	for _, currency := range currencies {
+		account, err := ensureEquityAccount(ctx, db, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to ensure %s equity account: %w", currency, err)
+		}
+		accounts.equity[currency] = account
+
+		if err := ensureRevenueAccount(ctx, db, currency); err != nil {
+			return nil, fmt.Errorf("failed to ensure %s revenue account: %w", currency, err)
+		}
+	}
+
+	return accounts, nil
 }
 
-// ensureEquityAccount creates the bank equity account if it doesn't exist
-func ensureEquityAccount(ctx context.Context, db *pgxpool.Pool) error {
-	// Check if equity account already exists
-	var exists bool
-	err := db.QueryRow(ctx, `
-		SELECT EXISTS(SELECT 1 FROM accounts WHERE account_number = $1)
-	`, model.BankEquityAccountNumber).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("failed to check for equity account: %w", err)
+// checkSchema verifies that all required tables exist
+func checkSchema(ctx context.Context, db *pgxpool.Pool) error {
+	for _, table := range requiredTables {
+		var exists bool
+		err := db.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check schema: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("database schema is missing table %q - run migrations first", table)
+		}
 	}
+	return nil
+}
 
-	if exists {
-		log.Printf("Bank equity account %s already exists", model.BankEquityAccountNumber)
-		return nil
+// ensureEquityAccount creates the bank equity account for a currency if it doesn't exist
+// Safe to run concurrently from the API and worker: the insert is a no-op on conflict
+func ensureEquityAccount(ctx context.Context, db *pgxpool.Pool, currency string) (*model.Account, error) {
+	if err := ensureSystemAccount(ctx, db, model.AccountTypeEquity, model.EquityAccountNumber(currency), currency); err != nil {
+		return nil, err
 	}
+	return GetEquityAccount(ctx, db, currency)
+}
 
-	// Create the equity account
+// ensureRevenueAccount creates the bank revenue account for a currency if it doesn't exist
+// Transfer fees are paid into it; the repository finds it by its account number
+func ensureRevenueAccount(ctx context.Context, db *pgxpool.Pool, currency string) error {
+	return ensureSystemAccount(ctx, db, model.AccountTypeRevenue, model.RevenueAccountNumber(currency), currency)
+}
+
+// ensureSystemAccount inserts a system account unless one with its number already exists
+func ensureSystemAccount(ctx context.Context, db *pgxpool.Pool, accountType model.AccountType, accountNumber, currency string) error {
 	now := time.Now()
-	_, err = db.Exec(ctx, `
+	result, err := db.Exec(ctx, `
 		INSERT INTO accounts (id, account_number, account_type, currency, status, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING
 	`,
 		uuid.New(),
-		model.BankEquityAccountNumber,
-		model.AccountTypeEquity,
-		"NOK", // Default currency for the bank
+		accountNumber,
+		accountType,
+		currency,
 		model.AccountStatusActive,
 		now,
 		now,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create equity account: %w", err)
+		return fmt.Errorf("failed to create %s account: %w", accountType, err)
+	}
+
+	if result.RowsAffected() > 0 {
+		log.Printf("Created bank %s account: %s", accountType, accountNumber)
+	} else {
+		log.Printf("Bank %s account %s already exists", accountType, accountNumber)
 	}
 
-	log.Printf("Created bank equity account: %s", model.BankEquityAccountNumber)
 	return nil
 }
 
-// GetEquityAccount retrieves the bank equity account
-func GetEquityAccount(ctx context.Context, db *pgxpool.Pool) (*model.Account, error) {
+// GetEquityAccount retrieves the bank equity account for a currency
+func GetEquityAccount(ctx context.Context, db *pgxpool.Pool, currency string) (*model.Account, error) {
 	query := `
 		SELECT id, account_number, account_type, currency, status, created_at, updated_at
 		FROM accounts
@@ -70,7 +190,7 @@ func GetEquityAccount(ctx context.Context, db *pgxpool.Pool) (*model.Account, er
 	`
 
 	account := &model.Account{}
-	err := db.QueryRow(ctx, query, model.BankEquityAccountNumber).Scan(
+	err := db.QueryRow(ctx, query, model.EquityAccountNumber(currency)).Scan(
 		&account.ID,
 		&account.AccountNumber,
 		&account.AccountType,
@@ -80,8 +200,12 @@ func GetEquityAccount(ctx context.Context, db *pgxpool.Pool) (*model.Account, er
 		&account.UpdatedAt,
 	)
 	if err != nil {
-		return nil, errors.New("bank equity account not found - run Initialize first")
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s - run Initialize first", ErrSystemAccountNotFound, currency)
+		}
+		return nil, fmt.Errorf("failed to get equity account: %w", err)
 	}
 
+	account.OverdraftLimit = model.ZeroMoney(account.Currency)
 	return account, nil
 }
@@ -0,0 +1,72 @@
+package bootstrap
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func TestParseCurrencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "default when empty",
+			value: "",
+			want:  []string{"NOK", "EUR", "USD"},
+		},
+		{
+			name:  "trims, uppercases and de-duplicates",
+			value: " nok, EUR ,nok,,jpy",
+			want:  []string{"NOK", "EUR", "JPY"},
+		},
+		{
+			name:    "unsupported currency",
+			value:   "NOK,XYZ",
+			wantErr: true,
+		},
+		{
+			name:    "only separators",
+			value:   ",,",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCurrencies(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCurrencies(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCurrencies(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSystemAccounts_EquityAccount(t *testing.T) {
+	nok := &model.Account{ID: uuid.New(), AccountNumber: model.EquityAccountNumber("NOK"), Currency: "NOK"}
+	eur := &model.Account{ID: uuid.New(), AccountNumber: model.EquityAccountNumber("EUR"), Currency: "EUR"}
+	accounts := &SystemAccounts{equity: map[string]*model.Account{"NOK": nok, "EUR": eur}}
+
+	got, err := accounts.EquityAccount("EUR")
+	if err != nil || got != eur {
+		t.Errorf("EquityAccount(EUR) = %v, %v; want EUR equity account", got, err)
+	}
+
+	if _, err := accounts.EquityAccount("USD"); !errors.Is(err, ErrSystemAccountNotFound) {
+		t.Errorf("EquityAccount(USD) error = %v, want ErrSystemAccountNotFound", err)
+	}
+
+	if got := accounts.Currencies(); !reflect.DeepEqual(got, []string{"EUR", "NOK"}) {
+		t.Errorf("Currencies() = %v, want [EUR NOK]", got)
+	}
+}
@@ -3,7 +3,10 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -16,12 +19,14 @@ import (
 
 // AccountHandler handles HTTP requests for accounts
 type AccountHandler struct {
-	repo *repository.AccountRepository
+	repo       *repository.AccountRepository
+	ledgerRepo *repository.LedgerRepository
+	limitRepo  *repository.LimitRepository
 }
 
 // NewAccountHandler creates a new AccountHandler
-func NewAccountHandler(repo *repository.AccountRepository) *AccountHandler {
-	return &AccountHandler{repo: repo}
+func NewAccountHandler(repo *repository.AccountRepository, ledgerRepo *repository.LedgerRepository, limitRepo *repository.LimitRepository) *AccountHandler {
+	return &AccountHandler{repo: repo, ledgerRepo: ledgerRepo, limitRepo: limitRepo}
 }
 
 // RegisterRoutes sets up the account routes on the given router
@@ -29,8 +34,15 @@ func (h *AccountHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/accounts", func(r chi.Router) {
 		r.Post("/", h.Create)
 		r.Get("/", h.List)
+		r.Get("/lookup", h.Lookup)
 		r.Get("/{id}", h.GetByID)
 		r.Get("/{id}/balance", h.GetBalance)
+		r.Get("/{id}/transactions", h.ListTransactions)
+		r.Post("/{id}/freeze", h.Freeze)
+		r.Post("/{id}/unfreeze", h.Unfreeze)
+		r.Post("/{id}/close", h.Close)
+		r.Get("/{id}/status-history", h.GetStatusHistory)
+		r.Get("/{id}/limits", h.GetLimits)
 	})
 }
 
@@ -88,6 +100,42 @@ func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, accounts)
 }
 
+// Lookup handles GET /accounts/lookup?account_number=...
+// Accepts an account number or IBAN and returns the masked holder name,
+// so a customer can confirm who they are paying before sending a transfer
+func (h *AccountHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	number := r.URL.Query().Get("account_number")
+	if number == "" {
+		writeError(w, http.StatusBadRequest, "account_number is required")
+		return
+	}
+
+	account, err := h.repo.GetByAccountNumber(r.Context(), number)
+	if err == nil {
+		var holder *model.AccountHolder
+		holder, err = h.repo.GetHolder(r.Context(), account.ID)
+		if err == nil {
+			writeJSON(w, http.StatusOK, holder)
+			return
+		}
+	}
+
+	switch {
+	case errors.Is(err, model.ErrInvalidAccountNumber):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrAccountNotFound):
+		writeError(w, http.StatusNotFound, "Account not found")
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to look up account")
+	}
+}
+
 // GetByID handles GET /accounts/{id}
 // Verifies the account belongs to the authenticated customer
 func (h *AccountHandler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -182,6 +230,121 @@ func (h *AccountHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, balance)
 }
 
+// ListTransactions handles GET /accounts/{id}/transactions
+// Query parameters (all optional):
+//   - from, to: ISO 8601 timestamps bounding the ledger line time
+//   - direction: debit or credit
+//   - status: transaction status
+//   - min_amount, max_amount: bounds on the absolute line amount
+//   - limit: page size (default 50, max 100)
+//   - cursor: next_cursor from a previous page
+//
+// Verifies the account belongs to the authenticated customer
+func (h *AccountHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	idParam := chi.URLParam(r, "id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid account ID format")
+		return
+	}
+
+	account, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrAccountNotFound) {
+			writeError(w, http.StatusNotFound, "Account not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get account")
+		return
+	}
+
+	// Authorization check
+	if account.CustomerID == nil || *account.CustomerID != customerID {
+		writeError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	filter, err := parseHistoryFilter(r.URL.Query(), account.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.ledgerRepo.ListAccountHistory(r.Context(), id, filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list transactions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// parseHistoryFilter builds a history filter from query parameters
+// Amounts are parsed in the account's currency
+func parseHistoryFilter(q url.Values, currency string) (model.TransactionHistoryFilter, error) {
+	var filter model.TransactionHistoryFilter
+
+	parseTime := func(name string) (*time.Time, error) {
+		v := q.Get(name)
+		if v == "" {
+			return nil, nil
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s format: use ISO 8601 (e.g., 2024-12-13T10:00:00Z)", name)
+		}
+		return &t, nil
+	}
+	parseAmount := func(name string) (*model.Money, error) {
+		v := q.Get(name)
+		if v == "" {
+			return nil, nil
+		}
+		m, err := model.ParseMoney(v, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return &m, nil
+	}
+
+	var err error
+	if filter.From, err = parseTime("from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTime("to"); err != nil {
+		return filter, err
+	}
+	if filter.MinAmount, err = parseAmount("min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseAmount("max_amount"); err != nil {
+		return filter, err
+	}
+
+	filter.Direction = model.LedgerEntryType(q.Get("direction"))
+	filter.Status = model.TransactionStatus(q.Get("status"))
+
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			return filter, model.ErrInvalidLimit
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		if filter.Cursor, err = model.DecodeHistoryCursor(v); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, filter.Validate()
+}
+
 // Helper functions for HTTP responses
 
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/middleware"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// Freeze handles POST /accounts/{id}/freeze
+// The customer can lift their own freeze with unfreeze
+func (h *AccountHandler) Freeze(w http.ResponseWriter, r *http.Request) {
+	account, customerID, ok := h.ownAccount(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.AccountStatusRequest
+	if !decodeStatusRequest(w, r, &req) {
+		return
+	}
+	if err := req.Validate(model.StatusActorCustomer); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := h.repo.Freeze(r.Context(), account.ID, customerStatusChange(customerID, req.Reason))
+	if err != nil {
+		writeStatusChangeError(w, err, "Failed to freeze account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// Unfreeze handles POST /accounts/{id}/unfreeze
+// Only works if the customer froze the account; a freeze placed by the bank returns 403
+func (h *AccountHandler) Unfreeze(w http.ResponseWriter, r *http.Request) {
+	account, customerID, ok := h.ownAccount(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.AccountStatusRequest
+	if !decodeStatusRequest(w, r, &req) {
+		return
+	}
+	if err := req.Validate(model.StatusActorCustomer); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := h.repo.Unfreeze(r.Context(), account.ID, customerStatusChange(customerID, req.Reason))
+	if err != nil {
+		writeStatusChangeError(w, err, "Failed to unfreeze account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// Close handles POST /accounts/{id}/close
+// A positive balance requires sweep_to_account_id: another active account of the customer in the same currency
+func (h *AccountHandler) Close(w http.ResponseWriter, r *http.Request) {
+	account, customerID, ok := h.ownAccount(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CloseAccountRequest
+	if !decodeStatusRequest(w, r, &req) {
+		return
+	}
+	if err := req.Validate(model.StatusActorCustomer); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	closure, err := h.repo.Close(r.Context(), account.ID, req.SweepToAccountID, customerStatusChange(customerID, req.Reason))
+	if err != nil {
+		writeStatusChangeError(w, err, "Failed to close account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, closure)
+}
+
+// GetStatusHistory handles GET /accounts/{id}/status-history
+func (h *AccountHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.ownAccount(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.repo.ListStatusHistory(r.Context(), account.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get status history")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+// GetLimits handles GET /accounts/{id}/limits
+// Reports the account's transfer limits and how much of each is left, counting in-flight transfers
+func (h *AccountHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	account, _, ok := h.ownAccount(w, r)
+	if !ok {
+		return
+	}
+
+	headroom, err := h.limitRepo.GetHeadroom(r.Context(), account)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get transfer limits")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, headroom)
+}
+
+// ownAccount loads the account in the URL and checks it belongs to the authenticated customer
+// Writes the error response and returns false if not
+func (h *AccountHandler) ownAccount(w http.ResponseWriter, r *http.Request) (*model.Account, uuid.UUID, bool) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return nil, uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid account ID format")
+		return nil, uuid.Nil, false
+	}
+
+	account, err := h.repo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrAccountNotFound) {
+			writeError(w, http.StatusNotFound, "Account not found")
+			return nil, uuid.Nil, false
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get account")
+		return nil, uuid.Nil, false
+	}
+
+	if account.CustomerID == nil || *account.CustomerID != customerID {
+		writeError(w, http.StatusForbidden, "Access denied")
+		return nil, uuid.Nil, false
+	}
+
+	return account, customerID, true
+}
+
+// customerStatusChange records a status change made by the customer themselves
+func customerStatusChange(customerID uuid.UUID, reason string) model.StatusChange {
+	return model.StatusChange{
+		Actor:      model.StatusActorCustomer,
+		CustomerID: &customerID,
+		Reason:     reason,
+	}
+}
+
+// decodeStatusRequest decodes an optional JSON body; an empty body leaves req unchanged
+func decodeStatusRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	return true
+}
+
+// writeStatusChangeError maps account lifecycle errors to HTTP responses
+func writeStatusChangeError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case model.ErrAccountNotFound:
+		writeError(w, http.StatusNotFound, "Account not found")
+	case model.ErrAccountFrozenByBank, model.ErrSystemAccountStatus:
+		writeError(w, http.StatusForbidden, err.Error())
+	case model.ErrAccountFrozen, model.ErrAccountClosed, model.ErrAccountNotFrozen,
+		model.ErrBalanceNotZero, model.ErrAccountHasPendingTransactions:
+		writeError(w, http.StatusConflict, err.Error())
+	case model.ErrInvalidSweepAccount:
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func TestParseHistoryFilter(t *testing.T) {
+	cursor := model.HistoryCursor{CreatedAt: time.Now().UTC(), EntryID: uuid.New()}
+
+	q := url.Values{}
+	q.Set("from", "2025-01-01T00:00:00Z")
+	q.Set("to", "2025-02-01T00:00:00Z")
+	q.Set("direction", "credit")
+	q.Set("status", "completed")
+	q.Set("min_amount", "10")
+	q.Set("max_amount", "250.50")
+	q.Set("limit", "20")
+	q.Set("cursor", cursor.Encode())
+
+	filter, err := parseHistoryFilter(q, "NOK")
+	if err != nil {
+		t.Fatalf("parseHistoryFilter() error = %v", err)
+	}
+
+	if filter.From == nil || filter.From.Month() != time.January {
+		t.Errorf("From = %v, want 2025-01-01", filter.From)
+	}
+	if filter.To == nil || filter.To.Month() != time.February {
+		t.Errorf("To = %v, want 2025-02-01", filter.To)
+	}
+	if filter.Direction != model.LedgerEntryTypeCredit {
+		t.Errorf("Direction = %v, want credit", filter.Direction)
+	}
+	if filter.Status != model.TransactionStatusCompleted {
+		t.Errorf("Status = %v, want completed", filter.Status)
+	}
+	if filter.MinAmount == nil || filter.MinAmount.String() != "10.00" {
+		t.Errorf("MinAmount = %v, want 10.00", filter.MinAmount)
+	}
+	if filter.MaxAmount == nil || filter.MaxAmount.String() != "250.50" {
+		t.Errorf("MaxAmount = %v, want 250.50", filter.MaxAmount)
+	}
+	if filter.Limit != 20 {
+		t.Errorf("Limit = %d, want 20", filter.Limit)
+	}
+	if filter.Cursor == nil || filter.Cursor.EntryID != cursor.EntryID {
+		t.Errorf("Cursor = %v, want %v", filter.Cursor, cursor)
+	}
+}
+
+func TestParseHistoryFilter_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "bad from", query: "from=yesterday"},
+		{name: "bad direction", query: "direction=up"},
+		{name: "bad status", query: "status=done"},
+		{name: "amount too precise", query: "min_amount=1.001"},
+		{name: "min above max", query: "min_amount=100&max_amount=10"},
+		{name: "zero limit", query: "limit=0"},
+		{name: "non-numeric limit", query: "limit=ten"},
+		{name: "bad cursor", query: "cursor=garbage!"},
+		{name: "inverted date range", query: "from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+			if _, err := parseHistoryFilter(q, "NOK"); err == nil {
+				t.Errorf("parseHistoryFilter(%q) error = nil, want error", tt.query)
+			}
+		})
+	}
+}
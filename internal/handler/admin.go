@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/repository"
+)
+
+// AdminHandler handles back-office HTTP requests
+// Routes must be mounted behind middleware.RequireAdminToken
+type AdminHandler struct {
+	accountRepo   *repository.AccountRepository
+	txRepo        *repository.TransactionRepository
+	limitRepo     *repository.LimitRepository
+	scheduledRepo *repository.ScheduledTransferRepository
+	feeRepo       *repository.FeeRepository
+	interestRepo  *repository.InterestRepository
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(accountRepo *repository.AccountRepository, txRepo *repository.TransactionRepository, limitRepo *repository.LimitRepository, scheduledRepo *repository.ScheduledTransferRepository, feeRepo *repository.FeeRepository, interestRepo *repository.InterestRepository) *AdminHandler {
+	return &AdminHandler{accountRepo: accountRepo, txRepo: txRepo, limitRepo: limitRepo, scheduledRepo: scheduledRepo, feeRepo: feeRepo, interestRepo: interestRepo}
+}
+
+// RegisterRoutes sets up the admin routes
+func (h *AdminHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/accounts/{id}", func(r chi.Router) {
+		r.Post("/freeze", h.FreezeAccount)
+		r.Post("/unfreeze", h.UnfreezeAccount)
+		r.Post("/close", h.CloseAccount)
+		r.Get("/status-history", h.GetAccountStatusHistory)
+		r.Get("/limits", h.GetAccountLimits)
+		r.Put("/overdraft", h.SetOverdraft)
+	})
+	r.Get("/overdrafts", h.ListOverdrawn)
+	r.Route("/transfer-limits", func(r chi.Router) {
+		r.Get("/", h.ListDefaultLimits)
+		r.Put("/{accountType}/{currency}", h.SetDefaultLimits)
+	})
+	r.Route("/fee-schedules", func(r chi.Router) {
+		r.Get("/", h.ListFeeSchedules)
+		r.Put("/{accountType}/{currency}", h.SetFeeSchedule)
+		r.Delete("/{accountType}/{currency}", h.DeleteFeeSchedule)
+	})
+	r.Route("/interest-rates", func(r chi.Router) {
+		r.Get("/", h.ListInterestRates)
+		r.Put("/{accountType}/{currency}", h.SetInterestRate)
+	})
+	r.Route("/customers/{id}/transfer-limits", func(r chi.Router) {
+		r.Get("/", h.ListLimitOverrides)
+		r.Put("/{currency}", h.SetLimitOverride)
+		r.Delete("/{currency}", h.DeleteLimitOverride)
+	})
+	r.Get("/scheduled-transfers/failed", h.ListFailedScheduledTransfers)
+	r.Post("/transactions/{id}/reverse", h.ReverseTransaction)
+}
+
+// FreezeAccount handles POST /admin/accounts/{id}/freeze
+// A reason is required; the customer cannot lift an admin freeze
+func (h *AdminHandler) FreezeAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.AccountStatusRequest
+	if !decodeStatusRequest(w, r, &req) {
+		return
+	}
+	if err := req.Validate(model.StatusActorAdmin); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.accountRepo.Freeze(r.Context(), id, adminStatusChange(req.Reason))
+	if err != nil {
+		writeStatusChangeError(w, err, "Failed to freeze account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// UnfreezeAccount handles POST /admin/accounts/{id}/unfreeze
+// Lifts any freeze, including one the customer placed
+func (h *AdminHandler) UnfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.AccountStatusRequest
+	if !decodeStatusRequest(w, r, &req) {
+		return
+	}
+	if err := req.Validate(model.StatusActorAdmin); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.accountRepo.Unfreeze(r.Context(), id, adminStatusChange(req.Reason))
+	if err != nil {
+		writeStatusChangeError(w, err, "Failed to unfreeze account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// CloseAccount handles POST /admin/accounts/{id}/close
+// Unlike customers, admins can close a frozen account
+func (h *AdminHandler) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.CloseAccountRequest
+	if !decodeStatusRequest(w, r, &req) {
+		return
+	}
+	if err := req.Validate(model.StatusActorAdmin); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	closure, err := h.accountRepo.Close(r.Context(), id, req.SweepToAccountID, adminStatusChange(req.Reason))
+	if err != nil {
+		writeStatusChangeError(w, err, "Failed to close account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, closure)
+}
+
+// GetAccountStatusHistory handles GET /admin/accounts/{id}/status-history
+func (h *AdminHandler) GetAccountStatusHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.accountRepo.ListStatusHistory(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get status history")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, history)
+}
+
+// parseAccountID reads the account ID from the URL, writing a 400 if it is malformed
+func parseAccountID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid account ID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// adminStatusChange records a status change made through the admin API
+func adminStatusChange(reason string) model.StatusChange {
+	return model.StatusChange{Actor: model.StatusActorAdmin, Reason: reason}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// ListFeeSchedules handles GET /admin/fee-schedules
+func (h *AdminHandler) ListFeeSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.feeRepo.ListSchedules(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list fee schedules")
+		return
+	}
+	if schedules == nil {
+		schedules = []model.FeeSchedule{}
+	}
+
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+// SetFeeSchedule handles PUT /admin/fee-schedules/{accountType}/{currency}
+// Applies to transfers created from now on; pending transfers keep the fee they were quoted
+func (h *AdminHandler) SetFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	accountType, ok := feeAccountType(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.SetFeeScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	schedule, err := req.Parse(accountType, limitCurrency(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	saved, err := h.feeRepo.SetSchedule(r.Context(), schedule)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to set fee schedule")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// DeleteFeeSchedule handles DELETE /admin/fee-schedules/{accountType}/{currency}
+// Transfers from that account type and currency are free afterwards
+func (h *AdminHandler) DeleteFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	accountType, ok := feeAccountType(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.feeRepo.DeleteSchedule(r.Context(), accountType, limitCurrency(r)); err != nil {
+		if errors.Is(err, model.ErrFeeScheduleNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to delete fee schedule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// feeAccountType reads the account type from the URL and checks it is one customers can open,
+// writing a 400 if not
+func feeAccountType(w http.ResponseWriter, r *http.Request) (model.AccountType, bool) {
+	accountType := model.AccountType(chi.URLParam(r, "accountType"))
+	if err := (model.CreateAccountRequest{AccountType: accountType, Currency: limitCurrency(r)}).Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return "", false
+	}
+	return accountType, true
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// ListInterestRates handles GET /admin/interest-rates
+// Returns past and future rates, so the history of each account type and currency can be seen
+func (h *AdminHandler) ListInterestRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.interestRepo.ListRates(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list interest rates")
+		return
+	}
+	if rates == nil {
+		rates = []model.InterestRate{}
+	}
+
+	writeJSON(w, http.StatusOK, rates)
+}
+
+// SetInterestRate handles PUT /admin/interest-rates/{accountType}/{currency}
+// The rate applies from effective_from, today or later, until the next rate takes effect;
+// setting it again for the same date replaces it. A rate of "0" stops interest.
+func (h *AdminHandler) SetInterestRate(w http.ResponseWriter, r *http.Request) {
+	accountType := model.AccountType(chi.URLParam(r, "accountType"))
+
+	var req model.SetInterestRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rate, err := req.Parse(accountType, limitCurrency(r), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	saved, err := h.interestRepo.SetRate(r.Context(), rate)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to set interest rate")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
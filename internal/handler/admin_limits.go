@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// GetAccountLimits handles GET /admin/accounts/{id}/limits
+func (h *AdminHandler) GetAccountLimits(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.accountRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrAccountNotFound) {
+			writeError(w, http.StatusNotFound, "Account not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get account")
+		return
+	}
+
+	headroom, err := h.limitRepo.GetHeadroom(r.Context(), account)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get transfer limits")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, headroom)
+}
+
+// ListDefaultLimits handles GET /admin/transfer-limits
+func (h *AdminHandler) ListDefaultLimits(w http.ResponseWriter, r *http.Request) {
+	defaults, err := h.limitRepo.ListDefaults(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list transfer limits")
+		return
+	}
+	if defaults == nil {
+		defaults = []model.TransferLimitDefault{}
+	}
+
+	writeJSON(w, http.StatusOK, defaults)
+}
+
+// SetDefaultLimits handles PUT /admin/transfer-limits/{accountType}/{currency}
+// Omitted or null limits mean no cap for that period
+func (h *AdminHandler) SetDefaultLimits(w http.ResponseWriter, r *http.Request) {
+	accountType := model.AccountType(chi.URLParam(r, "accountType"))
+	if err := (model.CreateAccountRequest{AccountType: accountType, Currency: limitCurrency(r)}).Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limits, _, ok := decodeLimitsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	d, err := h.limitRepo.SetDefault(r.Context(), accountType, limitCurrency(r), limits)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to set transfer limits")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+// ListLimitOverrides handles GET /admin/customers/{id}/transfer-limits
+func (h *AdminHandler) ListLimitOverrides(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := parseCustomerID(w, r)
+	if !ok {
+		return
+	}
+
+	overrides, err := h.limitRepo.ListOverrides(r.Context(), customerID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list transfer limit overrides")
+		return
+	}
+	if overrides == nil {
+		overrides = []model.TransferLimitOverride{}
+	}
+
+	writeJSON(w, http.StatusOK, overrides)
+}
+
+// SetLimitOverride handles PUT /admin/customers/{id}/transfer-limits/{currency}
+// A reason is required; omitted or null limits fall back to the account type's default
+func (h *AdminHandler) SetLimitOverride(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := parseCustomerID(w, r)
+	if !ok {
+		return
+	}
+
+	limits, reason, ok := decodeLimitsRequest(w, r)
+	if !ok {
+		return
+	}
+	if reason == "" {
+		writeError(w, http.StatusBadRequest, model.ErrStatusReasonRequired.Error())
+		return
+	}
+
+	override, err := h.limitRepo.SetOverride(r.Context(), customerID, limitCurrency(r), limits, reason)
+	if err != nil {
+		if errors.Is(err, model.ErrCustomerNotFound) {
+			writeError(w, http.StatusNotFound, "Customer not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to set transfer limit override")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, override)
+}
+
+// DeleteLimitOverride handles DELETE /admin/customers/{id}/transfer-limits/{currency}
+func (h *AdminHandler) DeleteLimitOverride(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := parseCustomerID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.limitRepo.DeleteOverride(r.Context(), customerID, limitCurrency(r)); err != nil {
+		if errors.Is(err, model.ErrTransferLimitNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to delete transfer limit override")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeLimitsRequest decodes and validates a SetTransferLimitsRequest in the URL's currency
+func decodeLimitsRequest(w http.ResponseWriter, r *http.Request) (model.TransferLimits, string, bool) {
+	var req model.SetTransferLimitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return model.TransferLimits{}, "", false
+	}
+
+	limits, err := req.Parse(limitCurrency(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return model.TransferLimits{}, "", false
+	}
+	return limits, req.Reason, true
+}
+
+// limitCurrency reads the currency from the URL, upper-cased
+func limitCurrency(r *http.Request) string {
+	return strings.ToUpper(chi.URLParam(r, "currency"))
+}
+
+// parseCustomerID reads the customer ID from the URL, writing a 400 if it is malformed
+func parseCustomerID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid customer ID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// SetOverdraft handles PUT /admin/accounts/{id}/overdraft
+// Sets the arranged overdraft of a checking account; "0" removes it
+func (h *AdminHandler) SetOverdraft(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.SetOverdraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	account, err := h.accountRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrAccountNotFound) {
+			writeError(w, http.StatusNotFound, "Account not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get account")
+		return
+	}
+
+	limit, err := req.Parse(account.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := h.accountRepo.SetOverdraftLimit(r.Context(), id, limit)
+	if err != nil {
+		switch err {
+		case model.ErrAccountNotFound:
+			writeError(w, http.StatusNotFound, "Account not found")
+		case model.ErrOverdraftNotAllowed:
+			writeError(w, http.StatusBadRequest, err.Error())
+		case model.ErrAccountClosed:
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to set overdraft limit")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// ListOverdrawn handles GET /admin/overdrafts
+// Reports every customer account with a negative balance, flagging those beyond their limit
+func (h *AdminHandler) ListOverdrawn(w http.ResponseWriter, r *http.Request) {
+	overdrawn, err := h.accountRepo.ListOverdrawn(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list overdrawn accounts")
+		return
+	}
+	if overdrawn == nil {
+		overdrawn = []model.OverdrawnAccount{}
+	}
+
+	writeJSON(w, http.StatusOK, overdrawn)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// ReverseTransaction handles POST /admin/transactions/{id}/reverse
+// Books a reversal with mirrored ledger entries and returns it; the original moves to status reversed
+// A reason is required and is stored in the reversal's metadata. A reversal that would overdraw a customer
+// account, e.g. because the destination has spent the money, needs force.
+func (h *AdminHandler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid transaction ID format")
+		return
+	}
+
+	var req model.ReverseTransactionRequest
+	if !decodeStatusRequest(w, r, &req) {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reversal, err := h.txRepo.Reverse(r.Context(), id, req.Reason, req.Force)
+	if err != nil {
+		switch err {
+		case model.ErrTransactionNotFound:
+			writeError(w, http.StatusNotFound, "Transaction not found")
+		case model.ErrNotReversible, model.ErrAccountClosed:
+			writeError(w, http.StatusConflict, err.Error())
+		case model.ErrInsufficientFunds:
+			writeError(w, http.StatusConflict, "Reversal would overdraw an account; set force to book it anyway")
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to reverse transaction")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reversal)
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// ListFailedScheduledTransfers handles GET /admin/scheduled-transfers/failed
+// Reports failed occurrences of every customer's standing orders, newest first
+// Query parameters: since (ISO 8601, default 30 days ago) and limit (default 100, max 500)
+func (h *AdminHandler) ListFailedScheduledTransfers(w http.ResponseWriter, r *http.Request) {
+	since, limit, err := parseFailedFilter(r.URL.Query(), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	failed, err := h.scheduledRepo.ListFailed(r.Context(), nil, since, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list failed scheduled transfers")
+		return
+	}
+	if failed == nil {
+		failed = []model.FailedScheduledOccurrence{}
+	}
+
+	writeJSON(w, http.StatusOK, failed)
+}
@@ -2,11 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 
 	"github.com/simonkvalheim/hm9-banking/internal/auth"
+	"github.com/simonkvalheim/hm9-banking/internal/middleware"
 	"github.com/simonkvalheim/hm9-banking/internal/model"
 )
 
@@ -24,8 +27,18 @@ func NewAuthHandler(authService *auth.Service) *AuthHandler {
 func (h *AuthHandler) RegisterRoutes(r chi.Router) {
 	r.Post("/auth/register", h.Register)
 	r.Post("/auth/login", h.Login)
+	r.Post("/auth/login/mfa", h.LoginMFA)
 	r.Post("/auth/refresh", h.RefreshToken)
 	r.Post("/auth/logout", h.Logout)
+	r.Post("/auth/verify-email/request", h.RequestEmailVerification)
+	r.Post("/auth/verify-email", h.VerifyEmail)
+	r.Post("/auth/password-reset/request", h.RequestPasswordReset)
+	r.Post("/auth/password-reset", h.ResetPassword)
+}
+
+// RegisterProtectedRoutes sets up auth routes that require an access token
+func (h *AuthHandler) RegisterProtectedRoutes(r chi.Router) {
+	r.Post("/auth/logout-all", h.LogoutAll)
 }
 
 // Register handles POST /auth/register
@@ -63,7 +76,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.authService.Login(r.Context(), req)
+	result, err := h.authService.Login(r.Context(), req, clientInfo(r))
 	if err != nil {
 		switch err {
 		case model.ErrInvalidCredentials:
@@ -78,6 +91,50 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Two-factor enabled: the client must finish at /auth/login/mfa
+	if result.MFARequired {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+			"expires_at":   result.MFAExpiresAt,
+		})
+		return
+	}
+
+	writeLoginTokens(w, result.Tokens)
+}
+
+// LoginMFA handles POST /auth/login/mfa
+// Exchanges the challenge token from Login plus a TOTP or recovery code for tokens
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req model.MFALoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tokens, err := h.authService.CompleteMFALogin(r.Context(), req, clientInfo(r))
+	if err != nil {
+		switch err {
+		case model.ErrMFACodeRequired:
+			writeError(w, http.StatusBadRequest, err.Error())
+		case model.ErrInvalidMFAToken, model.ErrInvalidMFACode:
+			writeError(w, http.StatusUnauthorized, err.Error())
+		case model.ErrAccountLocked:
+			writeError(w, http.StatusForbidden, "Account is temporarily locked")
+		case model.ErrAccountSuspended:
+			writeError(w, http.StatusForbidden, "Account is suspended")
+		default:
+			writeError(w, http.StatusInternalServerError, "Login failed")
+		}
+		return
+	}
+
+	writeLoginTokens(w, tokens)
+}
+
+// writeLoginTokens sets the refresh token cookie and returns the access token
+func writeLoginTokens(w http.ResponseWriter, tokens *auth.TokenPair) {
 	// Set refresh token as HttpOnly cookie
 	http.SetCookie(w, &http.Cookie{
 		Name:     "refresh_token",
@@ -106,7 +163,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.authService.RefreshTokens(r.Context(), cookie.Value)
+	tokens, err := h.authService.RefreshTokens(r.Context(), cookie.Value, clientInfo(r))
 	if err != nil {
 		// Clear invalid cookie
 		http.SetCookie(w, &http.Cookie{
@@ -139,7 +196,15 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 }
 
 // Logout handles POST /auth/logout
+// Revokes the refresh token server-side so it cannot be used again, even if it was copied
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie("refresh_token"); err == nil && cookie.Value != "" {
+		if err := h.authService.Logout(r.Context(), cookie.Value); err != nil {
+			writeError(w, http.StatusInternalServerError, "Logout failed")
+			return
+		}
+	}
+
 	// Clear the refresh token cookie
 	http.SetCookie(w, &http.Cookie{
 		Name:     "refresh_token",
@@ -155,3 +220,138 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		"message": "Logged out successfully",
 	})
 }
+
+// LogoutAll handles POST /v1/auth/logout-all
+// Revokes every refresh token the customer holds, signing out all devices
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	revoked, err := h.authService.LogoutAll(r.Context(), customerID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Logout failed")
+		return
+	}
+
+	// Clear this device's cookie too
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   false, // Set to true in production with HTTPS
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   -1, // Delete cookie
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"message":          "Logged out of all sessions",
+		"sessions_revoked": revoked,
+	})
+}
+
+// RequestEmailVerification handles POST /auth/verify-email/request
+// Always answers 202 so the response does not reveal whether the email is registered
+func (h *AuthHandler) RequestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	var req model.EmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.authService.RequestEmailVerification(r.Context(), req.Email); err != nil {
+		log.Printf("Failed to send verification email: %v", err)
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If the address is registered and not yet verified, a verification link has been sent",
+	})
+}
+
+// VerifyEmail handles POST /auth/verify-email
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req model.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.authService.VerifyEmail(r.Context(), req.Token); err != nil {
+		switch err {
+		case model.ErrVerificationTokenInvalid:
+			writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to verify email")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"email_verified": true})
+}
+
+// RequestPasswordReset handles POST /auth/password-reset/request
+// Always answers 202 so the response does not reveal whether the email is registered
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req model.EmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		log.Printf("Failed to send password reset email: %v", err)
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "If the address is registered, a password reset link has been sent",
+	})
+}
+
+// ResetPassword handles POST /auth/password-reset
+// All sessions are revoked, so the refresh token cookie is cleared too
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), req); err != nil {
+		switch err {
+		case model.ErrVerificationTokenInvalid, model.ErrPasswordTooShort, model.ErrPasswordTooWeak:
+			writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to reset password")
+		}
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   false, // Set to true in production with HTTPS
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   -1, // Delete cookie
+	})
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "Password has been reset. Please log in again.",
+	})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/middleware"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// CreateDeposit handles POST /accounts/{id}/deposits
+// Moves money from the bank equity account into the customer's account
+// Idempotency-Key header is required, with the same semantics as transfers
+func (h *TransferHandler) CreateDeposit(w http.ResponseWriter, r *http.Request) {
+	h.createFunding(w, r, model.TransactionTypeDeposit)
+}
+
+// CreateWithdrawal handles POST /accounts/{id}/withdrawals
+// Moves money from the customer's account back to the bank equity account
+// Idempotency-Key header is required, with the same semantics as transfers
+func (h *TransferHandler) CreateWithdrawal(w http.ResponseWriter, r *http.Request) {
+	h.createFunding(w, r, model.TransactionTypeWithdrawal)
+}
+
+// createFunding builds a deposit or withdrawal against the bank equity account
+// Verifies the account belongs to the authenticated customer
+func (h *TransferHandler) createFunding(w http.ResponseWriter, r *http.Request, txType model.TransactionType) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+	if idempotencyKey == "" {
+		writeError(w, http.StatusBadRequest, "Idempotency-Key header is required")
+		return
+	}
+
+	if h.replayIdempotent(w, r, idempotencyKey) {
+		return
+	}
+
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid account ID format")
+		return
+	}
+
+	var req model.CreateFundingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	amount, err := validateAmount(req.Amount, req.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.accountRepo.GetByID(r.Context(), accountID)
+	if err != nil {
+		if errors.Is(err, model.ErrAccountNotFound) {
+			writeError(w, http.StatusNotFound, "Account not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get account")
+		return
+	}
+
+	// Authorization: can only fund or withdraw from your own accounts
+	if account.CustomerID == nil || *account.CustomerID != customerID {
+		writeError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+	if account.Status != model.AccountStatusActive {
+		writeError(w, http.StatusBadRequest, "Account is not active")
+		return
+	}
+	if req.Currency != account.Currency {
+		writeError(w, http.StatusBadRequest, "Request currency does not match account currency")
+		return
+	}
+
+	equity, err := h.systemAccounts.EquityAccount(account.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Deposits and withdrawals are not supported in "+account.Currency)
+		return
+	}
+
+	// Deposits flow equity -> customer, withdrawals flow customer -> equity
+	fromAccountID, toAccountID := equity.ID, account.ID
+	if txType == model.TransactionTypeWithdrawal {
+		fromAccountID, toAccountID = account.ID, equity.ID
+	}
+
+	txID := uuid.New()
+	tx := model.Transaction{
+		ID:             txID,
+		IdempotencyKey: idempotencyKey,
+		Type:           txType,
+		Status:         model.TransactionStatusPending,
+		Reference:      req.Reference,
+		InitiatedAt:    time.Now(),
+		Amount:         amount.String(),
+		Currency:       req.Currency,
+		FromAccountID:  &fromAccountID,
+		ToAccountID:    &toAccountID,
+	}
+
+	parties := model.PartiesFor(txID, model.TransferLegs(fromAccountID, toAccountID, amount))
+
+	h.submit(w, r, tx, parties, nil)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/auth"
+	"github.com/simonkvalheim/hm9-banking/internal/middleware"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// MFAHeader carries the two-factor code for operations that require step-up verification
+const MFAHeader = "X-MFA-Code"
+
+// MFAHandler handles two-factor authentication setup HTTP requests
+type MFAHandler struct {
+	authService *auth.Service
+}
+
+// NewMFAHandler creates a new MFAHandler
+func NewMFAHandler(authService *auth.Service) *MFAHandler {
+	return &MFAHandler{authService: authService}
+}
+
+// RegisterRoutes sets up the two-factor routes
+func (h *MFAHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/mfa", h.GetStatus)
+	r.Post("/mfa/totp/enroll", h.EnrollTOTP)
+	r.Post("/mfa/totp/confirm", h.ConfirmTOTP)
+	r.Post("/mfa/totp/disable", h.DisableTOTP)
+}
+
+// GetStatus handles GET /mfa
+func (h *MFAHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	status, err := h.authService.GetMFAStatus(r.Context(), customerID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get two-factor status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// EnrollTOTP handles POST /mfa/totp/enroll
+// Returns a new secret; two-factor stays off until it is confirmed with a code
+func (h *MFAHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	enrollment, err := h.authService.EnrollTOTP(r.Context(), customerID)
+	if err != nil {
+		switch err {
+		case model.ErrMFAAlreadyEnabled:
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to start two-factor enrollment")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP handles POST /mfa/totp/confirm
+// Enables two-factor and returns the recovery codes, which are never shown again
+func (h *MFAHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req model.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	codes, err := h.authService.ConfirmTOTP(r.Context(), customerID, req.Code)
+	if err != nil {
+		switch err {
+		case model.ErrInvalidMFACode, model.ErrMFANotEnrolled:
+			writeError(w, http.StatusBadRequest, err.Error())
+		case model.ErrMFAAlreadyEnabled:
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to enable two-factor authentication")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"totp_enabled":   true,
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP handles POST /mfa/totp/disable
+// Requires a current TOTP code or a recovery code
+func (h *MFAHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req model.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.authService.DisableTOTP(r.Context(), customerID, req.Code); err != nil {
+		switch err {
+		case model.ErrMFANotEnabled:
+			writeError(w, http.StatusConflict, err.Error())
+		case model.ErrInvalidMFACode:
+			writeError(w, http.StatusForbidden, err.Error())
+		case model.ErrAccountLocked:
+			writeError(w, http.StatusForbidden, "Account is temporarily locked")
+		case model.ErrAccountSuspended:
+			writeError(w, http.StatusForbidden, "Account is suspended")
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to disable two-factor authentication")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]bool{"totp_enabled": false})
+}
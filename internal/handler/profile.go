@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/auth"
+	"github.com/simonkvalheim/hm9-banking/internal/middleware"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/repository"
+)
+
+// ProfileHandler handles the authenticated customer's own profile
+type ProfileHandler struct {
+	customerRepo *repository.CustomerRepository
+	authService  *auth.Service
+}
+
+// NewProfileHandler creates a new ProfileHandler
+func NewProfileHandler(customerRepo *repository.CustomerRepository, authService *auth.Service) *ProfileHandler {
+	return &ProfileHandler{
+		customerRepo: customerRepo,
+		authService:  authService,
+	}
+}
+
+// RegisterRoutes sets up the profile routes
+func (h *ProfileHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/me", h.GetProfile)
+	r.Patch("/me", h.UpdateProfile)
+	r.Post("/me/password", h.ChangePassword)
+}
+
+// GetProfile handles GET /me
+func (h *ProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	customer, err := h.customerRepo.GetByID(r.Context(), customerID)
+	if err != nil {
+		if err == model.ErrCustomerNotFound {
+			writeError(w, http.StatusNotFound, "Customer not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get profile")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customer)
+}
+
+// UpdateProfile handles PATCH /me
+// Only fields present in the body are changed; an empty string clears an optional field
+func (h *ProfileHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req model.UpdateProfileRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields() // Reject typos and fields that cannot be changed here, such as email
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Normalize()
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	customer, err := h.customerRepo.UpdateProfile(r.Context(), customerID, req)
+	if err != nil {
+		if err == model.ErrCustomerNotFound {
+			writeError(w, http.StatusNotFound, "Customer not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to update profile")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, customer)
+}
+
+// ChangePassword handles POST /me/password
+// All sessions are revoked; the response carries tokens for a new session on this device
+func (h *ProfileHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tokens, err := h.authService.ChangePassword(r.Context(), customerID, req, clientInfo(r))
+	if err != nil {
+		switch err {
+		case model.ErrPasswordRequired, model.ErrPasswordTooShort,
+			model.ErrPasswordTooWeak, model.ErrPasswordUnchanged:
+			writeError(w, http.StatusBadRequest, err.Error())
+		case model.ErrIncorrectPassword:
+			writeError(w, http.StatusForbidden, err.Error())
+		case model.ErrAccountLocked:
+			writeError(w, http.StatusForbidden, "Account is temporarily locked")
+		case model.ErrAccountSuspended:
+			writeError(w, http.StatusForbidden, "Account is suspended")
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to change password")
+		}
+		return
+	}
+
+	writeLoginTokens(w, tokens)
+}
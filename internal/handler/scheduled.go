@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/middleware"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+const (
+	defaultFailedWindow = 30 * 24 * time.Hour // How far back the failed occurrence report looks without ?since=
+	defaultFailedLimit  = 100
+	maxFailedLimit      = 500
+)
+
+// CreateScheduledTransfer handles POST /scheduled-transfers
+// The transfer is checked like POST /transfers, including the two-factor code for high-value amounts;
+// each occurrence is then made by the worker's scheduler without further input from the customer
+func (h *TransferHandler) CreateScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var req model.CreateScheduledTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	startDate, endDate, _ := req.Dates()
+
+	amount, err := validateAmount(req.Amount, req.Currency)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fromAccount, toAccount, _, ok := h.authorizeTransfer(w, r, customerID, req.CreateTransferRequest, amount)
+	if !ok {
+		return
+	}
+
+	schedule := &model.ScheduledTransfer{
+		ID:            uuid.New(),
+		CustomerID:    customerID,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		Currency:      req.Currency,
+		Reference:     req.Reference,
+		Frequency:     req.Frequency,
+		StartDate:     startDate,
+		EndDate:       endDate,
+	}
+	if err := h.scheduledRepo.Create(r.Context(), schedule); err != nil {
+		if errors.Is(err, model.ErrScheduleStartInPast) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to create scheduled transfer")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, schedule)
+}
+
+// ListScheduledTransfers handles GET /scheduled-transfers
+// Returns all of the customer's standing orders, including completed and cancelled ones
+func (h *TransferHandler) ListScheduledTransfers(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	schedules, err := h.scheduledRepo.ListByCustomer(r.Context(), customerID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list scheduled transfers")
+		return
+	}
+	if schedules == nil {
+		schedules = []model.ScheduledTransfer{}
+	}
+
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+// GetScheduledTransfer handles GET /scheduled-transfers/{id}
+func (h *TransferHandler) GetScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.ownSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+// CancelScheduledTransfer handles POST /scheduled-transfers/{id}/cancel
+// Transfers already made for earlier occurrences are not affected
+func (h *TransferHandler) CancelScheduledTransfer(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.ownSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	cancelled, err := h.scheduledRepo.Cancel(r.Context(), schedule.ID)
+	if err != nil {
+		switch err {
+		case model.ErrScheduledTransferNotFound:
+			writeError(w, http.StatusNotFound, "Scheduled transfer not found")
+		case model.ErrScheduleNotActive:
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to cancel scheduled transfer")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cancelled)
+}
+
+// ListScheduledOccurrences handles GET /scheduled-transfers/{id}/occurrences
+// Each occurrence carries the status and error message of the transfer it created
+func (h *TransferHandler) ListScheduledOccurrences(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.ownSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	occurrences, err := h.scheduledRepo.ListOccurrences(r.Context(), schedule.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list scheduled transfer occurrences")
+		return
+	}
+	if occurrences == nil {
+		occurrences = []model.ScheduledOccurrence{}
+	}
+
+	writeJSON(w, http.StatusOK, occurrences)
+}
+
+// ListFailedScheduledTransfers handles GET /scheduled-transfers/failed
+// Query parameters: since (ISO 8601, default 30 days ago) and limit (default 100, max 500)
+func (h *TransferHandler) ListFailedScheduledTransfers(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	since, limit, err := parseFailedFilter(r.URL.Query(), time.Now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	failed, err := h.scheduledRepo.ListFailed(r.Context(), &customerID, since, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list failed scheduled transfers")
+		return
+	}
+	if failed == nil {
+		failed = []model.FailedScheduledOccurrence{}
+	}
+
+	writeJSON(w, http.StatusOK, failed)
+}
+
+// ownSchedule loads the standing order in the URL and checks it belongs to the authenticated customer
+// Writes the error response and returns false if not
+func (h *TransferHandler) ownSchedule(w http.ResponseWriter, r *http.Request) (*model.ScheduledTransfer, bool) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return nil, false
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid scheduled transfer ID format")
+		return nil, false
+	}
+
+	schedule, err := h.scheduledRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrScheduledTransferNotFound) {
+			writeError(w, http.StatusNotFound, "Scheduled transfer not found")
+			return nil, false
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get scheduled transfer")
+		return nil, false
+	}
+
+	if schedule.CustomerID != customerID {
+		writeError(w, http.StatusForbidden, "Access denied")
+		return nil, false
+	}
+
+	return schedule, true
+}
+
+// parseFailedFilter reads the since and limit query parameters of the failed occurrence reports
+func parseFailedFilter(q url.Values, now time.Time) (time.Time, int, error) {
+	since := now.Add(-defaultFailedWindow)
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, 0, errors.New("invalid since format: use ISO 8601 (e.g., 2024-12-13T10:00:00Z)")
+		}
+		since = t
+	}
+
+	limit := defaultFailedLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxFailedLimit {
+			return time.Time{}, 0, model.ErrInvalidLimit
+		}
+		limit = n
+	}
+
+	return since, limit, nil
+}
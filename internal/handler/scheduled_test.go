@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func TestParseFailedFilter(t *testing.T) {
+	now := time.Date(2026, 11, 15, 12, 0, 0, 0, time.UTC)
+
+	since, limit, err := parseFailedFilter(url.Values{}, now)
+	if err != nil {
+		t.Fatalf("parseFailedFilter() error = %v", err)
+	}
+	if !since.Equal(now.Add(-defaultFailedWindow)) || limit != defaultFailedLimit {
+		t.Errorf("defaults = (%s, %d), want (%s, %d)", since, limit, now.Add(-defaultFailedWindow), defaultFailedLimit)
+	}
+
+	q := url.Values{}
+	q.Set("since", "2026-11-01T00:00:00Z")
+	q.Set("limit", "25")
+	since, limit, err = parseFailedFilter(q, now)
+	if err != nil {
+		t.Fatalf("parseFailedFilter() error = %v", err)
+	}
+	if !since.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)) || limit != 25 {
+		t.Errorf("parsed = (%s, %d), want (2026-11-01T00:00:00Z, 25)", since, limit)
+	}
+}
+
+func TestParseFailedFilter_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"since": "2026-11-01",
+		"limit": "501",
+	}
+
+	for name, value := range tests {
+		q := url.Values{}
+		q.Set(name, value)
+		if _, _, err := parseFailedFilter(q, time.Now()); err == nil {
+			t.Errorf("parseFailedFilter(%s=%s) error = nil, want error", name, value)
+		}
+	}
+
+	q := url.Values{}
+	q.Set("limit", "0")
+	if _, _, err := parseFailedFilter(q, time.Now()); err != model.ErrInvalidLimit {
+		t.Errorf("parseFailedFilter(limit=0) error = %v, want %v", err, model.ErrInvalidLimit)
+	}
+}
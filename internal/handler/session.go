@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/auth"
+	"github.com/simonkvalheim/hm9-banking/internal/middleware"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// SessionHandler handles login session HTTP requests
+type SessionHandler struct {
+	authService *auth.Service
+}
+
+// NewSessionHandler creates a new SessionHandler
+func NewSessionHandler(authService *auth.Service) *SessionHandler {
+	return &SessionHandler{authService: authService}
+}
+
+// RegisterRoutes sets up the session routes
+func (h *SessionHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/sessions", h.ListSessions)
+	r.Delete("/sessions/{id}", h.RevokeSession)
+}
+
+// ListSessions handles GET /sessions
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(r.Context(), customerID, middleware.GetSessionID(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list sessions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession handles DELETE /sessions/{id}
+// Revoking the current session is allowed and logs this device out
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), customerID, sessionID); err != nil {
+		if errors.Is(err, model.ErrSessionNotFound) {
+			// Other customers' sessions are reported as not found, not forbidden
+			writeError(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientInfo describes the device making a request, for the session list
+func clientInfo(r *http.Request) model.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return model.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: ip,
+	}
+}
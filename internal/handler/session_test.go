@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func TestClientInfo(t *testing.T) {
+	r := httptest.NewRequest("POST", "/auth/login", nil)
+	r.RemoteAddr = "203.0.113.7:54321"
+	r.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh)")
+
+	got := clientInfo(r)
+	if got.IPAddress != "203.0.113.7" {
+		t.Errorf("IPAddress = %q, want %q", got.IPAddress, "203.0.113.7")
+	}
+	if got.UserAgent != "Mozilla/5.0 (Macintosh)" {
+		t.Errorf("UserAgent = %q, want %q", got.UserAgent, "Mozilla/5.0 (Macintosh)")
+	}
+
+	r.RemoteAddr = "[2001:db8::1]:443"
+	if got := clientInfo(r); got.IPAddress != "2001:db8::1" {
+		t.Errorf("IPAddress = %q, want %q", got.IPAddress, "2001:db8::1")
+	}
+}
+
+func TestClientInfo_Truncated(t *testing.T) {
+	info := model.ClientInfo{UserAgent: strings.Repeat("a", model.MaxUserAgentLength+50)}
+
+	if got := len(info.Truncated().UserAgent); got != model.MaxUserAgentLength {
+		t.Errorf("len(UserAgent) = %d, want %d", got, model.MaxUserAgentLength)
+	}
+}
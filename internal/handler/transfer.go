@@ -5,13 +5,13 @@ import (
 	"errors"
 	"log"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/simonkvalheim/hm9-banking/internal/auth"
+	"github.com/simonkvalheim/hm9-banking/internal/bootstrap"
 	"github.com/simonkvalheim/hm9-banking/internal/middleware"
 	"github.com/simonkvalheim/hm9-banking/internal/model"
 	"github.com/simonkvalheim/hm9-banking/internal/processor"
@@ -21,28 +21,50 @@ import (
 
 // TransferHandler handles HTTP requests for transfers
 type TransferHandler struct {
-	txRepo      *repository.TransactionRepository
-	accountRepo *repository.AccountRepository
-	processor   *processor.TransferProcessor
-	publisher   *queue.Publisher // Optional: if set, uses async processing
+	txRepo         *repository.TransactionRepository
+	accountRepo    *repository.AccountRepository
+	scheduledRepo  *repository.ScheduledTransferRepository
+	feeRepo        *repository.FeeRepository
+	processor      *processor.TransferProcessor
+	publisher      *queue.Publisher          // Optional: if set, uses async processing
+	systemAccounts *bootstrap.SystemAccounts // Equity accounts used for deposits and withdrawals
+	authService    *auth.Service             // Optional: if set, large transfers need a two-factor code
 }
 
 // NewTransferHandler creates a new TransferHandler
 // If publisher is nil, transactions are processed synchronously
 // If publisher is provided, transactions are queued for async processing
-func NewTransferHandler(txRepo *repository.TransactionRepository, accountRepo *repository.AccountRepository, proc *processor.TransferProcessor, publisher *queue.Publisher) *TransferHandler {
+// If authService is provided, transfers above its step-up threshold require a two-factor code,
+// and transfers require a verified email when it is configured to
+func NewTransferHandler(txRepo *repository.TransactionRepository, accountRepo *repository.AccountRepository, scheduledRepo *repository.ScheduledTransferRepository, feeRepo *repository.FeeRepository, proc *processor.TransferProcessor, publisher *queue.Publisher, systemAccounts *bootstrap.SystemAccounts, authService *auth.Service) *TransferHandler {
 	return &TransferHandler{
-		txRepo:      txRepo,
-		accountRepo: accountRepo,
-		processor:   proc,
-		publisher:   publisher,
+		txRepo:         txRepo,
+		accountRepo:    accountRepo,
+		scheduledRepo:  scheduledRepo,
+		feeRepo:        feeRepo,
+		processor:      proc,
+		publisher:      publisher,
+		systemAccounts: systemAccounts,
+		authService:    authService,
 	}
 }
 
 // RegisterRoutes sets up the transfer routes on the given router
 func (h *TransferHandler) RegisterRoutes(r chi.Router) {
 	r.Post("/transfers", h.CreateTransfer)
+	r.Post("/transfers/quote", h.QuoteTransfer)
 	r.Get("/transactions/{id}", h.GetTransaction)
+	r.Post("/transactions/{id}/cancel", h.CancelTransaction)
+	r.Post("/accounts/{id}/deposits", h.CreateDeposit)
+	r.Post("/accounts/{id}/withdrawals", h.CreateWithdrawal)
+	r.Route("/scheduled-transfers", func(r chi.Router) {
+		r.Post("/", h.CreateScheduledTransfer)
+		r.Get("/", h.ListScheduledTransfers)
+		r.Get("/failed", h.ListFailedScheduledTransfers)
+		r.Get("/{id}", h.GetScheduledTransfer)
+		r.Get("/{id}/occurrences", h.ListScheduledOccurrences)
+		r.Post("/{id}/cancel", h.CancelScheduledTransfer)
+	})
 }
 
 // CreateTransfer handles POST /transfers
@@ -63,18 +85,7 @@ func (h *TransferHandler) CreateTransfer(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Check for existing transaction with this idempotency key
-	existingTx, err := h.txRepo.GetByIdempotencyKey(r.Context(), idempotencyKey)
-	if err == nil && existingTx != nil {
-		// Transaction already exists - return existing result (idempotent behavior)
-		writeJSON(w, http.StatusAccepted, model.TransferResponse{
-			TransactionID: existingTx.ID,
-			Status:        existingTx.Status,
-			CreatedAt:     existingTx.InitiatedAt,
-		})
-		return
-	}
-	if err != nil && !errors.Is(err, model.ErrTransactionNotFound) {
-		writeError(w, http.StatusInternalServerError, "Failed to check idempotency")
+	if h.replayIdempotent(w, r, idempotencyKey) {
 		return
 	}
 
@@ -91,55 +102,22 @@ func (h *TransferHandler) CreateTransfer(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Validate amount is a positive number
-	if err := validateAmount(req.Amount); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	// Validate source account exists and is active
-	fromAccount, err := h.accountRepo.GetByID(r.Context(), req.FromAccountID)
+	// Validate amount is a positive number in the currency's minor unit scale
+	amount, err := validateAmount(req.Amount, req.Currency)
 	if err != nil {
-		if errors.Is(err, model.ErrAccountNotFound) {
-			writeError(w, http.StatusBadRequest, "Source account not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "Failed to validate source account")
-		return
-	}
-	if fromAccount.Status != model.AccountStatusActive {
-		writeError(w, http.StatusBadRequest, "Source account is not active")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Authorization: can only transfer FROM your own accounts
-	if fromAccount.CustomerID == nil || *fromAccount.CustomerID != customerID {
-		writeError(w, http.StatusForbidden, "You can only transfer from your own accounts")
+	fromAccount, toAccount, payee, ok := h.authorizeTransfer(w, r, customerID, req, amount)
+	if !ok {
 		return
 	}
 
-	// Validate destination account exists and is active
-	toAccount, err := h.accountRepo.GetByID(r.Context(), req.ToAccountID)
+	// The fee is fixed now, as quoted, even if the schedule changes before the transfer is processed
+	fee, revenueAccountID, err := h.feeRepo.Quote(r.Context(), fromAccount, amount)
 	if err != nil {
-		if errors.Is(err, model.ErrAccountNotFound) {
-			writeError(w, http.StatusBadRequest, "Destination account not found")
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "Failed to validate destination account")
-		return
-	}
-	if toAccount.Status != model.AccountStatusActive {
-		writeError(w, http.StatusBadRequest, "Destination account is not active")
-		return
-	}
-
-	// Validate currencies match
-	if fromAccount.Currency != toAccount.Currency {
-		writeError(w, http.StatusBadRequest, "Currency mismatch between accounts")
-		return
-	}
-	if req.Currency != fromAccount.Currency {
-		writeError(w, http.StatusBadRequest, "Request currency does not match account currency")
+		writeError(w, http.StatusInternalServerError, "Failed to calculate fee")
 		return
 	}
 
@@ -154,90 +132,152 @@ func (h *TransferHandler) CreateTransfer(w http.ResponseWriter, r *http.Request)
 		Status:         model.TransactionStatusPending,
 		Reference:      req.Reference,
 		InitiatedAt:    now,
-		Amount:         req.Amount,
+		Amount:         amount.String(),
 		Currency:       req.Currency,
-		FromAccountID:  &req.FromAccountID,
-		ToAccountID:    &req.ToAccountID,
+		FromAccountID:  &fromAccount.ID,
+		ToAccountID:    &toAccount.ID,
 	}
 
-	parties := []model.TransactionParty{
-		{
-			ID:            uuid.New(),
-			TransactionID: txID,
-			AccountID:     req.FromAccountID,
-			Role:          "source",
-		},
-		{
-			ID:            uuid.New(),
-			TransactionID: txID,
-			AccountID:     req.ToAccountID,
-			Role:          "destination",
-		},
+	legs := model.TransferLegs(fromAccount.ID, toAccount.ID, amount)
+	if fee.IsPositive() {
+		tx.FeeAmount = fee.String()
+		legs = append(legs, model.FeeLegs(fromAccount.ID, revenueAccountID, fee)...)
 	}
 
-	createdTx, err := h.txRepo.Create(r.Context(), tx, parties)
+	h.submit(w, r, tx, model.PartiesFor(txID, legs), payee)
+}
+
+// QuoteTransfer handles POST /transfers/quote
+// Takes the same body as POST /transfers and returns the fee and total without creating anything,
+// so the customer can confirm them first. Needs neither an Idempotency-Key nor a two-factor code.
+func (h *TransferHandler) QuoteTransfer(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var req model.CreateTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	amount, err := validateAmount(req.Amount, req.Currency)
 	if err != nil {
-		if errors.Is(err, model.ErrTransactionExists) {
-			// Race condition: another request created it first
-			// Fetch and return the existing transaction
-			existingTx, fetchErr := h.txRepo.GetByIdempotencyKey(r.Context(), idempotencyKey)
-			if fetchErr != nil {
-				writeError(w, http.StatusInternalServerError, "Failed to create transfer")
-				return
-			}
-			writeJSON(w, http.StatusAccepted, model.TransferResponse{
-				TransactionID: existingTx.ID,
-				Status:        existingTx.Status,
-				CreatedAt:     existingTx.InitiatedAt,
-			})
-			return
-		}
-		writeError(w, http.StatusInternalServerError, "Failed to create transfer")
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Check if async processing is enabled
-	if h.publisher != nil {
-		// Async mode: publish to queue for worker to process
-		if err := h.publisher.PublishTransaction(r.Context(), createdTx.ID, string(createdTx.Type)); err != nil {
-			log.Printf("Failed to publish transaction %s to queue: %v", createdTx.ID, err)
-			// Transaction created but failed to queue - return pending status
-			// A background job could pick this up later
-		}
+	fromAccount, _, payee, ok := h.transferAccounts(w, r, customerID, req)
+	if !ok {
+		return
+	}
 
-		// Return 202 Accepted with pending status
-		writeJSON(w, http.StatusAccepted, model.TransferResponse{
-			TransactionID: createdTx.ID,
-			Status:        model.TransactionStatusPending,
-			CreatedAt:     createdTx.InitiatedAt,
-		})
+	fee, _, err := h.feeRepo.Quote(r.Context(), fromAccount, amount)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to calculate fee")
 		return
 	}
 
-	// Sync mode: process the transaction immediately
-	result, err := h.processor.Process(r.Context(), createdTx.ID)
+	quote, err := model.NewTransferQuote(amount, fee)
 	if err != nil {
-		log.Printf("Failed to process transaction %s: %v", createdTx.ID, err)
-		// Transaction created but processing failed - return pending status
-		writeJSON(w, http.StatusAccepted, model.TransferResponse{
-			TransactionID: createdTx.ID,
-			Status:        model.TransactionStatusPending,
-			CreatedAt:     createdTx.InitiatedAt,
-		})
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if payee != nil {
+		quote.ToAccountNumber = payee.AccountNumber
+		quote.ToHolderName = payee.HolderName
+	}
 
-	// Fetch updated transaction status after processing
-	finalStatus := model.TransactionStatusCompleted
-	if !result.Success && result.ErrorMessage != "" {
-		finalStatus = model.TransactionStatusFailed
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// authorizeTransfer runs the checks every customer transfer goes through before it is accepted:
+// verified email, the account checks of transferAccounts, and a two-factor code for high-value amounts
+// Returns false if a response has been written and the caller should stop
+func (h *TransferHandler) authorizeTransfer(w http.ResponseWriter, r *http.Request, customerID uuid.UUID, req model.CreateTransferRequest, amount model.Money) (*model.Account, *model.Account, *model.AccountHolder, bool) {
+	// Optionally, only customers with a verified email may transfer
+	if h.authService != nil {
+		if err := h.authService.CheckEmailVerified(r.Context(), customerID); err != nil {
+			if errors.Is(err, model.ErrEmailNotVerified) {
+				writeError(w, http.StatusForbidden, err.Error())
+				return nil, nil, nil, false
+			}
+			writeError(w, http.StatusInternalServerError, "Failed to check email verification")
+			return nil, nil, nil, false
+		}
 	}
 
-	writeJSON(w, http.StatusAccepted, model.TransferResponse{
-		TransactionID: createdTx.ID,
-		Status:        finalStatus,
-		CreatedAt:     createdTx.InitiatedAt,
-	})
+	fromAccount, toAccount, payee, ok := h.transferAccounts(w, r, customerID, req)
+	if !ok {
+		return nil, nil, nil, false
+	}
+
+	// High-value transfers need a fresh two-factor code in the X-MFA-Code header
+	if h.authService != nil && h.authService.RequiresStepUp(amount) {
+		if !h.verifyStepUp(w, r, customerID) {
+			return nil, nil, nil, false
+		}
+	}
+
+	return fromAccount, toAccount, payee, true
+}
+
+// transferAccounts loads the source and destination of a transfer and checks the customer may pay
+// from one into the other: the source is theirs, both are active, and the currencies match
+// Returns false if a response has been written and the caller should stop
+func (h *TransferHandler) transferAccounts(w http.ResponseWriter, r *http.Request, customerID uuid.UUID, req model.CreateTransferRequest) (*model.Account, *model.Account, *model.AccountHolder, bool) {
+	// Validate source account exists and is active
+	fromAccount, err := h.accountRepo.GetByID(r.Context(), req.FromAccountID)
+	if err != nil {
+		if errors.Is(err, model.ErrAccountNotFound) {
+			writeError(w, http.StatusBadRequest, "Source account not found")
+			return nil, nil, nil, false
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to validate source account")
+		return nil, nil, nil, false
+	}
+	if fromAccount.Status != model.AccountStatusActive {
+		writeError(w, http.StatusBadRequest, "Source account is not active")
+		return nil, nil, nil, false
+	}
+
+	// Authorization: can only transfer FROM your own accounts
+	if fromAccount.CustomerID == nil || *fromAccount.CustomerID != customerID {
+		writeError(w, http.StatusForbidden, "You can only transfer from your own accounts")
+		return nil, nil, nil, false
+	}
+
+	// Validate destination account exists and is active
+	toAccount, payee, ok := h.resolveDestination(w, r, req)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	if toAccount.ID == fromAccount.ID {
+		writeError(w, http.StatusBadRequest, model.ErrSameAccount.Error())
+		return nil, nil, nil, false
+	}
+	if toAccount.Status != model.AccountStatusActive {
+		writeError(w, http.StatusBadRequest, "Destination account is not active")
+		return nil, nil, nil, false
+	}
+
+	// Validate currencies match
+	if fromAccount.Currency != toAccount.Currency {
+		writeError(w, http.StatusBadRequest, "Currency mismatch between accounts")
+		return nil, nil, nil, false
+	}
+	if req.Currency != fromAccount.Currency {
+		writeError(w, http.StatusBadRequest, "Request currency does not match account currency")
+		return nil, nil, nil, false
+	}
+
+	return fromAccount, toAccount, payee, true
 }
 
 // GetTransaction handles GET /transactions/{id}
@@ -298,22 +338,264 @@ func (h *TransferHandler) GetTransaction(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, detail)
 }
 
-// validateAmount checks if the amount is a valid positive decimal
-func validateAmount(amount string) error {
-	amount = strings.TrimSpace(amount)
-	if amount == "" {
-		return model.ErrInvalidAmount
+// CancelTransaction handles POST /transactions/{id}/cancel
+// Only the customer who initiated the transaction may cancel it, and only while it is still pending:
+// the account it was paid from, or for a deposit the account it was paid into, must be theirs
+func (h *TransferHandler) CancelTransaction(w http.ResponseWriter, r *http.Request) {
+	customerID := middleware.GetCustomerID(r.Context())
+	if customerID == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid transaction ID format")
+		return
+	}
+
+	tx, err := h.txRepo.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrTransactionNotFound) {
+			writeError(w, http.StatusNotFound, "Transaction not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to get transaction")
+		return
+	}
+
+	initiatedFrom := tx.FromAccountID
+	if tx.Type == model.TransactionTypeDeposit {
+		initiatedFrom = tx.ToAccountID
+	}
+	authorized := false
+	if initiatedFrom != nil {
+		account, err := h.accountRepo.GetByID(r.Context(), *initiatedFrom)
+		if err == nil && account.CustomerID != nil && *account.CustomerID == customerID {
+			authorized = true
+		}
+	}
+	if !authorized {
+		writeError(w, http.StatusForbidden, "Access denied")
+		return
+	}
+
+	if err := h.txRepo.Cancel(r.Context(), id); err != nil {
+		switch err {
+		case model.ErrTransactionNotFound:
+			writeError(w, http.StatusNotFound, "Transaction not found")
+		case model.ErrTransactionNotPending:
+			writeError(w, http.StatusConflict, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to cancel transaction")
+		}
+		return
+	}
+
+	tx, err = h.txRepo.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get transaction")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, model.TransactionDetail{
+		Transaction:   *tx,
+		FromAccountID: tx.FromAccountID,
+		ToAccountID:   tx.ToAccountID,
+		Amount:        tx.Amount,
+		Currency:      tx.Currency,
+	})
+}
+
+// replayIdempotent writes the existing transaction for an idempotency key, if any
+// Returns true if a response has been written and the caller should stop
+func (h *TransferHandler) replayIdempotent(w http.ResponseWriter, r *http.Request, idempotencyKey string) bool {
+	existingTx, err := h.txRepo.GetByIdempotencyKey(r.Context(), idempotencyKey)
+	if err == nil && existingTx != nil {
+		// Transaction already exists - return existing result (idempotent behavior)
+		writeJSON(w, http.StatusAccepted, model.TransferResponse{
+			TransactionID: existingTx.ID,
+			Status:        existingTx.Status,
+			CreatedAt:     existingTx.InitiatedAt,
+			Fee:           transferFee(existingTx),
+		})
+		return true
 	}
+	if err != nil && !errors.Is(err, model.ErrTransactionNotFound) {
+		writeError(w, http.StatusInternalServerError, "Failed to check idempotency")
+		return true
+	}
+	return false
+}
+
+// resolveDestination loads the destination account from to_account_id or to_account_number
+// When given by account number or IBAN, the holder is returned too so the response can name the payee
+// Returns false if a response has been written and the caller should stop
+func (h *TransferHandler) resolveDestination(w http.ResponseWriter, r *http.Request, req model.CreateTransferRequest) (*model.Account, *model.AccountHolder, bool) {
+	if req.ToAccountNumber == "" {
+		toAccount, err := h.accountRepo.GetByID(r.Context(), req.ToAccountID)
+		if err != nil {
+			if errors.Is(err, model.ErrAccountNotFound) {
+				writeError(w, http.StatusBadRequest, "Destination account not found")
+				return nil, nil, false
+			}
+			writeError(w, http.StatusInternalServerError, "Failed to validate destination account")
+			return nil, nil, false
+		}
+		return toAccount, nil, true
+	}
+
+	toAccount, err := h.accountRepo.GetByAccountNumber(r.Context(), req.ToAccountNumber)
+	if err == nil {
+		var payee *model.AccountHolder
+		payee, err = h.accountRepo.GetHolder(r.Context(), toAccount.ID)
+		if err == nil {
+			return toAccount, payee, true
+		}
+	}
+
+	switch {
+	case errors.Is(err, model.ErrInvalidAccountNumber):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrAccountNotFound):
+		writeError(w, http.StatusBadRequest, "Destination account not found")
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to validate destination account")
+	}
+	return nil, nil, false
+}
+
+// verifyStepUp checks the two-factor code sent with a high-value transfer
+// Returns false if a response has been written and the caller should stop
+func (h *TransferHandler) verifyStepUp(w http.ResponseWriter, r *http.Request, customerID uuid.UUID) bool {
+	err := h.authService.VerifyStepUp(r.Context(), customerID, r.Header.Get(MFAHeader))
+	if err == nil {
+		return true
+	}
+
+	switch err {
+	case model.ErrStepUpRequired, model.ErrStepUpNotAvailable, model.ErrInvalidMFACode:
+		writeError(w, http.StatusForbidden, err.Error())
+	case model.ErrAccountLocked:
+		writeError(w, http.StatusForbidden, "Account is temporarily locked")
+	case model.ErrAccountSuspended:
+		writeError(w, http.StatusForbidden, "Account is suspended")
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to verify two-factor code")
+	}
+	return false
+}
+
+// submit persists a pending transaction and either queues it or processes it synchronously
+// Shared by transfers, deposits and withdrawals so they get identical idempotency handling
+// payee is set when the destination was given by account number, and is echoed in the response
+func (h *TransferHandler) submit(w http.ResponseWriter, r *http.Request, tx model.Transaction, parties []model.TransactionParty, payee *model.AccountHolder) {
+	createdTx, err := h.txRepo.Create(r.Context(), tx, parties)
+	if err != nil {
+		if errors.Is(err, model.ErrTransactionExists) {
+			// Race condition: another request created it first
+			// Fetch and return the existing transaction
+			existingTx, fetchErr := h.txRepo.GetByIdempotencyKey(r.Context(), tx.IdempotencyKey)
+			if fetchErr != nil {
+				writeError(w, http.StatusInternalServerError, "Failed to create transaction")
+				return
+			}
+			writeJSON(w, http.StatusAccepted, model.TransferResponse{
+				TransactionID: existingTx.ID,
+				Status:        existingTx.Status,
+				CreatedAt:     existingTx.InitiatedAt,
+				Fee:           transferFee(existingTx),
+			})
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "Failed to create transaction")
+		return
+	}
+
+	// Check if async processing is enabled
+	if h.publisher != nil {
+		// Async mode: publish to queue for worker to process
+		if err := h.publisher.PublishTransaction(r.Context(), createdTx.ID, string(createdTx.Type)); err != nil {
+			log.Printf("Failed to publish transaction %s to queue: %v", createdTx.ID, err)
+			// Transaction created but failed to queue - return pending status
+			// The outbox relay in the worker will publish it later
+		} else if err := h.txRepo.MarkOutboxPublished(r.Context(), createdTx.ID); err != nil {
+			// Harmless: the relay will publish again and the processor ignores already-claimed transactions
+			log.Printf("Failed to mark outbox entry for transaction %s as published: %v", createdTx.ID, err)
+		}
+
+		// Return 202 Accepted with pending status
+		writeJSON(w, http.StatusAccepted, withPayee(model.TransferResponse{
+			TransactionID: createdTx.ID,
+			Status:        model.TransactionStatusPending,
+			CreatedAt:     createdTx.InitiatedAt,
+			Fee:           transferFee(createdTx),
+		}, payee))
+		return
+	}
+
+	// Sync mode: process the transaction immediately
+	result, err := h.processor.Process(r.Context(), createdTx.ID)
+	if err != nil {
+		log.Printf("Failed to process transaction %s: %v", createdTx.ID, err)
+		// Transaction created but processing failed - return pending status
+		writeJSON(w, http.StatusAccepted, withPayee(model.TransferResponse{
+			TransactionID: createdTx.ID,
+			Status:        model.TransactionStatusPending,
+			CreatedAt:     createdTx.InitiatedAt,
+			Fee:           transferFee(createdTx),
+		}, payee))
+		return
+	}
+
+	// Fetch updated transaction status after processing
+	finalStatus := model.TransactionStatusCompleted
+	errorMessage := ""
+	if !result.Success && result.ErrorMessage != "" {
+		finalStatus = model.TransactionStatusFailed
+		errorMessage = result.ErrorMessage
+	}
+
+	writeJSON(w, http.StatusAccepted, withPayee(model.TransferResponse{
+		TransactionID: createdTx.ID,
+		Status:        finalStatus,
+		CreatedAt:     createdTx.InitiatedAt,
+		ErrorMessage:  errorMessage,
+		Fee:           transferFee(createdTx),
+	}, payee))
+}
+
+// withPayee adds the destination's account number and masked holder name to a transfer response
+func withPayee(resp model.TransferResponse, payee *model.AccountHolder) model.TransferResponse {
+	if payee != nil {
+		resp.ToAccountNumber = payee.AccountNumber
+		resp.ToHolderName = payee.HolderName
+	}
+	return resp
+}
+
+// transferFee formats the fee charged on a transaction in its currency, or returns "" if there is none
+func transferFee(tx *model.Transaction) string {
+	if tx.FeeAmount == "" {
+		return ""
+	}
+	fee, err := model.ParseMoney(tx.FeeAmount, tx.Currency)
+	if err != nil {
+		return tx.FeeAmount
+	}
+	return fee.String()
+}
 
-	// Parse as float to validate format
-	val, err := strconv.ParseFloat(amount, 64)
+// validateAmount parses the amount as an exact decimal and checks it is positive
+func validateAmount(amount, currency string) (model.Money, error) {
+	money, err := model.ParseMoney(amount, currency)
 	if err != nil {
-		return model.ErrInvalidAmount
+		return model.Money{}, err
 	}
 
-	if val <= 0 {
-		return model.ErrInvalidAmount
+	if !money.IsPositive() {
+		return model.Money{}, model.ErrInvalidAmount
 	}
 
-	return nil
+	return money, nil
 }
@@ -67,17 +67,40 @@ func TestValidateAmount(t *testing.T) {
 			amount:  " 100.00 ",
 			wantErr: false,
 		},
+		{
+			name:    "too many decimals for currency",
+			amount:  "100.001",
+			wantErr: true,
+		},
+		{
+			name:    "float exponent notation",
+			amount:  "1e2",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateAmount(tt.amount)
+			_, err := validateAmount(tt.amount, "NOK")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("validateAmount(%q) error = %v, wantErr %v", tt.amount, err, tt.wantErr)
 			}
-			if tt.wantErr && err != nil && err != model.ErrInvalidAmount {
-				t.Errorf("validateAmount(%q) error = %v, want ErrInvalidAmount", tt.amount, err)
+			if tt.wantErr && err != nil && err != model.ErrInvalidAmount && err != model.ErrAmountPrecision {
+				t.Errorf("validateAmount(%q) error = %v, want ErrInvalidAmount or ErrAmountPrecision", tt.amount, err)
 			}
 		})
 	}
 }
+
+func TestValidateAmount_ReturnsExactMoney(t *testing.T) {
+	money, err := validateAmount("0.10", "NOK")
+	if err != nil {
+		t.Fatalf("validateAmount() error = %v", err)
+	}
+	if money.MinorUnits() != 10 {
+		t.Errorf("MinorUnits() = %d, want 10", money.MinorUnits())
+	}
+	if money.Currency() != "NOK" {
+		t.Errorf("Currency() = %s, want NOK", money.Currency())
+	}
+}
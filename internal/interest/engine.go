@@ -0,0 +1,231 @@
+package interest
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/repository"
+)
+
+// Engine accrues interest on every account with a rate, day by day, and pays each month's interest
+// into the account once the month is over
+// Days and months follow model.InterestTimeZone. Days missed while the worker was down are all
+// accrued on the next run, and accruals and capitalizations are idempotent, so several workers can
+// run the engine side by side.
+type Engine struct {
+	repo     *repository.InterestRepository
+	ledger   *repository.LedgerRepository
+	interval time.Duration
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewEngine creates a new Engine that runs every interval
+func NewEngine(repo *repository.InterestRepository, ledger *repository.LedgerRepository, interval time.Duration) *Engine {
+	return &Engine{
+		repo:     repo,
+		ledger:   ledger,
+		interval: interval,
+	}
+}
+
+// Report is the outcome of a single run
+type Report struct {
+	RanAt       time.Time
+	Accrued     int // Days of interest accrued, over all accounts
+	Capitalized int // Months of interest paid into accounts
+	Failed      int // Accounts whose accrual or capitalization failed and is retried on the next run
+}
+
+// Stats are cumulative counters across all runs since the engine started
+type Stats struct {
+	Runs        int64
+	Accrued     int64
+	Capitalized int64
+	Failed      int64
+}
+
+// Stats returns a snapshot of the cumulative counters
+func (e *Engine) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+// product identifies the accounts an interest rate applies to
+type product struct {
+	accountType model.AccountType
+	currency    string
+}
+
+// RunOnce accrues every day that has ended and not been accrued yet, then capitalizes every month that has ended
+// A failure on one account is logged and counted; it does not stop the others
+func (e *Engine) RunOnce(ctx context.Context) (*Report, error) {
+	report := &Report{RanAt: time.Now()}
+	today := model.LocalDate(report.RanAt, model.InterestTimeZone)
+
+	rates, err := e.repo.ListRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byProduct := make(map[product][]model.InterestRate)
+	for _, rate := range rates {
+		p := product{rate.AccountType, rate.Currency}
+		byProduct[p] = append(byProduct[p], rate)
+	}
+
+	accounts, err := e.repo.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		accrued, err := e.accrue(ctx, account, byProduct[product{account.AccountType, account.Currency}], today)
+		if err != nil {
+			if ctx.Err() != nil {
+				e.record(report)
+				return nil, ctx.Err()
+			}
+			log.Printf("Interest: failed to accrue interest on account %s: %v", account.ID, err)
+			report.Failed++
+			continue
+		}
+		report.Accrued += accrued
+	}
+
+	due, err := e.repo.ListDueCapitalizations(ctx, model.InterestPeriod(today))
+	if err != nil {
+		e.record(report)
+		return nil, err
+	}
+	for _, d := range due {
+		c, err := e.repo.Capitalize(ctx, d.AccountID, d.Period)
+		if err != nil {
+			if ctx.Err() != nil {
+				e.record(report)
+				return nil, ctx.Err()
+			}
+			log.Printf("Interest: failed to capitalize %s interest on account %s: %v", d.Period.Format("2006-01"), d.AccountID, err)
+			report.Failed++
+			continue
+		}
+		if c != nil {
+			report.Capitalized++
+		}
+	}
+
+	e.record(report)
+	return report, nil
+}
+
+// accrue works out and stores the interest for every day the account still needs, returning how many days
+func (e *Engine) accrue(ctx context.Context, account model.InterestAccount, rates []model.InterestRate, today time.Time) (int, error) {
+	var accruals []model.InterestAccrual
+	for _, date := range accrualDates(account, rates, today) {
+		rate := model.RateOn(rates, date)
+		if rate == nil {
+			continue
+		}
+
+		balance, err := e.ledger.GetBalanceAtTime(ctx, account.ID, model.EndOfInterestDay(date))
+		if err != nil {
+			return 0, err
+		}
+		amount, err := model.DailyInterest(balance, rate.AnnualRate, date)
+		if err != nil {
+			return 0, err
+		}
+
+		accruals = append(accruals, model.InterestAccrual{
+			AccountID:   account.ID,
+			AccrualDate: date,
+			Balance:     balance,
+			AnnualRate:  rate.AnnualRate,
+			Amount:      amount,
+		})
+	}
+
+	if len(accruals) == 0 {
+		return 0, nil
+	}
+	if err := e.repo.RecordAccruals(ctx, accruals); err != nil {
+		return 0, err
+	}
+	return len(accruals), nil
+}
+
+// accrualDates returns the days an account still needs interest accrued for, oldest first, ending yesterday
+// Accrual starts the day after the last accrued one, or for a new account the later of the day it was
+// opened and the day its first rate took effect
+func accrualDates(account model.InterestAccount, rates []model.InterestRate, today time.Time) []time.Time {
+	var start time.Time
+	if account.AccruedThrough != nil {
+		start = account.AccruedThrough.AddDate(0, 0, 1)
+	} else {
+		start = model.LocalDate(account.CreatedAt, model.InterestTimeZone)
+		var first *model.InterestRate
+		for i := range rates {
+			if first == nil || rates[i].EffectiveFrom.Before(first.EffectiveFrom) {
+				first = &rates[i]
+			}
+		}
+		if first == nil {
+			return nil
+		}
+		if first.EffectiveFrom.After(start) {
+			start = first.EffectiveFrom
+		}
+	}
+
+	var dates []time.Time
+	for date := start; date.Before(today); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+// record adds a run's counts to the cumulative stats
+func (e *Engine) record(report *Report) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.stats.Runs++
+	e.stats.Accrued += int64(report.Accrued)
+	e.stats.Capitalized += int64(report.Capitalized)
+	e.stats.Failed += int64(report.Failed)
+}
+
+// Start runs the engine on every interval until ctx is cancelled
+func (e *Engine) Start(ctx context.Context) {
+	log.Printf("Interest engine started (interval: %s)", e.interval)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Interest engine stopping")
+			return
+		case <-ticker.C:
+		}
+
+		report, err := e.RunOnce(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Interest engine run failed: %v", err)
+			continue
+		}
+
+		if report.Accrued > 0 || report.Capitalized > 0 || report.Failed > 0 {
+			stats := e.Stats()
+			log.Printf("Interest engine: %d days accrued, %d months capitalized, %d accounts failed (totals: %d capitalized over %d runs)",
+				report.Accrued, report.Capitalized, report.Failed, stats.Capitalized, stats.Runs)
+		}
+	}
+}
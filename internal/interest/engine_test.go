@@ -0,0 +1,89 @@
+package interest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func mustDate(s string) time.Time {
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestAccrualDates(t *testing.T) {
+	rates := []model.InterestRate{
+		{AnnualRate: "3", EffectiveFrom: mustDate("2026-10-10")},
+		{AnnualRate: "2", EffectiveFrom: mustDate("2026-10-05")},
+	}
+	accruedThrough := mustDate("2026-10-12")
+	today := mustDate("2026-10-15")
+
+	tests := []struct {
+		name    string
+		account model.InterestAccount
+		rates   []model.InterestRate
+		want    []string
+	}{
+		{
+			name:    "continues after the last accrual",
+			account: model.InterestAccount{CreatedAt: mustDate("2026-09-01"), AccruedThrough: &accruedThrough},
+			rates:   rates,
+			want:    []string{"2026-10-13", "2026-10-14"},
+		},
+		{
+			name:    "new account starts with the first rate",
+			account: model.InterestAccount{CreatedAt: mustDate("2026-09-01")},
+			rates:   rates,
+			want:    []string{"2026-10-05", "2026-10-06", "2026-10-07", "2026-10-08", "2026-10-09", "2026-10-10", "2026-10-11", "2026-10-12", "2026-10-13", "2026-10-14"},
+		},
+		{
+			// 22:30 UTC on 12 October is already 13 October in Oslo
+			name:    "new account starts the day it was opened",
+			account: model.InterestAccount{CreatedAt: time.Date(2026, 10, 12, 22, 30, 0, 0, time.UTC)},
+			rates:   rates,
+			want:    []string{"2026-10-13", "2026-10-14"},
+		},
+		{
+			name:    "nothing before today",
+			account: model.InterestAccount{CreatedAt: mustDate("2026-09-01"), AccruedThrough: &[]time.Time{mustDate("2026-10-14")}[0]},
+			rates:   rates,
+			want:    nil,
+		},
+		{
+			name:    "no rate",
+			account: model.InterestAccount{CreatedAt: mustDate("2026-09-01")},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := accrualDates(tt.account, tt.rates, today)
+			if len(got) != len(tt.want) {
+				t.Fatalf("accrualDates() returned %d dates, want %d", len(got), len(tt.want))
+			}
+			for i, date := range got {
+				if date.Format(time.DateOnly) != tt.want[i] {
+					t.Errorf("accrualDates()[%d] = %s, want %s", i, date.Format(time.DateOnly), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEngineRecord(t *testing.T) {
+	e := &Engine{}
+
+	e.record(&Report{Accrued: 30, Capitalized: 1})
+	e.record(&Report{Accrued: 2, Failed: 1})
+
+	want := Stats{Runs: 2, Accrued: 32, Capitalized: 1, Failed: 1}
+	if got := e.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
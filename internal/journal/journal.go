@@ -0,0 +1,236 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// Posting is a journal booked under one transaction
+// It may have any number of legs, as long as they sum to zero in each currency
+type Posting struct {
+	TransactionID uuid.UUID
+	Legs          []model.PostingLeg
+
+	// Check runs with the balances of every account in the legs locked, before anything is written,
+	// e.g. to enforce account status and funds. It returns a rejection reason, or "" to go ahead.
+	// Postings the bank forces through, such as reversals, have no Check.
+	Check func(balances map[uuid.UUID]model.Money) (string, error)
+}
+
+// Book books a posting inside dbTx, which the caller commits
+// This is the only place ledger entries are written. The legs are validated, the balances of their
+// accounts are locked in a fixed order and held until commit, and one entry per leg is inserted, with
+// the balance it leaves its account at, and applied to account_balances. The entries are then summed again in the database, and the posting
+// is rejected with ErrUnbalancedPosting unless they come to zero in each currency.
+// If Check rejects the posting, nothing is written and its reason is returned.
+func Book(ctx context.Context, dbTx pgx.Tx, posting Posting) (string, error) {
+	if err := model.ValidateLegs(posting.Legs); err != nil {
+		return "", err
+	}
+
+	accountIDs := make([]uuid.UUID, len(posting.Legs))
+	for i, leg := range posting.Legs {
+		accountIDs[i] = leg.AccountID
+	}
+	balances, err := LockBalances(ctx, dbTx, accountIDs)
+	if err != nil {
+		return "", err
+	}
+
+	if posting.Check != nil {
+		if reason, err := posting.Check(balances); err != nil || reason != "" {
+			return reason, err
+		}
+	}
+
+	entries := model.NewLedgerEntries(posting.TransactionID, posting.Legs, time.Now())
+	after, err := balancesAfter(balances, entries)
+	if err != nil {
+		return "", err
+	}
+	if err := insertEntries(ctx, dbTx, entries, after); err != nil {
+		return "", err
+	}
+	if err := applyToBalances(ctx, dbTx, entries); err != nil {
+		return "", err
+	}
+	if err := verifyBalanced(ctx, dbTx, posting.TransactionID); err != nil {
+		return "", err
+	}
+
+	return "", nil
+}
+
+// LockBalances locks the materialized balance rows of the given accounts with SELECT ... FOR UPDATE
+// and returns the balances. Missing rows are created first. Rows are locked in LockOrder, so two
+// postings touching the same accounts cannot deadlock. The locks are held until commit, and taking
+// them again later in the same transaction, e.g. in Book, is harmless.
+func LockBalances(ctx context.Context, dbTx pgx.Tx, accountIDs []uuid.UUID) (map[uuid.UUID]model.Money, error) {
+	ids := LockOrder(accountIDs)
+
+	balances := make(map[uuid.UUID]model.Money, len(ids))
+	for _, id := range ids {
+		_, err := dbTx.Exec(ctx, `
+			INSERT INTO account_balances (account_id) VALUES ($1)
+			ON CONFLICT (account_id) DO NOTHING
+		`, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create balance row: %w", err)
+		}
+
+		var balance, currency string
+		err = dbTx.QueryRow(ctx, `
+			SELECT ab.balance::text, a.currency
+			FROM account_balances ab
+			JOIN accounts a ON a.id = ab.account_id
+			WHERE ab.account_id = $1
+			FOR UPDATE OF ab
+		`, id).Scan(&balance, &currency)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, model.ErrAccountNotFound
+			}
+			return nil, fmt.Errorf("failed to lock balance: %w", err)
+		}
+
+		money, err := model.ParseMoney(balance, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse balance: %w", err)
+		}
+		balances[id] = money
+	}
+
+	return balances, nil
+}
+
+// LockOrder returns the distinct account IDs sorted by their bytes
+// Every lock on balance rows is taken in this order, and so are locks on account rows where a
+// caller takes several of them, e.g. closing an account with a sweep or reversing a transaction
+func LockOrder(accountIDs []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(accountIDs))
+	ids := make([]uuid.UUID, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	return ids
+}
+
+// balancesAfter returns the balance each entry leaves its account at, given the locked balances before the posting
+// The entries of a posting share their created_at, so they are applied in ID order, the order an account's
+// history lists them in
+func balancesAfter(balances map[uuid.UUID]model.Money, entries []model.LedgerEntry) ([]model.Money, error) {
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return bytes.Compare(entries[order[i]].ID[:], entries[order[j]].ID[:]) < 0
+	})
+
+	running := make(map[uuid.UUID]model.Money, len(balances))
+	for id, balance := range balances {
+		running[id] = balance
+	}
+
+	after := make([]model.Money, len(entries))
+	for _, i := range order {
+		balance, err := running[entries[i].AccountID].Add(entries[i].Amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute balance after entry: %w", err)
+		}
+		running[entries[i].AccountID] = balance
+		after[i] = balance
+	}
+	return after, nil
+}
+
+// insertEntries inserts the ledger entries with the balances they leave their accounts at
+func insertEntries(ctx context.Context, dbTx pgx.Tx, entries []model.LedgerEntry, after []model.Money) error {
+	query := `
+		INSERT INTO ledger_entries (id, transaction_id, account_id, amount, balance_after, entry_type, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	for i, entry := range entries {
+		_, err := dbTx.Exec(ctx, query,
+			entry.ID,
+			entry.TransactionID,
+			entry.AccountID,
+			entry.Amount.String(),
+			after[i].String(),
+			entry.EntryType,
+			entry.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create ledger entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// applyToBalances adds each ledger entry to its account's materialized balance
+// Must be called with the balance rows locked (see LockBalances)
+func applyToBalances(ctx context.Context, dbTx pgx.Tx, entries []model.LedgerEntry) error {
+	query := `
+		UPDATE account_balances
+		SET balance = balance + $1::numeric, version = version + 1, last_entry_id = $2, updated_at = $3
+		WHERE account_id = $4
+	`
+
+	for _, entry := range entries {
+		result, err := dbTx.Exec(ctx, query,
+			entry.Amount.String(),
+			entry.ID,
+			entry.CreatedAt,
+			entry.AccountID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update account balance: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return fmt.Errorf("no balance row for account %s", entry.AccountID)
+		}
+	}
+
+	return nil
+}
+
+// verifyBalanced checks that a transaction's ledger entries sum to zero in each account currency
+// It reads the rows just written in dbTx, so an unbalanced posting is rolled back rather than committed
+func verifyBalanced(ctx context.Context, dbTx pgx.Tx, transactionID uuid.UUID) error {
+	query := `
+		SELECT a.currency
+		FROM ledger_entries le
+		JOIN accounts a ON a.id = le.account_id
+		WHERE le.transaction_id = $1
+		GROUP BY a.currency
+		HAVING SUM(le.amount) <> 0
+		LIMIT 1
+	`
+
+	var currency string
+	err := dbTx.QueryRow(ctx, query, transactionID).Scan(&currency)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to verify posting balance: %w", err)
+	}
+	return fmt.Errorf("%w: %s entries of transaction %s do not sum to zero", model.ErrUnbalancedPosting, currency, transactionID)
+}
@@ -0,0 +1,81 @@
+package journal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func TestLockOrder(t *testing.T) {
+	a := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	b := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	c := uuid.MustParse("f0000000-0000-0000-0000-000000000000")
+
+	// A transfer with a fee names the source account twice
+	got := LockOrder([]uuid.UUID{c, a, b, a})
+
+	want := []uuid.UUID{a, b, c}
+	if len(got) != len(want) {
+		t.Fatalf("LockOrder() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("LockOrder()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLockOrder_SameForAnyInputOrder(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	reversed := []uuid.UUID{ids[2], ids[1], ids[0]}
+
+	first, second := LockOrder(ids), LockOrder(reversed)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("LockOrder() depends on input order: %v vs %v", first, second)
+		}
+	}
+}
+
+func TestBalancesAfter(t *testing.T) {
+	from := uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	to := uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	revenue := uuid.MustParse("00000000-0000-0000-0000-00000000000c")
+	money := func(amount string) model.Money {
+		m, err := model.ParseMoney(amount, "NOK")
+		if err != nil {
+			t.Fatalf("ParseMoney(%q) error = %v", amount, err)
+		}
+		return m
+	}
+	balances := map[uuid.UUID]model.Money{from: money("100.00"), to: money("5.00"), revenue: money("0.00")}
+
+	// A transfer of 50.00 with a fee of 2.00: the source has two entries, applied in ID order
+	entries := []model.LedgerEntry{
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000000003"), AccountID: from, Amount: money("-50.00")},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), AccountID: to, Amount: money("50.00")},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), AccountID: from, Amount: money("-2.00")},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-000000000004"), AccountID: revenue, Amount: money("2.00")},
+	}
+
+	got, err := balancesAfter(balances, entries)
+	if err != nil {
+		t.Fatalf("balancesAfter() error = %v", err)
+	}
+	want := []string{"48.00", "55.00", "98.00", "2.00"}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("balancesAfter()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+	if balances[from].String() != "100.00" {
+		t.Errorf("balancesAfter() changed the locked balances: %s", balances[from])
+	}
+
+	if _, err := balancesAfter(balances, []model.LedgerEntry{{AccountID: to, Amount: model.ZeroMoney("EUR")}}); !errors.Is(err, model.ErrCurrencyMismatch) {
+		t.Errorf("balancesAfter(other currency) error = %v, want %v", err, model.ErrCurrencyMismatch)
+	}
+}
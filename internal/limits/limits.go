@@ -0,0 +1,203 @@
+// Package limits evaluates the outgoing transfer limits of an account
+package limits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// Querier runs a single-row query; both *pgxpool.Pool and pgx.Tx satisfy it
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Violation describes the limit a transfer would break
+type Violation struct {
+	Period model.LimitPeriod
+	Limit  model.Money
+}
+
+// Reason is the error_message recorded on the failed transaction
+func (v *Violation) Reason() string {
+	return fmt.Sprintf("%s: %s limit of %s %s exceeded", model.LimitExceededReason, v.Period, v.Limit, v.Limit.Currency())
+}
+
+// Load returns the limits that apply to an account: the customer's override in the account's
+// currency where set, otherwise the default for the account type and currency
+func Load(ctx context.Context, q Querier, account *model.Account) (model.TransferLimits, error) {
+	query := `
+		SELECT COALESCE(o.per_transaction, d.per_transaction)::text,
+		       COALESCE(o.daily, d.daily)::text,
+		       COALESCE(o.monthly, d.monthly)::text
+		FROM (SELECT 1) one
+		LEFT JOIN transfer_limits d ON d.account_type = $1 AND d.currency = $2
+		LEFT JOIN customer_transfer_limits o ON o.customer_id = $3 AND o.currency = $2
+	`
+
+	var perTransaction, daily, monthly *string
+	err := q.QueryRow(ctx, query, account.AccountType, account.Currency, account.CustomerID).Scan(&perTransaction, &daily, &monthly)
+	if err != nil {
+		return model.TransferLimits{}, fmt.Errorf("failed to load transfer limits: %w", err)
+	}
+
+	return ParseLimits(account.Currency, perTransaction, daily, monthly)
+}
+
+// LoadUsage sums what has left an account in the current day and month
+// Completed transactions always count. Pending and processing ones count if they were initiated
+// at or before queuedBefore, so queued transfers use up the limits in the order they were made.
+// exclude is left out (the transaction being checked); pass uuid.Nil to count everything.
+func LoadUsage(ctx context.Context, q Querier, account *model.Account, exclude uuid.UUID, queuedBefore time.Time) (model.LimitUsage, error) {
+	query := `
+		WITH bounds AS (
+			SELECT date_trunc('day', NOW() AT TIME ZONE $2) AS day_start,
+			       date_trunc('month', NOW() AT TIME ZONE $2) AS month_start
+		)
+		SELECT COALESCE(SUM(t.amount) FILTER (WHERE t.initiated_at >= b.day_start AT TIME ZONE $2), 0)::text,
+		       COALESCE(SUM(t.amount), 0)::text,
+		       (b.day_start + INTERVAL '1 day') AT TIME ZONE $2,
+		       (b.month_start + INTERVAL '1 month') AT TIME ZONE $2
+		FROM bounds b
+		LEFT JOIN transactions t
+		       ON t.from_account_id = $1
+		      AND t.id <> $3
+		      AND t.type <> $8
+		      AND t.initiated_at >= b.month_start AT TIME ZONE $2
+		      AND (t.status = $4 OR (t.status IN ($5, $6) AND t.initiated_at <= $7))
+		GROUP BY b.day_start, b.month_start
+	`
+
+	var daily, monthly string
+	var usage model.LimitUsage
+	err := q.QueryRow(ctx, query,
+		account.ID,
+		model.LimitTimeZone,
+		exclude,
+		model.TransactionStatusCompleted,
+		model.TransactionStatusPending,
+		model.TransactionStatusProcessing,
+		queuedBefore,
+		model.TransactionTypeReversal,
+	).Scan(&daily, &monthly, &usage.DayEndsAt, &usage.MonthEndsAt)
+	if err != nil {
+		return model.LimitUsage{}, fmt.Errorf("failed to load transfer limit usage: %w", err)
+	}
+
+	if usage.Daily, err = model.ParseMoney(daily, account.Currency); err != nil {
+		return model.LimitUsage{}, err
+	}
+	if usage.Monthly, err = model.ParseMoney(monthly, account.Currency); err != nil {
+		return model.LimitUsage{}, err
+	}
+	return usage, nil
+}
+
+// ParseLimits converts nullable database amounts to limits in the given currency
+func ParseLimits(currency string, perTransaction, daily, monthly *string) (model.TransferLimits, error) {
+	var limits model.TransferLimits
+	for _, field := range []struct {
+		in  *string
+		out **model.Money
+	}{
+		{perTransaction, &limits.PerTransaction},
+		{daily, &limits.Daily},
+		{monthly, &limits.Monthly},
+	} {
+		if field.in == nil {
+			continue
+		}
+		amount, err := model.ParseMoney(*field.in, currency)
+		if err != nil {
+			return model.TransferLimits{}, err
+		}
+		*field.out = &amount
+	}
+	return limits, nil
+}
+
+// Check returns the first limit the amount would break on top of the usage, or nil
+// Limits are checked from the shortest period to the longest
+func Check(limits model.TransferLimits, usage model.LimitUsage, amount model.Money) (*Violation, error) {
+	if limits.PerTransaction != nil {
+		cmp, err := amount.Cmp(*limits.PerTransaction)
+		if err != nil {
+			return nil, err
+		}
+		if cmp > 0 {
+			return &Violation{Period: model.LimitPeriodTransaction, Limit: *limits.PerTransaction}, nil
+		}
+	}
+
+	for _, window := range []struct {
+		period model.LimitPeriod
+		limit  *model.Money
+		used   model.Money
+	}{
+		{model.LimitPeriodDaily, limits.Daily, usage.Daily},
+		{model.LimitPeriodMonthly, limits.Monthly, usage.Monthly},
+	} {
+		if window.limit == nil {
+			continue
+		}
+		total, err := window.used.Add(amount)
+		if err != nil {
+			return nil, err
+		}
+		cmp, err := total.Cmp(*window.limit)
+		if err != nil {
+			return nil, err
+		}
+		if cmp > 0 {
+			return &Violation{Period: window.period, Limit: *window.limit}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Headroom reports how much of each limit is left and the largest transfer allowed right now
+func Headroom(account *model.Account, limits model.TransferLimits, usage model.LimitUsage) model.LimitHeadroom {
+	headroom := model.LimitHeadroom{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		PerTransaction: limits.PerTransaction,
+		Daily:          window(limits.Daily, usage.Daily, usage.DayEndsAt),
+		Monthly:        window(limits.Monthly, usage.Monthly, usage.MonthEndsAt),
+	}
+
+	for _, allowed := range []*model.Money{headroom.PerTransaction, headroom.Daily.Remaining, headroom.Monthly.Remaining} {
+		if allowed == nil {
+			continue
+		}
+		if headroom.MaxTransfer == nil {
+			headroom.MaxTransfer = allowed
+			continue
+		}
+		if cmp, err := allowed.Cmp(*headroom.MaxTransfer); err == nil && cmp < 0 {
+			headroom.MaxTransfer = allowed
+		}
+	}
+	return headroom
+}
+
+// window reports one daily or monthly limit; remaining never goes below zero,
+// which can happen when a limit is lowered after money has left the account
+func window(limit *model.Money, used model.Money, resetsAt time.Time) model.LimitWindow {
+	w := model.LimitWindow{Limit: limit, Used: used, ResetsAt: resetsAt}
+	if limit == nil {
+		return w
+	}
+
+	remaining, err := limit.Sub(used)
+	if err != nil || remaining.IsNegative() {
+		remaining = model.ZeroMoney(limit.Currency())
+	}
+	w.Remaining = &remaining
+	return w
+}
@@ -0,0 +1,153 @@
+package limits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func nok(t *testing.T, amount string) model.Money {
+	t.Helper()
+	m, err := model.ParseMoney(amount, "NOK")
+	if err != nil {
+		t.Fatalf("ParseMoney(%q) error = %v", amount, err)
+	}
+	return m
+}
+
+func nokPtr(t *testing.T, amount string) *model.Money {
+	m := nok(t, amount)
+	return &m
+}
+
+func TestCheck(t *testing.T) {
+	limits := model.TransferLimits{
+		PerTransaction: nokPtr(t, "1000.00"),
+		Daily:          nokPtr(t, "2000.00"),
+		Monthly:        nokPtr(t, "5000.00"),
+	}
+
+	tests := []struct {
+		name       string
+		daily      string
+		monthly    string
+		amount     string
+		wantPeriod model.LimitPeriod
+	}{
+		{"within all limits", "0", "0", "1000.00", ""},
+		{"above per-transaction limit", "0", "0", "1000.01", model.LimitPeriodTransaction},
+		{"fills the daily limit exactly", "1000.00", "1000.00", "1000.00", ""},
+		{"above daily limit", "1500.00", "1500.00", "600.00", model.LimitPeriodDaily},
+		{"above monthly limit", "0", "4500.00", "600.00", model.LimitPeriodMonthly},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := model.LimitUsage{Daily: nok(t, tt.daily), Monthly: nok(t, tt.monthly)}
+			violation, err := Check(limits, usage, nok(t, tt.amount))
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+
+			var got model.LimitPeriod
+			if violation != nil {
+				got = violation.Period
+			}
+			if got != tt.wantPeriod {
+				t.Errorf("Check() period = %q, want %q", got, tt.wantPeriod)
+			}
+		})
+	}
+}
+
+func TestCheck_NoLimits(t *testing.T) {
+	usage := model.LimitUsage{Daily: nok(t, "1000000.00"), Monthly: nok(t, "1000000.00")}
+	violation, err := Check(model.TransferLimits{}, usage, nok(t, "1000000.00"))
+	if err != nil || violation != nil {
+		t.Errorf("Check() = (%v, %v), want (nil, nil)", violation, err)
+	}
+}
+
+func TestViolation_Reason(t *testing.T) {
+	v := &Violation{Period: model.LimitPeriodDaily, Limit: nok(t, "200000")}
+	want := "limit_exceeded: daily limit of 200000.00 NOK exceeded"
+	if got := v.Reason(); got != want {
+		t.Errorf("Reason() = %q, want %q", got, want)
+	}
+}
+
+func TestHeadroom(t *testing.T) {
+	account := &model.Account{ID: uuid.New(), Currency: "NOK"}
+	dayEnd := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	monthEnd := time.Date(2026, 3, 31, 22, 0, 0, 0, time.UTC)
+
+	limits := model.TransferLimits{
+		PerTransaction: nokPtr(t, "1000.00"),
+		Daily:          nokPtr(t, "2000.00"),
+		Monthly:        nokPtr(t, "5000.00"),
+	}
+	usage := model.LimitUsage{
+		Daily:       nok(t, "1500.00"),
+		Monthly:     nok(t, "4000.00"),
+		DayEndsAt:   dayEnd,
+		MonthEndsAt: monthEnd,
+	}
+
+	h := Headroom(account, limits, usage)
+	if h.Daily.Remaining == nil || h.Daily.Remaining.String() != "500.00" {
+		t.Errorf("Daily.Remaining = %v, want 500.00", h.Daily.Remaining)
+	}
+	if h.Monthly.Remaining == nil || h.Monthly.Remaining.String() != "1000.00" {
+		t.Errorf("Monthly.Remaining = %v, want 1000.00", h.Monthly.Remaining)
+	}
+	if h.MaxTransfer == nil || h.MaxTransfer.String() != "500.00" {
+		t.Errorf("MaxTransfer = %v, want 500.00", h.MaxTransfer)
+	}
+	if !h.Daily.ResetsAt.Equal(dayEnd) || !h.Monthly.ResetsAt.Equal(monthEnd) {
+		t.Errorf("ResetsAt = %v/%v, want %v/%v", h.Daily.ResetsAt, h.Monthly.ResetsAt, dayEnd, monthEnd)
+	}
+}
+
+func TestHeadroom_LoweredLimit(t *testing.T) {
+	// Usage above a limit that was lowered afterwards leaves nothing, not a negative amount
+	account := &model.Account{ID: uuid.New(), Currency: "NOK"}
+	limits := model.TransferLimits{Daily: nokPtr(t, "1000.00")}
+	usage := model.LimitUsage{Daily: nok(t, "1500.00"), Monthly: nok(t, "1500.00")}
+
+	h := Headroom(account, limits, usage)
+	if h.Daily.Remaining == nil || !h.Daily.Remaining.IsZero() {
+		t.Errorf("Daily.Remaining = %v, want 0.00", h.Daily.Remaining)
+	}
+	if h.Monthly.Remaining != nil {
+		t.Errorf("Monthly.Remaining = %v, want nil (no cap)", h.Monthly.Remaining)
+	}
+	if h.MaxTransfer == nil || !h.MaxTransfer.IsZero() {
+		t.Errorf("MaxTransfer = %v, want 0.00", h.MaxTransfer)
+	}
+}
+
+func TestHeadroom_NoLimits(t *testing.T) {
+	account := &model.Account{ID: uuid.New(), Currency: "NOK"}
+	usage := model.LimitUsage{Daily: nok(t, "0"), Monthly: nok(t, "0")}
+
+	if h := Headroom(account, model.TransferLimits{}, usage); h.MaxTransfer != nil {
+		t.Errorf("MaxTransfer = %v, want nil (no cap)", h.MaxTransfer)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	daily := "2000.0000" // As returned by DECIMAL(19,4)
+	limits, err := ParseLimits("NOK", nil, &daily, nil)
+	if err != nil {
+		t.Fatalf("ParseLimits() error = %v", err)
+	}
+	if limits.PerTransaction != nil || limits.Monthly != nil {
+		t.Errorf("NULL limits should stay nil, got %+v", limits)
+	}
+	if limits.Daily == nil || limits.Daily.String() != "2000.00" {
+		t.Errorf("Daily = %v, want 2000.00", limits.Daily)
+	}
+}
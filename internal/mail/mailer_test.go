@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m, err := NewFileMailer(dir)
+	if err != nil {
+		t.Fatalf("NewFileMailer() error = %v", err)
+	}
+
+	msg := Message{To: "kari@example.com", Subject: "Verify your email", Body: "Open this link"}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	if name := files[0].Name(); !strings.HasSuffix(name, "-kari@example.com.eml") {
+		t.Errorf("file name = %q, want it to end with the recipient", name)
+	}
+
+	content, _ := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	for _, want := range []string{"To: kari@example.com\r\n", "Subject: Verify your email\r\n", "\r\n\r\nOpen this link"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("email does not contain %q:\n%s", want, content)
+		}
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	if got := sanitizeFilename("../../etc/passwd"); strings.Contains(got, "/") {
+		t.Errorf("sanitizeFilename kept a path separator: %q", got)
+	}
+	if got := sanitizeFilename("ola.nordmann+bank@example.no"); got != "ola.nordmann_bank@example.no" {
+		t.Errorf("sanitizeFilename() = %q, want %q", got, "ola.nordmann_bank@example.no")
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+)
+
+// AdminTokenHeader carries the shared secret for back-office endpoints
+const AdminTokenHeader = "X-Admin-Token"
+
+// RequireAdminToken is middleware that only lets through requests carrying the admin token
+// The comparison is constant-time; hashing first makes it independent of the token length
+func RequireAdminToken(token string) func(http.Handler) http.Handler {
+	want := sha256.Sum256([]byte(token))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := sha256.Sum256([]byte(r.Header.Get(AdminTokenHeader)))
+			if r.Header.Get(AdminTokenHeader) == "" || subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+				writeUnauthorized(w, "Invalid admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,12 +2,14 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/google/uuid"
 
 	"github.com/simonkvalheim/hm9-banking/internal/auth"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
 )
 
 // ContextKey is the type for context keys to avoid collisions
@@ -18,6 +20,8 @@ const (
 	CustomerIDKey ContextKey = "customer_id"
 	// CustomerEmailKey is the context key for the authenticated customer email
 	CustomerEmailKey ContextKey = "customer_email"
+	// SessionIDKey is the context key for the session the access token belongs to
+	SessionIDKey ContextKey = "session_id"
 )
 
 // AuthMiddleware validates JWT tokens and adds customer info to context
@@ -62,9 +66,22 @@ func (m *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 			return
 		}
 
+		// Reject tokens whose session was logged out or revoked
+		if err := m.authService.ValidateSession(r.Context(), claims.SessionID); err != nil {
+			if errors.Is(err, model.ErrSessionRevoked) {
+				writeUnauthorized(w, "Session has been revoked")
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "Failed to validate session"}`))
+			return
+		}
+
 		// Add customer info to request context
 		ctx := context.WithValue(r.Context(), CustomerIDKey, claims.CustomerID)
 		ctx = context.WithValue(ctx, CustomerEmailKey, claims.Email)
+		ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
 
 		// Call next handler with enriched context
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -90,6 +107,16 @@ func GetCustomerEmail(ctx context.Context) string {
 	return email
 }
 
+// GetSessionID extracts the session ID from the request context
+// Returns uuid.Nil if not authenticated
+func GetSessionID(ctx context.Context) uuid.UUID {
+	id, ok := ctx.Value(SessionIDKey).(uuid.UUID)
+	if !ok {
+		return uuid.Nil
+	}
+	return id
+}
+
 func writeUnauthorized(w http.ResponseWriter, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusUnauthorized)
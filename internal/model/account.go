@@ -1,7 +1,9 @@
 package model
 
 import (
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
@@ -14,10 +16,26 @@ const (
 	AccountTypeSavings  AccountType = "savings"
 	AccountTypeLoan     AccountType = "loan"
 	AccountTypeEquity   AccountType = "equity"
+	AccountTypeRevenue  AccountType = "revenue"
 )
 
-// BankEquityAccountNumber is the well-known account number for the bank's equity account
-const BankEquityAccountNumber = "BANK-EQUITY-001"
+// BankEquityAccountPrefix prefixes the well-known account numbers of the bank's equity accounts
+// There is one equity account per currency, e.g. BANK-EQUITY-NOK
+const BankEquityAccountPrefix = "BANK-EQUITY-"
+
+// EquityAccountNumber returns the deterministic account number of the equity account for a currency
+func EquityAccountNumber(currency string) string {
+	return BankEquityAccountPrefix + currency
+}
+
+// BankRevenueAccountPrefix prefixes the well-known account numbers of the bank's revenue accounts
+// Transfer fees are paid into the revenue account of the transfer's currency, e.g. BANK-REVENUE-NOK
+const BankRevenueAccountPrefix = "BANK-REVENUE-"
+
+// RevenueAccountNumber returns the deterministic account number of the revenue account for a currency
+func RevenueAccountNumber(currency string) string {
+	return BankRevenueAccountPrefix + currency
+}
 
 // AccountStatus represents the current status of an account
 type AccountStatus string
@@ -30,20 +48,33 @@ const (
 
 // Account represents a bank account
 type Account struct {
-	ID            uuid.UUID     `json:"id"`
-	AccountNumber string        `json:"account_number"`
-	AccountType   AccountType   `json:"account_type"`
-	Currency      string        `json:"currency"`
-	Status        AccountStatus `json:"status"`
-	CustomerID    *uuid.UUID    `json:"customer_id,omitempty"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	ID             uuid.UUID     `json:"id"`
+	AccountNumber  string        `json:"account_number"` // 11-digit Norwegian BBAN; BANK-EQUITY-<CCY> or BANK-REVENUE-<CCY> for system accounts
+	IBAN           string        `json:"iban,omitempty"`
+	AccountType    AccountType   `json:"account_type"`
+	Currency       string        `json:"currency"`
+	Status         AccountStatus `json:"status"`
+	OverdraftLimit Money         `json:"overdraft_limit"` // Arranged overdraft; the balance may go down to -OverdraftLimit
+	CustomerID     *uuid.UUID    `json:"customer_id,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
 }
 
-// IsSystemAccount returns true if this is a system account (e.g., bank equity)
+// IsSystemAccount returns true if this is a system account (bank equity or revenue)
 // System accounts bypass certain validations like insufficient funds checks
 func (a *Account) IsSystemAccount() bool {
-	return a.AccountType == AccountTypeEquity
+	return a.AccountType == AccountTypeEquity || a.AccountType == AccountTypeRevenue
+}
+
+// AvailableBalance is what can be spent from a ledger balance: the balance plus the overdraft limit
+// The result is negative when the account is overdrawn beyond its limit (e.g. after the limit was lowered);
+// an unset limit counts as no overdraft
+func (a *Account) AvailableBalance(balance Money) Money {
+	available, err := balance.Add(a.OverdraftLimit)
+	if err != nil {
+		return balance
+	}
+	return available
 }
 
 // CreateAccountRequest is the payload for creating a new account
@@ -55,7 +86,7 @@ type CreateAccountRequest struct {
 // Validate checks if the create request is valid
 func (r CreateAccountRequest) Validate() error {
 	// Reject system account types - these can only be created internally
-	if r.AccountType == AccountTypeEquity {
+	if r.AccountType == AccountTypeEquity || r.AccountType == AccountTypeRevenue {
 		return ErrSystemAccountType
 	}
 
@@ -69,13 +100,85 @@ func (r CreateAccountRequest) Validate() error {
 		return ErrInvalidCurrency
 	}
 
+	if !IsSupportedCurrency(r.Currency) {
+		return ErrUnsupportedCurrency
+	}
+
 	return nil
 }
 
+// AccountHolder identifies who owns an account, for confirming a payee before paying them
+// Account numbers are sequential and easy to guess, so only a masked name is exposed
+type AccountHolder struct {
+	AccountNumber string `json:"account_number"`
+	IBAN          string `json:"iban,omitempty"`
+	Currency      string `json:"currency"`
+	HolderName    string `json:"holder_name"`
+}
+
+// MaskedHolderName shortens a name to first name and last initial, e.g. "Kari N."
+func MaskedHolderName(firstName, lastName string) string {
+	firstName = strings.TrimSpace(firstName)
+	lastName = strings.TrimSpace(lastName)
+	if lastName == "" {
+		return firstName
+	}
+
+	initial, _ := utf8.DecodeRuneInString(lastName)
+	if firstName == "" {
+		return string(initial) + "."
+	}
+	return firstName + " " + string(initial) + "."
+}
+
 // AccountBalance represents an account's current balance
+// Balance is the ledger balance; AvailableBalance adds the account's current overdraft limit
 type AccountBalance struct {
-	AccountID uuid.UUID `json:"account_id"`
-	Balance   string    `json:"balance"`
-	Currency  string    `json:"currency"`
-	AsOf      time.Time `json:"as_of"`
-}
\ No newline at end of file
+	AccountID        uuid.UUID `json:"account_id"`
+	Balance          Money     `json:"balance"`
+	AvailableBalance Money     `json:"available_balance"`
+	OverdraftLimit   Money     `json:"overdraft_limit"`
+	Currency         string    `json:"currency"`
+	AsOf             time.Time `json:"as_of"`
+}
+
+// BalanceDrift reports a materialized balance that disagrees with the ledger
+type BalanceDrift struct {
+	AccountID  uuid.UUID `json:"account_id"`
+	Stored     Money     `json:"stored"`     // Value in account_balances
+	Derived    Money     `json:"derived"`    // SUM of ledger_entries
+	Difference Money     `json:"difference"` // Stored - Derived
+}
+
+// SetOverdraftRequest is the admin payload for arranging, changing or removing an overdraft
+type SetOverdraftRequest struct {
+	OverdraftLimit string `json:"overdraft_limit"`
+}
+
+// Parse validates the limit in the account's currency; "0" removes the overdraft
+func (r SetOverdraftRequest) Parse(currency string) (Money, error) {
+	if r.OverdraftLimit == "" {
+		return Money{}, ErrInvalidOverdraftLimit
+	}
+	limit, err := ParseMoney(r.OverdraftLimit, currency)
+	if err != nil {
+		return Money{}, err
+	}
+	if limit.IsNegative() {
+		return Money{}, ErrInvalidOverdraftLimit
+	}
+	return limit, nil
+}
+
+// OverdrawnAccount is one line of the overdraft report
+type OverdrawnAccount struct {
+	AccountID      uuid.UUID     `json:"account_id"`
+	AccountNumber  string        `json:"account_number"`
+	CustomerID     *uuid.UUID    `json:"customer_id,omitempty"`
+	AccountType    AccountType   `json:"account_type"`
+	Status         AccountStatus `json:"status"`
+	Currency       string        `json:"currency"`
+	Balance        Money         `json:"balance"`
+	OverdraftLimit Money         `json:"overdraft_limit"`
+	OverLimit      bool          `json:"over_limit"` // Overdrawn beyond the arranged limit
+}
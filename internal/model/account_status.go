@@ -0,0 +1,81 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxStatusReasonLength limits the free-text reason recorded with a status change
+const MaxStatusReasonLength = 500
+
+// StatusActor identifies who changed an account's status
+type StatusActor string
+
+const (
+	StatusActorCustomer StatusActor = "customer"
+	StatusActorAdmin    StatusActor = "admin"
+)
+
+// AccountStatusChange is one entry in an account's status history
+type AccountStatusChange struct {
+	ID                 uuid.UUID     `json:"id"`
+	AccountID          uuid.UUID     `json:"account_id"`
+	FromStatus         AccountStatus `json:"from_status"`
+	ToStatus           AccountStatus `json:"to_status"`
+	Actor              StatusActor   `json:"actor"`
+	ActorCustomerID    *uuid.UUID    `json:"actor_customer_id,omitempty"`
+	Reason             string        `json:"reason,omitempty"`
+	SweepTransactionID *uuid.UUID    `json:"sweep_transaction_id,omitempty"` // Set when closing moved the remaining balance
+	CreatedAt          time.Time     `json:"created_at"`
+}
+
+// StatusChange describes who is changing an account's status and why
+type StatusChange struct {
+	Actor      StatusActor
+	CustomerID *uuid.UUID // Set when Actor is StatusActorCustomer
+	Reason     string
+}
+
+// AccountStatusRequest is the payload for freezing or unfreezing an account
+type AccountStatusRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// Validate checks the reason; admins must always give one
+func (r *AccountStatusRequest) Validate(actor StatusActor) error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	return validateStatusReason(r.Reason, actor)
+}
+
+// CloseAccountRequest is the payload for closing an account
+// A positive balance must be swept to another active account of the same customer and currency
+type CloseAccountRequest struct {
+	SweepToAccountID *uuid.UUID `json:"sweep_to_account_id,omitempty"`
+	Reason           string     `json:"reason,omitempty"`
+}
+
+// Validate checks the reason; admins must always give one
+func (r *CloseAccountRequest) Validate(actor StatusActor) error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	return validateStatusReason(r.Reason, actor)
+}
+
+// AccountClosure is the result of closing an account
+type AccountClosure struct {
+	Account          *Account                 `json:"account"`
+	Interest         []InterestCapitalization `json:"interest,omitempty"` // Unpaid interest paid in before the sweep
+	SweepTransaction *Transaction             `json:"sweep_transaction,omitempty"`
+}
+
+// validateStatusReason checks the reason recorded in the status history
+func validateStatusReason(reason string, actor StatusActor) error {
+	if reason == "" && actor == StatusActorAdmin {
+		return ErrStatusReasonRequired
+	}
+	if len(reason) > MaxStatusReasonLength {
+		return ErrStatusReasonTooLong
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -66,6 +67,14 @@ func TestCreateAccountRequest_Validate(t *testing.T) {
 			},
 			wantErr: ErrInvalidCurrency,
 		},
+		{
+			name: "unsupported currency",
+			request: CreateAccountRequest{
+				AccountType: AccountTypeChecking,
+				Currency:    "XYZ",
+			},
+			wantErr: ErrUnsupportedCurrency,
+		},
 		{
 			name: "empty currency",
 			request: CreateAccountRequest{
@@ -111,3 +120,107 @@ func TestAccountStatus_Constants(t *testing.T) {
 		t.Errorf("AccountStatusClosed = %v, want closed", AccountStatusClosed)
 	}
 }
+
+func TestEquityAccountNumber(t *testing.T) {
+	if got := EquityAccountNumber("NOK"); got != "BANK-EQUITY-NOK" {
+		t.Errorf("EquityAccountNumber(NOK) = %v, want BANK-EQUITY-NOK", got)
+	}
+	if EquityAccountNumber("EUR") == EquityAccountNumber("USD") {
+		t.Error("EquityAccountNumber must differ per currency")
+	}
+}
+
+func TestAccountStatusRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		reason  string
+		actor   StatusActor
+		wantErr error
+	}{
+		{"customer without reason", "", StatusActorCustomer, nil},
+		{"customer with reason", "Lost my card", StatusActorCustomer, nil},
+		{"admin with reason", "Suspected fraud", StatusActorAdmin, nil},
+		{"admin without reason", "", StatusActorAdmin, ErrStatusReasonRequired},
+		{"admin with blank reason", "   ", StatusActorAdmin, ErrStatusReasonRequired},
+		{"reason too long", strings.Repeat("x", MaxStatusReasonLength+1), StatusActorCustomer, ErrStatusReasonTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AccountStatusRequest{Reason: tt.reason}
+			if err := req.Validate(tt.actor); err != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaskedHolderName(t *testing.T) {
+	tests := []struct {
+		first, last string
+		want        string
+	}{
+		{"Kari", "Nordmann", "Kari N."},
+		{"Øystein", "Ødegård", "Øystein Ø."},
+		{" Ola ", " Hansen ", "Ola H."},
+		{"Kari", "", "Kari"},
+		{"", "Nordmann", "N."},
+	}
+
+	for _, tt := range tests {
+		if got := MaskedHolderName(tt.first, tt.last); got != tt.want {
+			t.Errorf("MaskedHolderName(%q, %q) = %q, want %q", tt.first, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestAccount_AvailableBalance(t *testing.T) {
+	nok := func(amount string) Money {
+		m, err := ParseMoney(amount, "NOK")
+		if err != nil {
+			t.Fatalf("ParseMoney(%q) error = %v", amount, err)
+		}
+		return m
+	}
+
+	tests := []struct {
+		name      string
+		limit     Money
+		balance   string
+		available string
+	}{
+		{"no overdraft", nok("0"), "100.00", "100.00"},
+		{"in credit with overdraft", nok("5000"), "100.00", "5100.00"},
+		{"overdrawn within limit", nok("5000"), "-2000.00", "3000.00"},
+		{"overdrawn beyond lowered limit", nok("1000"), "-2000.00", "-1000.00"},
+		{"unset limit", Money{}, "100.00", "100.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := &Account{Currency: "NOK", OverdraftLimit: tt.limit}
+			if got := account.AvailableBalance(nok(tt.balance)).String(); got != tt.available {
+				t.Errorf("AvailableBalance(%s) = %s, want %s", tt.balance, got, tt.available)
+			}
+		})
+	}
+}
+
+func TestSetOverdraftRequest_Parse(t *testing.T) {
+	tests := []struct {
+		limit   string
+		wantErr error
+	}{
+		{"5000.00", nil},
+		{"0", nil},
+		{"", ErrInvalidOverdraftLimit},
+		{"-100", ErrInvalidOverdraftLimit},
+		{"100.001", ErrAmountPrecision},
+	}
+
+	for _, tt := range tests {
+		if _, err := (SetOverdraftRequest{OverdraftLimit: tt.limit}).Parse("NOK"); err != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want %v", tt.limit, err, tt.wantErr)
+		}
+	}
+}
@@ -18,11 +18,11 @@ const (
 
 // Customer represents a bank customer
 type Customer struct {
-	ID           uuid.UUID      `json:"id"`
-	Email        string         `json:"email"`
-	PasswordHash string         `json:"-"` // Never serialize password hash!
-	FirstName    string         `json:"first_name"`
-	LastName     string         `json:"last_name"`
+	ID           uuid.UUID `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"` // Never serialize password hash!
+	FirstName    string    `json:"first_name"`
+	LastName     string    `json:"last_name"`
 
 	// Optional contact
 	Phone         *string `json:"phone,omitempty"`
@@ -46,6 +46,7 @@ type Customer struct {
 	LockedUntil         *time.Time     `json:"-"` // Don't expose
 	LastLoginAt         *time.Time     `json:"last_login_at,omitempty"`
 	PasswordChangedAt   *time.Time     `json:"-"`
+	TOTPEnabled         bool           `json:"totp_enabled"`
 
 	// Preferences
 	PreferredLanguage string `json:"preferred_language"`
@@ -82,11 +83,8 @@ func (r CreateCustomerRequest) Validate() error {
 	if r.Email == "" || !isValidEmail(r.Email) {
 		return ErrInvalidEmail
 	}
-	if len(r.Password) < 8 {
-		return ErrPasswordTooShort
-	}
-	if !isStrongPassword(r.Password) {
-		return ErrPasswordTooWeak
+	if err := ValidatePassword(r.Password); err != nil {
+		return err
 	}
 	if r.FirstName == "" {
 		return ErrFirstNameRequired
@@ -97,6 +95,17 @@ func (r CreateCustomerRequest) Validate() error {
 	return nil
 }
 
+// ValidatePassword checks a new password against the password rules
+func ValidatePassword(password string) error {
+	if len(password) < 8 {
+		return ErrPasswordTooShort
+	}
+	if !isStrongPassword(password) {
+		return ErrPasswordTooWeak
+	}
+	return nil
+}
+
 // isValidEmail performs basic email validation
 func isValidEmail(email string) bool {
 	// Basic check - contains @ and at least one dot after @
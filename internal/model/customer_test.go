@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{"valid", "Secret123", nil},
+		{"too short", "Sec123", ErrPasswordTooShort},
+		{"no uppercase", "secret123", ErrPasswordTooWeak},
+		{"no lowercase", "SECRET123", ErrPasswordTooWeak},
+		{"no digit", "SecretSecret", ErrPasswordTooWeak},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePassword(tt.password); err != tt.wantErr {
+				t.Errorf("ValidatePassword(%q) = %v, want %v", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResetPasswordRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request ResetPasswordRequest
+		wantErr error
+	}{
+		{"valid", ResetPasswordRequest{Token: "t", NewPassword: "Secret123"}, nil},
+		{"missing token", ResetPasswordRequest{NewPassword: "Secret123"}, ErrVerificationTokenInvalid},
+		{"weak password", ResetPasswordRequest{Token: "t", NewPassword: "password"}, ErrPasswordTooWeak},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.request.Validate(); err != tt.wantErr {
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,6 +8,24 @@ var (
 	ErrInvalidAccountType   = errors.New("invalid account type: must be checking, savings, or loan")
 	ErrSystemAccountType    = errors.New("cannot create system account type via API")
 	ErrInvalidCurrency      = errors.New("invalid currency: must be 3-letter ISO code")
+	ErrUnsupportedCurrency  = errors.New("unsupported currency")
+	ErrInvalidAccountNumber = errors.New("invalid account number or IBAN")
+
+	// Account lifecycle errors
+	ErrAccountFrozen                 = errors.New("account is frozen")
+	ErrAccountClosed                 = errors.New("account is closed")
+	ErrAccountNotFrozen              = errors.New("account is not frozen")
+	ErrAccountFrozenByBank           = errors.New("account was frozen by the bank and can only be unfrozen by the bank")
+	ErrSystemAccountStatus           = errors.New("system account status cannot be changed")
+	ErrBalanceNotZero                = errors.New("account balance must be zero, or swept to another account if positive")
+	ErrInvalidSweepAccount           = errors.New("sweep account must be another active account of the same customer and currency")
+	ErrAccountHasPendingTransactions = errors.New("account has pending transactions")
+	ErrStatusReasonRequired          = errors.New("reason is required")
+	ErrStatusReasonTooLong           = errors.New("reason must be at most 500 characters")
+
+	// Overdraft errors
+	ErrInvalidOverdraftLimit = errors.New("overdraft_limit must be zero or a positive amount")
+	ErrOverdraftNotAllowed   = errors.New("overdrafts are only available on checking accounts")
 
 	// Transaction errors
 	ErrInsufficientFunds       = errors.New("insufficient funds")
@@ -17,9 +35,52 @@ var (
 	ErrInvalidFromAccount      = errors.New("invalid source account")
 	ErrInvalidToAccount        = errors.New("invalid destination account")
 	ErrSameAccount             = errors.New("source and destination accounts must be different")
+	ErrAmbiguousDestination    = errors.New("give either to_account_id or to_account_number, not both")
 	ErrInvalidAmount           = errors.New("invalid amount")
+	ErrAmountPrecision         = errors.New("amount has more decimal places than the currency allows")
+	ErrAmountOverflow          = errors.New("amount out of range")
 	ErrCurrencyMismatch        = errors.New("currency mismatch between accounts")
 	ErrAccountNotActive        = errors.New("account is not active")
+	ErrTransactionNotPending   = errors.New("only pending transactions can be cancelled")
+	ErrNotReversible           = errors.New("only completed transactions can be reversed, and only once")
+	ErrInvalidPosting          = errors.New("a posting needs at least two legs, each with an account and a non-zero amount")
+	ErrUnbalancedPosting       = errors.New("posting legs must sum to zero in each currency")
+
+	// Transfer limit errors
+	ErrInvalidTransferLimit  = errors.New("transfer limits must be zero or positive amounts")
+	ErrTransferLimitOrder    = errors.New("per-transaction limit must not exceed the daily or monthly limit, nor the daily limit the monthly limit")
+	ErrTransferLimitNotFound = errors.New("no transfer limits found")
+
+	// Fee errors
+	ErrInvalidFeeType       = errors.New("fee_type must be flat or percentage")
+	ErrInvalidFeeSchedule   = errors.New("a flat fee takes flat_amount only; a percentage fee takes percentage and optionally min_fee and max_fee")
+	ErrInvalidFeeAmount     = errors.New("fee amounts must be zero or positive")
+	ErrInvalidFeePercentage = errors.New("percentage must be between 0 and 100 with at most 4 decimal places")
+	ErrFeeMinAboveMax       = errors.New("min_fee must not exceed max_fee")
+	ErrFeeScheduleNotFound  = errors.New("no fee schedule found")
+
+	// Interest errors
+	ErrInterestNotAllowed   = errors.New("interest is only paid on savings accounts")
+	ErrInvalidInterestRate  = errors.New("annual_rate must be between 0 and 100 with at most 4 decimal places")
+	ErrInvalidEffectiveDate = errors.New("effective_from must be a date in YYYY-MM-DD format")
+	ErrRateEffectiveInPast  = errors.New("effective_from must be today or later")
+
+	// Scheduled transfer errors
+	ErrInvalidFrequency          = errors.New("frequency must be once, weekly or monthly")
+	ErrInvalidScheduleDate       = errors.New("start_date and end_date must be dates in YYYY-MM-DD format")
+	ErrScheduleStartInPast       = errors.New("start_date must be today or later in your time zone")
+	ErrScheduleEndBeforeStart    = errors.New("end_date must not be before start_date")
+	ErrScheduleEndDateOnce       = errors.New("a one-off scheduled transfer cannot have an end_date")
+	ErrScheduledTransferNotFound = errors.New("scheduled transfer not found")
+	ErrScheduleNotActive         = errors.New("scheduled transfer is not active")
+
+	// Transaction history errors
+	ErrInvalidCursor            = errors.New("invalid cursor")
+	ErrInvalidDateRange         = errors.New("invalid date range: from must not be after to")
+	ErrInvalidDirection         = errors.New("invalid direction: must be debit or credit")
+	ErrInvalidTransactionStatus = errors.New("invalid transaction status")
+	ErrInvalidAmountRange       = errors.New("invalid amount range")
+	ErrInvalidLimit             = errors.New("invalid limit")
 
 	// Customer/Auth errors
 	ErrInvalidEmail       = errors.New("invalid email address")
@@ -33,4 +94,36 @@ var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
 	ErrAccountLocked      = errors.New("account is locked")
 	ErrAccountSuspended   = errors.New("account is suspended")
+
+	// Profile errors
+	ErrFieldTooLong       = errors.New("field is too long")
+	ErrInvalidPhone       = errors.New("phone must be in E.164 format, e.g. +4712345678")
+	ErrInvalidCountry     = errors.New("country must be an ISO 3166-1 alpha-2 code, e.g. NO")
+	ErrInvalidDateOfBirth = errors.New("date of birth must be a past date in YYYY-MM-DD format")
+	ErrInvalidLanguage    = errors.New("preferred language must be a two-letter ISO 639-1 code, e.g. en")
+	ErrInvalidTimezone    = errors.New("timezone must be an IANA time zone name, e.g. Europe/Oslo")
+	ErrIncorrectPassword  = errors.New("current password is incorrect")
+	ErrPasswordUnchanged  = errors.New("new password must be different from the current password")
+
+	// Refresh token errors
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+
+	// Two-factor authentication errors
+	ErrMFACodeRequired    = errors.New("two-factor code is required")
+	ErrInvalidMFACode     = errors.New("invalid two-factor code")
+	ErrInvalidMFAToken    = errors.New("invalid or expired two-factor challenge")
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled     = errors.New("two-factor enrollment has not been started")
+	ErrMFANotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrStepUpRequired     = errors.New("two-factor verification is required for this amount")
+	ErrStepUpNotAvailable = errors.New("two-factor authentication must be enabled for transfers of this amount")
+
+	// Email verification and password reset errors
+	ErrVerificationTokenInvalid = errors.New("invalid or expired token")
+	ErrEmailNotVerified         = errors.New("email address must be verified first")
+
+	// Session errors
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked")
 )
\ No newline at end of file
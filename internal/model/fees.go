@@ -0,0 +1,175 @@
+package model
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FeeType says how a transfer fee is worked out
+type FeeType string
+
+const (
+	FeeTypeFlat       FeeType = "flat"       // The same amount on every transfer
+	FeeTypePercentage FeeType = "percentage" // A share of the amount, optionally kept between a minimum and a maximum
+)
+
+// percentageUnits is how many units make up 1%: percentages have at most four decimals
+const percentageUnits = 10000
+
+var percentagePattern = regexp.MustCompile(`^\d{1,3}(\.\d{1,4})?$`)
+
+// FeeSchedule is the fee charged on transfers from every account of a type in a currency
+// There is no fee where no schedule is set
+type FeeSchedule struct {
+	AccountType AccountType `json:"account_type"`
+	Currency    string      `json:"currency"`
+	FeeType     FeeType     `json:"fee_type"`
+	FlatAmount  *Money      `json:"flat_amount,omitempty"` // Flat fees only
+	Percentage  string      `json:"percentage,omitempty"`  // Percentage fees only, e.g. "0.25" for 0.25 %
+	MinFee      *Money      `json:"min_fee,omitempty"`
+	MaxFee      *Money      `json:"max_fee,omitempty"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// Fee returns the fee on a transfer of amount
+// Percentage fees are rounded half up to the currency's minor unit, then raised to MinFee or
+// lowered to MaxFee where those are set
+func (s *FeeSchedule) Fee(amount Money) (Money, error) {
+	if amount.Currency() != s.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	switch s.FeeType {
+	case FeeTypeFlat:
+		if s.FlatAmount == nil {
+			return Money{}, ErrInvalidFeeSchedule
+		}
+		return *s.FlatAmount, nil
+	case FeeTypePercentage:
+	default:
+		return Money{}, ErrInvalidFeeType
+	}
+
+	units, err := parsePercentage(s.Percentage)
+	if err != nil {
+		return Money{}, err
+	}
+
+	// amount * units / (100 * percentageUnits), rounded half up, without overflowing int64
+	const divisor = 100 * percentageUnits
+	fee := new(big.Int).Mul(big.NewInt(amount.MinorUnits()), big.NewInt(units))
+	fee.Add(fee, big.NewInt(divisor/2))
+	fee.Quo(fee, big.NewInt(divisor))
+	if !fee.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+	result := Money{minor: fee.Int64(), currency: s.Currency}
+
+	if s.MinFee != nil && result.minor < s.MinFee.minor {
+		result = *s.MinFee
+	}
+	if s.MaxFee != nil && result.minor > s.MaxFee.minor {
+		result = *s.MaxFee
+	}
+	return result, nil
+}
+
+// TransferQuote is the response for POST /transfers/quote: what a transfer would cost before it is made
+type TransferQuote struct {
+	Amount          Money  `json:"amount"`
+	Fee             Money  `json:"fee"`
+	Total           Money  `json:"total"` // Amount plus fee: what leaves the source account
+	ToAccountNumber string `json:"to_account_number,omitempty"`
+	ToHolderName    string `json:"to_holder_name,omitempty"`
+}
+
+// NewTransferQuote adds up a transfer's amount and fee
+func NewTransferQuote(amount, fee Money) (TransferQuote, error) {
+	total, err := amount.Add(fee)
+	if err != nil {
+		return TransferQuote{}, err
+	}
+	return TransferQuote{Amount: amount, Fee: fee, Total: total}, nil
+}
+
+// SetFeeScheduleRequest is the admin payload for setting the fee schedule of an account type and currency
+// Amounts are decimal strings in the currency given in the URL
+type SetFeeScheduleRequest struct {
+	FeeType    FeeType `json:"fee_type"`
+	FlatAmount *string `json:"flat_amount"`
+	Percentage *string `json:"percentage"`
+	MinFee     *string `json:"min_fee"`
+	MaxFee     *string `json:"max_fee"`
+}
+
+// Parse validates the request and returns the schedule it describes
+// A flat fee takes flat_amount only; a percentage fee takes percentage and optionally min_fee and max_fee
+func (r *SetFeeScheduleRequest) Parse(accountType AccountType, currency string) (FeeSchedule, error) {
+	if !IsSupportedCurrency(currency) {
+		return FeeSchedule{}, ErrUnsupportedCurrency
+	}
+
+	s := FeeSchedule{AccountType: accountType, Currency: currency, FeeType: r.FeeType}
+	switch r.FeeType {
+	case FeeTypeFlat:
+		if r.FlatAmount == nil || r.Percentage != nil || r.MinFee != nil || r.MaxFee != nil {
+			return FeeSchedule{}, ErrInvalidFeeSchedule
+		}
+	case FeeTypePercentage:
+		if r.Percentage == nil || r.FlatAmount != nil {
+			return FeeSchedule{}, ErrInvalidFeeSchedule
+		}
+		percentage := strings.TrimSpace(*r.Percentage)
+		if _, err := parsePercentage(percentage); err != nil {
+			return FeeSchedule{}, err
+		}
+		s.Percentage = percentage
+	default:
+		return FeeSchedule{}, ErrInvalidFeeType
+	}
+
+	for _, field := range []struct {
+		in  *string
+		out **Money
+	}{
+		{r.FlatAmount, &s.FlatAmount},
+		{r.MinFee, &s.MinFee},
+		{r.MaxFee, &s.MaxFee},
+	} {
+		if field.in == nil {
+			continue
+		}
+		amount, err := ParseMoney(*field.in, currency)
+		if err != nil {
+			return FeeSchedule{}, err
+		}
+		if amount.IsNegative() {
+			return FeeSchedule{}, ErrInvalidFeeAmount
+		}
+		*field.out = &amount
+	}
+
+	if s.MinFee != nil && s.MaxFee != nil && s.MinFee.minor > s.MaxFee.minor {
+		return FeeSchedule{}, ErrFeeMinAboveMax
+	}
+	return s, nil
+}
+
+// parsePercentage parses a percentage such as "0.25" into units of 0.0001 %
+// It must be between 0 and 100 with at most four decimals
+func parsePercentage(s string) (int64, error) {
+	if !percentagePattern.MatchString(s) {
+		return 0, ErrInvalidFeePercentage
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	fracPart += strings.Repeat("0", 4-len(fracPart))
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil || units > 100*percentageUnits {
+		return 0, ErrInvalidFeePercentage
+	}
+	return units, nil
+}
@@ -0,0 +1,134 @@
+package model
+
+import "testing"
+
+func moneyPtr(amount, currency string) *Money {
+	m := mustMoney(amount, currency)
+	return &m
+}
+
+func TestFeeSchedule_Fee(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   Money
+		want     string
+	}{
+		{
+			name:     "flat",
+			schedule: FeeSchedule{Currency: "NOK", FeeType: FeeTypeFlat, FlatAmount: moneyPtr("5.00", "NOK")},
+			amount:   mustMoney("1000.00", "NOK"),
+			want:     "5.00",
+		},
+		{
+			name:     "percentage",
+			schedule: FeeSchedule{Currency: "NOK", FeeType: FeeTypePercentage, Percentage: "0.25"},
+			amount:   mustMoney("1000.00", "NOK"),
+			want:     "2.50",
+		},
+		{
+			name:     "percentage rounds half up",
+			schedule: FeeSchedule{Currency: "NOK", FeeType: FeeTypePercentage, Percentage: "1.5"},
+			amount:   mustMoney("0.99", "NOK"), // 0.01485
+			want:     "0.01",
+		},
+		{
+			name:     "percentage rounds half up at the midpoint",
+			schedule: FeeSchedule{Currency: "NOK", FeeType: FeeTypePercentage, Percentage: "0.5"},
+			amount:   mustMoney("1.00", "NOK"), // 0.005
+			want:     "0.01",
+		},
+		{
+			name:     "percentage raised to minimum",
+			schedule: FeeSchedule{Currency: "NOK", FeeType: FeeTypePercentage, Percentage: "0.1", MinFee: moneyPtr("1.00", "NOK")},
+			amount:   mustMoney("50.00", "NOK"),
+			want:     "1.00",
+		},
+		{
+			name:     "percentage lowered to maximum",
+			schedule: FeeSchedule{Currency: "NOK", FeeType: FeeTypePercentage, Percentage: "1", MaxFee: moneyPtr("25.00", "NOK")},
+			amount:   mustMoney("10000.00", "NOK"),
+			want:     "25.00",
+		},
+		{
+			name:     "percentage in a currency without decimals",
+			schedule: FeeSchedule{Currency: "JPY", FeeType: FeeTypePercentage, Percentage: "0.3"},
+			amount:   mustMoney("1250", "JPY"), // 3.75
+			want:     "4",
+		},
+		{
+			name:     "percentage as returned by the database",
+			schedule: FeeSchedule{Currency: "EUR", FeeType: FeeTypePercentage, Percentage: "2.0000"},
+			amount:   mustMoney("10.00", "EUR"),
+			want:     "0.20",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.schedule.Fee(tt.amount)
+			if err != nil {
+				t.Fatalf("Fee() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Fee() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	flat := FeeSchedule{Currency: "NOK", FeeType: FeeTypeFlat, FlatAmount: moneyPtr("5.00", "NOK")}
+	if _, err := flat.Fee(mustMoney("10.00", "EUR")); err != ErrCurrencyMismatch {
+		t.Errorf("Fee() in another currency error = %v, want %v", err, ErrCurrencyMismatch)
+	}
+}
+
+func TestSetFeeScheduleRequest_Parse(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name    string
+		req     SetFeeScheduleRequest
+		wantErr error
+	}{
+		{"flat", SetFeeScheduleRequest{FeeType: FeeTypeFlat, FlatAmount: str("5.00")}, nil},
+		{"free", SetFeeScheduleRequest{FeeType: FeeTypeFlat, FlatAmount: str("0")}, nil},
+		{"percentage", SetFeeScheduleRequest{FeeType: FeeTypePercentage, Percentage: str("0.25")}, nil},
+		{"percentage with bounds", SetFeeScheduleRequest{FeeType: FeeTypePercentage, Percentage: str("1"), MinFee: str("2.00"), MaxFee: str("50.00")}, nil},
+		{"hundred percent", SetFeeScheduleRequest{FeeType: FeeTypePercentage, Percentage: str("100")}, nil},
+		{"unknown type", SetFeeScheduleRequest{FeeType: "tiered", FlatAmount: str("5.00")}, ErrInvalidFeeType},
+		{"flat without amount", SetFeeScheduleRequest{FeeType: FeeTypeFlat}, ErrInvalidFeeSchedule},
+		{"flat with percentage", SetFeeScheduleRequest{FeeType: FeeTypeFlat, FlatAmount: str("5.00"), Percentage: str("1")}, ErrInvalidFeeSchedule},
+		{"flat with minimum", SetFeeScheduleRequest{FeeType: FeeTypeFlat, FlatAmount: str("5.00"), MinFee: str("1.00")}, ErrInvalidFeeSchedule},
+		{"percentage without percentage", SetFeeScheduleRequest{FeeType: FeeTypePercentage, MinFee: str("1.00")}, ErrInvalidFeeSchedule},
+		{"percentage with flat amount", SetFeeScheduleRequest{FeeType: FeeTypePercentage, Percentage: str("1"), FlatAmount: str("5.00")}, ErrInvalidFeeSchedule},
+		{"percentage over 100", SetFeeScheduleRequest{FeeType: FeeTypePercentage, Percentage: str("100.5")}, ErrInvalidFeePercentage},
+		{"percentage too precise", SetFeeScheduleRequest{FeeType: FeeTypePercentage, Percentage: str("0.12345")}, ErrInvalidFeePercentage},
+		{"negative percentage", SetFeeScheduleRequest{FeeType: FeeTypePercentage, Percentage: str("-1")}, ErrInvalidFeePercentage},
+		{"negative flat amount", SetFeeScheduleRequest{FeeType: FeeTypeFlat, FlatAmount: str("-5.00")}, ErrInvalidFeeAmount},
+		{"flat amount too precise", SetFeeScheduleRequest{FeeType: FeeTypeFlat, FlatAmount: str("5.001")}, ErrAmountPrecision},
+		{"minimum above maximum", SetFeeScheduleRequest{FeeType: FeeTypePercentage, Percentage: str("1"), MinFee: str("10.00"), MaxFee: str("5.00")}, ErrFeeMinAboveMax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.req.Parse(AccountTypeChecking, "NOK"); err != tt.wantErr {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	req := SetFeeScheduleRequest{FeeType: FeeTypeFlat, FlatAmount: str("5.00")}
+	if _, err := req.Parse(AccountTypeChecking, "XYZ"); err != ErrUnsupportedCurrency {
+		t.Errorf("Parse() in an unsupported currency error = %v, want %v", err, ErrUnsupportedCurrency)
+	}
+}
+
+func TestNewTransferQuote(t *testing.T) {
+	quote, err := NewTransferQuote(mustMoney("1000.00", "NOK"), mustMoney("2.50", "NOK"))
+	if err != nil {
+		t.Fatalf("NewTransferQuote() error = %v", err)
+	}
+	if quote.Total.String() != "1002.50" {
+		t.Errorf("Total = %s, want 1002.50", quote.Total)
+	}
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultHistoryLimit is the page size used when none is requested
+	DefaultHistoryLimit = 50
+	// MaxHistoryLimit caps the page size for transaction history
+	MaxHistoryLimit = 100
+)
+
+// HistoryCursor identifies the last ledger line of a page (keyset pagination)
+// Lines are ordered newest first by (created_at, id)
+type HistoryCursor struct {
+	CreatedAt time.Time `json:"t"`
+	EntryID   uuid.UUID `json:"id"`
+}
+
+// Encode returns the cursor as an opaque URL-safe string
+func (c HistoryCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeHistoryCursor parses a cursor produced by HistoryCursor.Encode
+func DecodeHistoryCursor(s string) (*HistoryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c HistoryCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.EntryID == uuid.Nil || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// TransactionHistoryFilter narrows down an account's transaction history
+// Amount bounds apply to the absolute value of the ledger line
+type TransactionHistoryFilter struct {
+	From      *time.Time
+	To        *time.Time
+	Direction LedgerEntryType   // Empty means both debits and credits
+	Status    TransactionStatus // Empty means any status
+	MinAmount *Money
+	MaxAmount *Money
+	Cursor    *HistoryCursor
+	Limit     int
+}
+
+// Validate checks that the filter is internally consistent
+func (f TransactionHistoryFilter) Validate() error {
+	if f.From != nil && f.To != nil && f.From.After(*f.To) {
+		return ErrInvalidDateRange
+	}
+
+	switch f.Direction {
+	case "", LedgerEntryTypeDebit, LedgerEntryTypeCredit:
+	default:
+		return ErrInvalidDirection
+	}
+
+	switch f.Status {
+	case "", TransactionStatusPending, TransactionStatusProcessing,
+		TransactionStatusCompleted, TransactionStatusFailed,
+		TransactionStatusCancelled, TransactionStatusReversed:
+	default:
+		return ErrInvalidTransactionStatus
+	}
+
+	if f.MinAmount != nil && f.MinAmount.IsNegative() {
+		return ErrInvalidAmountRange
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil {
+		cmp, err := f.MinAmount.Cmp(*f.MaxAmount)
+		if err != nil || cmp > 0 {
+			return ErrInvalidAmountRange
+		}
+	}
+
+	if f.Limit < 0 || f.Limit > MaxHistoryLimit {
+		return ErrInvalidLimit
+	}
+
+	return nil
+}
+
+// TransactionHistoryEntry is one ledger line of an account joined with its transaction
+type TransactionHistoryEntry struct {
+	EntryID                   uuid.UUID         `json:"entry_id"`
+	TransactionID             uuid.UUID         `json:"transaction_id"`
+	Type                      TransactionType   `json:"type"`
+	Status                    TransactionStatus `json:"status"`
+	Reference                 string            `json:"reference,omitempty"`
+	Direction                 LedgerEntryType   `json:"direction"`
+	Amount                    Money             `json:"amount"` // Signed: negative for debits
+	RunningBalance            Money             `json:"running_balance"`
+	CounterpartyAccountID     *uuid.UUID        `json:"counterparty_account_id,omitempty"`
+	CounterpartyAccountNumber string            `json:"counterparty_account_number,omitempty"`
+	CreatedAt                 time.Time         `json:"created_at"`
+}
+
+// TransactionHistoryPage is a page of history with the cursor for the next page
+type TransactionHistoryPage struct {
+	Entries    []TransactionHistoryEntry `json:"entries"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestHistoryCursor_RoundTrip(t *testing.T) {
+	original := HistoryCursor{
+		CreatedAt: time.Date(2025, 12, 13, 10, 0, 0, 123456000, time.UTC),
+		EntryID:   uuid.New(),
+	}
+
+	decoded, err := DecodeHistoryCursor(original.Encode())
+	if err != nil {
+		t.Fatalf("DecodeHistoryCursor() error = %v", err)
+	}
+	if !decoded.CreatedAt.Equal(original.CreatedAt) || decoded.EntryID != original.EntryID {
+		t.Errorf("DecodeHistoryCursor() = %+v, want %+v", decoded, original)
+	}
+}
+
+func TestDecodeHistoryCursor_Invalid(t *testing.T) {
+	for _, s := range []string{"not base64!", "e30", "bm90IGpzb24"} {
+		if _, err := DecodeHistoryCursor(s); err != ErrInvalidCursor {
+			t.Errorf("DecodeHistoryCursor(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
+
+func TestTransactionHistoryFilter_Validate(t *testing.T) {
+	earlier := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(24 * time.Hour)
+	small, _ := ParseMoney("10.00", "NOK")
+	large, _ := ParseMoney("100.00", "NOK")
+
+	tests := []struct {
+		name    string
+		filter  TransactionHistoryFilter
+		wantErr error
+	}{
+		{
+			name:    "empty filter",
+			filter:  TransactionHistoryFilter{},
+			wantErr: nil,
+		},
+		{
+			name: "full filter",
+			filter: TransactionHistoryFilter{
+				From:      &earlier,
+				To:        &later,
+				Direction: LedgerEntryTypeDebit,
+				Status:    TransactionStatusCompleted,
+				MinAmount: &small,
+				MaxAmount: &large,
+				Limit:     MaxHistoryLimit,
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "from after to",
+			filter:  TransactionHistoryFilter{From: &later, To: &earlier},
+			wantErr: ErrInvalidDateRange,
+		},
+		{
+			name:    "invalid direction",
+			filter:  TransactionHistoryFilter{Direction: "sideways"},
+			wantErr: ErrInvalidDirection,
+		},
+		{
+			name:    "invalid status",
+			filter:  TransactionHistoryFilter{Status: "done"},
+			wantErr: ErrInvalidTransactionStatus,
+		},
+		{
+			name:    "min above max",
+			filter:  TransactionHistoryFilter{MinAmount: &large, MaxAmount: &small},
+			wantErr: ErrInvalidAmountRange,
+		},
+		{
+			name:    "limit too large",
+			filter:  TransactionHistoryFilter{Limit: MaxHistoryLimit + 1},
+			wantErr: ErrInvalidLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if err != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package model
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InterestTimeZone is the time zone whose calendar days interest accrues over and whose months it is paid in
+// It is the bank's calendar, the same one the transfer limits follow
+const InterestTimeZone = LimitTimeZone
+
+// AccrualScale is the number of decimals daily interest is accrued with
+// Interest is only rounded to the currency's minor unit when a month's accruals are capitalized
+const AccrualScale = 10
+
+// InterestRate is the annual rate paid on an account type in a currency from a date on
+// It applies until the next rate for the same account type and currency takes effect
+type InterestRate struct {
+	AccountType   AccountType `json:"account_type"`
+	Currency      string      `json:"currency"`
+	AnnualRate    string      `json:"annual_rate"`    // Percent per year, e.g. "2.5" for 2.5 %
+	EffectiveFrom time.Time   `json:"effective_from"` // A date in InterestTimeZone, held as midnight UTC
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// InterestAccount is an account that earns interest, and the last date its interest has been accrued for
+type InterestAccount struct {
+	ID             uuid.UUID
+	AccountType    AccountType
+	Currency       string
+	CreatedAt      time.Time
+	AccruedThrough *time.Time // nil until the first accrual
+}
+
+// InterestAccrual is one day's interest on an account, worked out from its balance at the end of the day
+type InterestAccrual struct {
+	AccountID   uuid.UUID `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	Balance     Money     `json:"balance"`     // Ledger balance at the end of the day
+	AnnualRate  string    `json:"annual_rate"` // Rate in effect on the day
+	Amount      string    `json:"amount"`      // Interest with AccrualScale decimals
+}
+
+// InterestCapitalization is a month of accrued interest paid into an account
+type InterestCapitalization struct {
+	AccountID     uuid.UUID  `json:"account_id"`
+	Period        time.Time  `json:"period"`                   // First day of the month
+	Accrued       string     `json:"accrued"`                  // Sum of the month's accruals with AccrualScale decimals
+	Amount        Money      `json:"amount"`                   // Accrued rounded to the currency's minor unit: what was paid
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"` // The deposit; nil if the interest rounded to zero
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// RateOn returns the rate in effect on date, or nil if none has taken effect yet
+// rates are those of one account type and currency, in any order
+func RateOn(rates []InterestRate, date time.Time) *InterestRate {
+	var current *InterestRate
+	for i := range rates {
+		if rates[i].EffectiveFrom.After(date) {
+			continue
+		}
+		if current == nil || rates[i].EffectiveFrom.After(current.EffectiveFrom) {
+			current = &rates[i]
+		}
+	}
+	return current
+}
+
+// DailyInterest returns the interest accrued on date for a day-end balance at an annual rate
+// The rate is divided over the days of the date's year (365 or 366). Only positive balances earn
+// interest. The result has AccrualScale decimals, rounded half up.
+func DailyInterest(balance Money, annualRate string, date time.Time) (string, error) {
+	units, err := parsePercentage(annualRate)
+	if err != nil {
+		return "", ErrInvalidInterestRate
+	}
+	if !balance.IsPositive() || units == 0 {
+		return formatAccrued(new(big.Int)), nil
+	}
+
+	scale, ok := CurrencyScale(balance.Currency())
+	if !ok {
+		return "", ErrUnsupportedCurrency
+	}
+
+	// balance * rate / 100 / days, in units of 10^-AccrualScale
+	numerator := new(big.Int).Mul(big.NewInt(balance.MinorUnits()), big.NewInt(units))
+	numerator.Mul(numerator, pow10(AccrualScale-scale))
+	denominator := big.NewInt(100 * percentageUnits * int64(daysInYear(date.Year())))
+
+	accrued := numerator.Add(numerator, new(big.Int).Quo(denominator, big.NewInt(2)))
+	accrued.Quo(accrued, denominator)
+	return formatAccrued(accrued), nil
+}
+
+// RoundAccrued rounds accrued interest, such as a month's sum of DailyInterest, half up to the currency's minor unit
+func RoundAccrued(accrued, currency string) (Money, error) {
+	scale, ok := CurrencyScale(currency)
+	if !ok {
+		return Money{}, ErrUnsupportedCurrency
+	}
+
+	value, err := parseAccrued(accrued)
+	if err != nil {
+		return Money{}, err
+	}
+	divisor := pow10(AccrualScale - scale)
+	value.Add(value, new(big.Int).Quo(divisor, big.NewInt(2)))
+	value.Quo(value, divisor)
+	if !value.IsInt64() {
+		return Money{}, ErrAmountOverflow
+	}
+	return NewMoney(value.Int64(), currency)
+}
+
+// UnpaidInterest sums accruals that have not been paid by month and rounds each month like RoundAccrued
+// Closing an account pays them all, including the month it is closed in. Months are returned in order;
+// only AccountID, Period, Accrued and Amount are set.
+func UnpaidInterest(accruals []InterestAccrual, currency string) ([]InterestCapitalization, error) {
+	var periods []time.Time
+	sums := make(map[time.Time]*big.Int)
+	for _, a := range accruals {
+		amount, err := parseAccrued(a.Amount)
+		if err != nil {
+			return nil, err
+		}
+		period := InterestPeriod(a.AccrualDate)
+		if sums[period] == nil {
+			periods = append(periods, period)
+			sums[period] = new(big.Int)
+		}
+		sums[period].Add(sums[period], amount)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Before(periods[j]) })
+
+	unpaid := make([]InterestCapitalization, 0, len(periods))
+	for _, period := range periods {
+		c := InterestCapitalization{Period: period, Accrued: formatAccrued(sums[period])}
+		if len(accruals) > 0 {
+			c.AccountID = accruals[0].AccountID
+		}
+		var err error
+		if c.Amount, err = RoundAccrued(c.Accrued, currency); err != nil {
+			return nil, err
+		}
+		unpaid = append(unpaid, c)
+	}
+	return unpaid, nil
+}
+
+// InterestPeriod returns the month a date's interest is paid for, as its first day
+func InterestPeriod(date time.Time) time.Time {
+	year, month, _ := date.Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// EndOfInterestDay returns the last instant of a date in InterestTimeZone
+// The ledger balance at that instant is the day-end balance interest accrues on
+func EndOfInterestDay(date time.Time) time.Time {
+	year, month, day := date.Date()
+	nextDay := time.Date(year, month, day+1, 0, 0, 0, 0, loadLocation(InterestTimeZone))
+	return nextDay.Add(-time.Microsecond)
+}
+
+// InterestIdempotencyKey returns the key of the deposit paying a month's interest into an account
+// It is derived from the account and the month, so interest can only be paid once per period
+func InterestIdempotencyKey(accountID uuid.UUID, period time.Time) string {
+	return "interest:" + accountID.String() + ":" + period.Format("2006-01")
+}
+
+// SetInterestRateRequest is the admin payload for setting the interest rate of an account type and currency
+type SetInterestRateRequest struct {
+	AnnualRate    string `json:"annual_rate"`
+	EffectiveFrom string `json:"effective_from"` // YYYY-MM-DD in InterestTimeZone
+}
+
+// Parse validates the request and returns the rate it describes
+// Only savings accounts earn interest. Days already accrued are never recalculated, so a rate
+// can take effect today at the earliest.
+func (r SetInterestRateRequest) Parse(accountType AccountType, currency string, now time.Time) (InterestRate, error) {
+	if accountType != AccountTypeSavings {
+		return InterestRate{}, ErrInterestNotAllowed
+	}
+	if !IsSupportedCurrency(currency) {
+		return InterestRate{}, ErrUnsupportedCurrency
+	}
+
+	annualRate := strings.TrimSpace(r.AnnualRate)
+	if _, err := parsePercentage(annualRate); err != nil {
+		return InterestRate{}, ErrInvalidInterestRate
+	}
+
+	effectiveFrom, err := time.Parse(time.DateOnly, r.EffectiveFrom)
+	if err != nil {
+		return InterestRate{}, ErrInvalidEffectiveDate
+	}
+	if effectiveFrom.Before(LocalDate(now, InterestTimeZone)) {
+		return InterestRate{}, ErrRateEffectiveInPast
+	}
+
+	return InterestRate{
+		AccountType:   accountType,
+		Currency:      currency,
+		AnnualRate:    annualRate,
+		EffectiveFrom: effectiveFrom,
+	}, nil
+}
+
+// parseAccrued parses a non-negative amount with up to AccrualScale decimals into units of 10^-AccrualScale
+func parseAccrued(accrued string) (*big.Int, error) {
+	intPart, fracPart, _ := strings.Cut(strings.TrimSpace(accrued), ".")
+	if intPart == "" || !isDigits(intPart) || (fracPart != "" && !isDigits(fracPart)) || len(fracPart) > AccrualScale {
+		return nil, ErrInvalidAmount
+	}
+	fracPart += strings.Repeat("0", AccrualScale-len(fracPart))
+
+	value, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok {
+		return nil, ErrInvalidAmount
+	}
+	return value, nil
+}
+
+// formatAccrued formats an amount in units of 10^-AccrualScale as a decimal string
+func formatAccrued(units *big.Int) string {
+	digits := units.String()
+	if len(digits) <= AccrualScale {
+		digits = strings.Repeat("0", AccrualScale-len(digits)+1) + digits
+	}
+	return digits[:len(digits)-AccrualScale] + "." + digits[len(digits)-AccrualScale:]
+}
+
+// pow10 returns 10^n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// daysInYear returns 366 in leap years and 365 otherwise
+func daysInYear(year int) int {
+	return time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
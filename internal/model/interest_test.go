@@ -0,0 +1,237 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDailyInterest(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance Money
+		rate    string
+		date    string
+		want    string
+	}{
+		{"whole cents", mustMoney("365000.00", "NOK"), "1", "2026-10-15", "10.0000000000"},
+		{"rounds half up", mustMoney("100000.00", "NOK"), "2.5", "2026-10-15", "6.8493150685"},
+		{"leap year", mustMoney("100000.00", "NOK"), "2.5", "2028-10-15", "6.8306010929"},
+		{"currency without decimals", mustMoney("1000000", "JPY"), "0.1", "2026-10-15", "2.7397260274"},
+		{"rate as returned by the database", mustMoney("365000.00", "NOK"), "1.0000", "2026-10-15", "10.0000000000"},
+		{"zero balance", mustMoney("0.00", "NOK"), "2.5", "2026-10-15", "0.0000000000"},
+		{"negative balance", mustMoney("-500.00", "NOK"), "2.5", "2026-10-15", "0.0000000000"},
+		{"zero rate", mustMoney("1000.00", "NOK"), "0", "2026-10-15", "0.0000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DailyInterest(tt.balance, tt.rate, mustDate(tt.date))
+			if err != nil {
+				t.Fatalf("DailyInterest() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DailyInterest() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := DailyInterest(mustMoney("1000.00", "NOK"), "abc", mustDate("2026-10-15")); err != ErrInvalidInterestRate {
+		t.Errorf("DailyInterest(invalid rate) error = %v, want %v", err, ErrInvalidInterestRate)
+	}
+}
+
+func TestDailyInterest_MonthRoundsOnce(t *testing.T) {
+	// 10000.00 NOK at 3 % earns 0.8219178082 a day; rounding each day to 0.82 would lose 0.06 over October
+	daily, err := DailyInterest(mustMoney("10000.00", "NOK"), "3", mustDate("2026-10-01"))
+	if err != nil {
+		t.Fatalf("DailyInterest() error = %v", err)
+	}
+	if daily != "0.8219178082" {
+		t.Fatalf("DailyInterest() = %s, want 0.8219178082", daily)
+	}
+
+	got, err := RoundAccrued("25.4794520542", "NOK") // 31 days
+	if err != nil {
+		t.Fatalf("RoundAccrued() error = %v", err)
+	}
+	if got.String() != "25.48" {
+		t.Errorf("RoundAccrued() = %s, want 25.48", got)
+	}
+}
+
+func TestRoundAccrued(t *testing.T) {
+	tests := []struct {
+		accrued  string
+		currency string
+		want     string
+	}{
+		{"25.4794520542", "NOK", "25.48"},
+		{"0.0049999999", "NOK", "0.00"},
+		{"0.005", "NOK", "0.01"},
+		{"12", "EUR", "12.00"},
+		{"2.5000000000", "JPY", "3"},
+		{"2.4999999999", "JPY", "2"},
+		{"0.0000000000", "NOK", "0.00"},
+	}
+
+	for _, tt := range tests {
+		got, err := RoundAccrued(tt.accrued, tt.currency)
+		if err != nil {
+			t.Errorf("RoundAccrued(%q, %s) error = %v", tt.accrued, tt.currency, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("RoundAccrued(%q, %s) = %s, want %s", tt.accrued, tt.currency, got, tt.want)
+		}
+	}
+
+	for _, accrued := range []string{"", "-1.00", "1.2.3", "abc", "0.00000000001"} {
+		if _, err := RoundAccrued(accrued, "NOK"); err != ErrInvalidAmount {
+			t.Errorf("RoundAccrued(%q) error = %v, want %v", accrued, err, ErrInvalidAmount)
+		}
+	}
+	if _, err := RoundAccrued("1.00", "XXX"); err != ErrUnsupportedCurrency {
+		t.Errorf("RoundAccrued(unsupported currency) error = %v, want %v", err, ErrUnsupportedCurrency)
+	}
+}
+
+func TestUnpaidInterest(t *testing.T) {
+	accountID := uuid.New()
+	accruals := []InterestAccrual{
+		{AccountID: accountID, AccrualDate: mustDate("2026-10-02"), Amount: "0.0049999999"},
+		{AccountID: accountID, AccrualDate: mustDate("2026-09-30"), Amount: "0.8219178082"},
+		{AccountID: accountID, AccrualDate: mustDate("2026-10-01"), Amount: "0.0000000002"},
+		{AccountID: accountID, AccrualDate: mustDate("2026-09-29"), Amount: "0.8219178082"},
+	}
+
+	got, err := UnpaidInterest(accruals, "NOK")
+	if err != nil {
+		t.Fatalf("UnpaidInterest() error = %v", err)
+	}
+
+	want := []struct {
+		period, accrued, amount string
+	}{
+		{"2026-09-01", "1.6438356164", "1.64"},
+		{"2026-10-01", "0.0050000001", "0.01"}, // rounded once for the month, not per day
+	}
+	if len(got) != len(want) {
+		t.Fatalf("UnpaidInterest() returned %d months, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].AccountID != accountID || got[i].Period.Format(time.DateOnly) != w.period || got[i].Accrued != w.accrued || got[i].Amount.String() != w.amount {
+			t.Errorf("UnpaidInterest()[%d] = %s %s %s, want %s %s %s", i, got[i].Period.Format(time.DateOnly), got[i].Accrued, got[i].Amount, w.period, w.accrued, w.amount)
+		}
+	}
+
+	if got, err := UnpaidInterest(nil, "NOK"); err != nil || len(got) != 0 {
+		t.Errorf("UnpaidInterest(nil) = %v, %v, want no months", got, err)
+	}
+	if _, err := UnpaidInterest([]InterestAccrual{{AccrualDate: mustDate("2026-10-01"), Amount: "abc"}}, "NOK"); err != ErrInvalidAmount {
+		t.Errorf("UnpaidInterest(invalid amount) error = %v, want %v", err, ErrInvalidAmount)
+	}
+}
+
+func TestRateOn(t *testing.T) {
+	rates := []InterestRate{
+		{AnnualRate: "3", EffectiveFrom: mustDate("2026-12-01")},
+		{AnnualRate: "2", EffectiveFrom: mustDate("2026-10-01")},
+	}
+
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2026-09-30", ""},
+		{"2026-10-01", "2"},
+		{"2026-11-30", "2"},
+		{"2026-12-01", "3"},
+		{"2027-06-01", "3"},
+	}
+
+	for _, tt := range tests {
+		got := RateOn(rates, mustDate(tt.date))
+		switch {
+		case got == nil && tt.want != "":
+			t.Errorf("RateOn(%s) = nil, want %s", tt.date, tt.want)
+		case got != nil && got.AnnualRate != tt.want:
+			t.Errorf("RateOn(%s) = %s, want %q", tt.date, got.AnnualRate, tt.want)
+		}
+	}
+}
+
+func TestInterestPeriod(t *testing.T) {
+	if got := InterestPeriod(mustDate("2026-10-31")); got != mustDate("2026-10-01") {
+		t.Errorf("InterestPeriod(2026-10-31) = %s, want 2026-10-01", got.Format(time.DateOnly))
+	}
+	if got := InterestPeriod(mustDate("2026-11-01")); got != mustDate("2026-11-01") {
+		t.Errorf("InterestPeriod(2026-11-01) = %s, want 2026-11-01", got.Format(time.DateOnly))
+	}
+}
+
+func TestEndOfInterestDay(t *testing.T) {
+	// Midnight in Oslo is 23:00 UTC the day before in winter and 22:00 in summer
+	if got, want := EndOfInterestDay(mustDate("2026-12-01")).UTC(), time.Date(2026, 12, 1, 22, 59, 59, 999999000, time.UTC); !got.Equal(want) {
+		t.Errorf("EndOfInterestDay(winter) = %s, want %s", got, want)
+	}
+	if got, want := EndOfInterestDay(mustDate("2026-07-01")).UTC(), time.Date(2026, 7, 1, 21, 59, 59, 999999000, time.UTC); !got.Equal(want) {
+		t.Errorf("EndOfInterestDay(summer) = %s, want %s", got, want)
+	}
+	// The clocks go back on 25 October 2026, so that day lasts 25 hours
+	if got, want := EndOfInterestDay(mustDate("2026-10-25")).UTC(), time.Date(2026, 10, 25, 22, 59, 59, 999999000, time.UTC); !got.Equal(want) {
+		t.Errorf("EndOfInterestDay(end of summer time) = %s, want %s", got, want)
+	}
+}
+
+func TestInterestIdempotencyKey(t *testing.T) {
+	id := uuid.MustParse("8f14e45f-ceea-467f-a0e6-000000000001")
+
+	got := InterestIdempotencyKey(id, mustDate("2026-10-01"))
+	if want := "interest:8f14e45f-ceea-467f-a0e6-000000000001:2026-10"; got != want {
+		t.Errorf("InterestIdempotencyKey() = %s, want %s", got, want)
+	}
+	if InterestIdempotencyKey(id, mustDate("2026-11-01")) == got {
+		t.Error("InterestIdempotencyKey() is the same for different months")
+	}
+}
+
+func TestSetInterestRateRequest_Parse(t *testing.T) {
+	// 23:30 UTC on 15 November is already 16 November in Oslo
+	now := time.Date(2026, 11, 15, 23, 30, 0, 0, time.UTC)
+
+	got, err := SetInterestRateRequest{AnnualRate: " 2.75 ", EffectiveFrom: "2026-11-16"}.Parse(AccountTypeSavings, "NOK", now)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := InterestRate{AccountType: AccountTypeSavings, Currency: "NOK", AnnualRate: "2.75", EffectiveFrom: mustDate("2026-11-16")}
+	if got != want {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		name        string
+		req         SetInterestRateRequest
+		accountType AccountType
+		currency    string
+		want        error
+	}{
+		{"checking account", SetInterestRateRequest{AnnualRate: "1", EffectiveFrom: "2026-12-01"}, AccountTypeChecking, "NOK", ErrInterestNotAllowed},
+		{"unsupported currency", SetInterestRateRequest{AnnualRate: "1", EffectiveFrom: "2026-12-01"}, AccountTypeSavings, "XXX", ErrUnsupportedCurrency},
+		{"missing rate", SetInterestRateRequest{EffectiveFrom: "2026-12-01"}, AccountTypeSavings, "NOK", ErrInvalidInterestRate},
+		{"negative rate", SetInterestRateRequest{AnnualRate: "-1", EffectiveFrom: "2026-12-01"}, AccountTypeSavings, "NOK", ErrInvalidInterestRate},
+		{"rate above 100", SetInterestRateRequest{AnnualRate: "100.5", EffectiveFrom: "2026-12-01"}, AccountTypeSavings, "NOK", ErrInvalidInterestRate},
+		{"missing date", SetInterestRateRequest{AnnualRate: "1"}, AccountTypeSavings, "NOK", ErrInvalidEffectiveDate},
+		{"date with time", SetInterestRateRequest{AnnualRate: "1", EffectiveFrom: "2026-12-01T00:00:00Z"}, AccountTypeSavings, "NOK", ErrInvalidEffectiveDate},
+		{"yesterday in Oslo", SetInterestRateRequest{AnnualRate: "1", EffectiveFrom: "2026-11-15"}, AccountTypeSavings, "NOK", ErrRateEffectiveInPast},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.req.Parse(tt.accountType, tt.currency, now); err != tt.want {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
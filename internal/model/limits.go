@@ -0,0 +1,136 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LimitExceededReason starts the error_message of a transaction failed by a transfer limit,
+// e.g. "limit_exceeded: daily limit of 200000.00 NOK exceeded"
+const LimitExceededReason = "limit_exceeded"
+
+// LimitTimeZone is the time zone whose calendar days and months the daily and monthly limits follow
+const LimitTimeZone = "Europe/Oslo"
+
+// LimitPeriod names one of the transfer limits
+type LimitPeriod string
+
+const (
+	LimitPeriodTransaction LimitPeriod = "per_transaction"
+	LimitPeriodDaily       LimitPeriod = "daily"
+	LimitPeriodMonthly     LimitPeriod = "monthly"
+)
+
+// TransferLimits caps the money leaving one account; a nil limit means no cap
+type TransferLimits struct {
+	PerTransaction *Money `json:"per_transaction"`
+	Daily          *Money `json:"daily"`
+	Monthly        *Money `json:"monthly"`
+}
+
+// LimitUsage is what has left an account in the current day and month,
+// counting completed and in-flight (pending or processing) transactions
+type LimitUsage struct {
+	Daily       Money
+	Monthly     Money
+	DayEndsAt   time.Time
+	MonthEndsAt time.Time
+}
+
+// LimitWindow reports a daily or monthly limit and how much of it is left
+type LimitWindow struct {
+	Limit     *Money    `json:"limit"` // null means no cap
+	Used      Money     `json:"used"`
+	Remaining *Money    `json:"remaining"` // null means no cap
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// LimitHeadroom is the response for an account's transfer limits
+type LimitHeadroom struct {
+	AccountID      uuid.UUID   `json:"account_id"`
+	Currency       string      `json:"currency"`
+	PerTransaction *Money      `json:"per_transaction"` // null means no cap
+	Daily          LimitWindow `json:"daily"`
+	Monthly        LimitWindow `json:"monthly"`
+	MaxTransfer    *Money      `json:"max_transfer"` // Largest transfer the limits allow right now; null means no cap
+}
+
+// TransferLimitDefault holds the limits for every account of a type in a currency
+type TransferLimitDefault struct {
+	AccountType AccountType    `json:"account_type"`
+	Currency    string         `json:"currency"`
+	Limits      TransferLimits `json:"limits"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// TransferLimitOverride replaces the default limits for one customer in one currency
+// A nil limit falls back to the default for the account type
+type TransferLimitOverride struct {
+	CustomerID uuid.UUID      `json:"customer_id"`
+	Currency   string         `json:"currency"`
+	Limits     TransferLimits `json:"limits"`
+	Reason     string         `json:"reason,omitempty"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// SetTransferLimitsRequest is the admin payload for setting default limits or a customer override
+// Amounts are decimal strings in the currency given in the URL; omitted or null means no cap
+// (for an override: use the default)
+type SetTransferLimitsRequest struct {
+	PerTransaction *string `json:"per_transaction"`
+	Daily          *string `json:"daily"`
+	Monthly        *string `json:"monthly"`
+	Reason         string  `json:"reason,omitempty"`
+}
+
+// Parse validates the amounts in the given currency and returns them as limits
+// Limits must not be negative, and a shorter period's limit must not exceed a longer one's
+func (r *SetTransferLimitsRequest) Parse(currency string) (TransferLimits, error) {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if len(r.Reason) > MaxStatusReasonLength {
+		return TransferLimits{}, ErrStatusReasonTooLong
+	}
+	if !IsSupportedCurrency(currency) {
+		return TransferLimits{}, ErrUnsupportedCurrency
+	}
+
+	var limits TransferLimits
+	for _, field := range []struct {
+		in  *string
+		out **Money
+	}{
+		{r.PerTransaction, &limits.PerTransaction},
+		{r.Daily, &limits.Daily},
+		{r.Monthly, &limits.Monthly},
+	} {
+		if field.in == nil {
+			continue
+		}
+		amount, err := ParseMoney(*field.in, currency)
+		if err != nil {
+			return TransferLimits{}, err
+		}
+		if amount.IsNegative() {
+			return TransferLimits{}, ErrInvalidTransferLimit
+		}
+		*field.out = &amount
+	}
+
+	if exceeds(limits.PerTransaction, limits.Daily) ||
+		exceeds(limits.PerTransaction, limits.Monthly) ||
+		exceeds(limits.Daily, limits.Monthly) {
+		return TransferLimits{}, ErrTransferLimitOrder
+	}
+	return limits, nil
+}
+
+// exceeds reports whether both limits are set and the first is larger than the second
+func exceeds(shorter, longer *Money) bool {
+	if shorter == nil || longer == nil {
+		return false
+	}
+	cmp, err := shorter.Cmp(*longer)
+	return err == nil && cmp > 0
+}
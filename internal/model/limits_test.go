@@ -0,0 +1,34 @@
+package model
+
+import "testing"
+
+func TestSetTransferLimitsRequest_Parse(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		request  SetTransferLimitsRequest
+		currency string
+		wantErr  error
+	}{
+		{"all limits", SetTransferLimitsRequest{PerTransaction: str("1000"), Daily: str("2000"), Monthly: str("5000")}, "NOK", nil},
+		{"no limits", SetTransferLimitsRequest{}, "NOK", nil},
+		{"zero blocks transfers", SetTransferLimitsRequest{Daily: str("0")}, "NOK", nil},
+		{"negative", SetTransferLimitsRequest{Daily: str("-1")}, "NOK", ErrInvalidTransferLimit},
+		{"too precise", SetTransferLimitsRequest{Daily: str("1.001")}, "NOK", ErrAmountPrecision},
+		{"not a number", SetTransferLimitsRequest{Daily: str("lots")}, "NOK", ErrInvalidAmount},
+		{"per transaction above daily", SetTransferLimitsRequest{PerTransaction: str("3000"), Daily: str("2000")}, "NOK", ErrTransferLimitOrder},
+		{"per transaction above monthly", SetTransferLimitsRequest{PerTransaction: str("3000"), Monthly: str("2000")}, "NOK", ErrTransferLimitOrder},
+		{"daily above monthly", SetTransferLimitsRequest{Daily: str("6000"), Monthly: str("5000")}, "NOK", ErrTransferLimitOrder},
+		{"unsupported currency", SetTransferLimitsRequest{Daily: str("100")}, "XYZ", ErrUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.request.Parse(tt.currency)
+			if err != tt.wantErr {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+// TOTPState is a customer's two-factor authentication state
+type TOTPState struct {
+	Secret                 string // Base32 secret; empty if never enrolled
+	Enabled                bool   // True once enrollment was confirmed with a valid code
+	LastStep               *int64 // Last accepted TOTP time step, to block replays
+	RecoveryCodesRemaining int
+}
+
+// MFACodeRequest is the payload for endpoints that take a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+// Validate checks that a code was supplied
+func (r MFACodeRequest) Validate() error {
+	if r.Code == "" {
+		return ErrMFACodeRequired
+	}
+	return nil
+}
+
+// MFALoginRequest is the payload for the second step of a two-factor login
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// Validate checks that the challenge token and code were supplied
+func (r MFALoginRequest) Validate() error {
+	if r.MFAToken == "" {
+		return ErrInvalidMFAToken
+	}
+	if r.Code == "" {
+		return ErrMFACodeRequired
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxAmountIntegerDigits is the number of integer digits that fit in the
+// DECIMAL(19,4) amount columns (19 total digits - 4 fractional digits)
+const MaxAmountIntegerDigits = 15
+
+// currencyScales maps supported ISO 4217 currency codes to their minor unit scale
+// (number of decimal places). All scales must be <= 4 to fit the DECIMAL(19,4) columns.
+var currencyScales = map[string]int{
+	"NOK": 2,
+	"SEK": 2,
+	"DKK": 2,
+	"EUR": 2,
+	"USD": 2,
+	"GBP": 2,
+	"CHF": 2,
+	"ISK": 0,
+	"JPY": 0,
+}
+
+// CurrencyScale returns the number of minor unit decimal places for a currency
+// The second return value is false if the currency is not supported
+func CurrencyScale(currency string) (int, bool) {
+	scale, ok := currencyScales[currency]
+	return scale, ok
+}
+
+// IsSupportedCurrency returns true if the currency has a known minor unit scale
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencyScales[currency]
+	return ok
+}
+
+// Money is an exact monetary amount in a specific currency
+// The amount is held as an integer number of minor units (e.g. øre for NOK),
+// so arithmetic and comparisons never suffer from floating point rounding.
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// NewMoney creates a Money value from an amount in minor units
+func NewMoney(minor int64, currency string) (Money, error) {
+	if !IsSupportedCurrency(currency) {
+		return Money{}, ErrUnsupportedCurrency
+	}
+	return Money{minor: minor, currency: currency}, nil
+}
+
+// ZeroMoney returns a zero amount in the given currency
+func ZeroMoney(currency string) Money {
+	return Money{currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "100.50" or "-42" into Money
+// Trailing zeros beyond the currency's scale are accepted (the database returns
+// "100.5000" for NOK), but non-zero digits beyond the scale are rejected.
+func ParseMoney(amount, currency string) (Money, error) {
+	scale, ok := CurrencyScale(currency)
+	if !ok {
+		return Money{}, ErrUnsupportedCurrency
+	}
+
+	s := strings.TrimSpace(amount)
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" || !isDigits(intPart) || (hasPoint && (fracPart == "" || !isDigits(fracPart))) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if len(intPart) > MaxAmountIntegerDigits {
+		return Money{}, ErrInvalidAmount
+	}
+
+	// Anything past the currency's scale must be zero padding
+	if len(fracPart) > scale {
+		if strings.Trim(fracPart[scale:], "0") != "" {
+			return Money{}, ErrAmountPrecision
+		}
+		fracPart = fracPart[:scale]
+	}
+	fracPart += strings.Repeat("0", scale-len(fracPart))
+
+	digits := intPart + fracPart
+	if digits == "" {
+		digits = "0"
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrInvalidAmount
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return Money{minor: minor, currency: currency}, nil
+}
+
+// Currency returns the ISO 4217 currency code
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// MinorUnits returns the amount as an integer number of minor units
+func (m Money) MinorUnits() int64 {
+	return m.minor
+}
+
+// IsZero returns true if the amount is zero
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+// IsPositive returns true if the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+// IsNegative returns true if the amount is less than zero
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// Neg returns the amount with its sign flipped
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// Add returns m + other
+// Returns ErrCurrencyMismatch if the currencies differ
+func (m Money) Add(other Money) (Money, error) {
+	if m.currency != other.currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	if (other.minor > 0 && m.minor > math.MaxInt64-other.minor) ||
+		(other.minor < 0 && m.minor < math.MinInt64-other.minor) {
+		return Money{}, ErrAmountOverflow
+	}
+	return Money{minor: m.minor + other.minor, currency: m.currency}, nil
+}
+
+// Sub returns m - other
+// Returns ErrCurrencyMismatch if the currencies differ
+func (m Money) Sub(other Money) (Money, error) {
+	return m.Add(other.Neg())
+}
+
+// Cmp compares m and other, returning -1, 0 or +1
+// Returns ErrCurrencyMismatch if the currencies differ
+func (m Money) Cmp(other Money) (int, error) {
+	if m.currency != other.currency {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.minor < other.minor:
+		return -1, nil
+	case m.minor > other.minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// String formats the amount with exactly the currency's number of decimals (e.g. "-100.50")
+// This is also the format used when writing amounts to the database
+func (m Money) String() string {
+	scale := currencyScales[m.currency]
+
+	sign := ""
+	abs := uint64(m.minor)
+	if m.minor < 0 {
+		sign = "-"
+		abs = uint64(-(m.minor + 1)) + 1 // Avoids overflow for MinInt64
+	}
+
+	digits := strconv.FormatUint(abs, 10)
+	if scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// MarshalJSON encodes the amount as a decimal string to avoid float precision loss in clients
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", m.String())), nil
+}
+
+// isDigits returns true if s consists only of ASCII digits
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
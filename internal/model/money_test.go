@@ -0,0 +1,199 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    string
+		currency  string
+		wantMinor int64
+		wantErr   error
+	}{
+		{
+			name:      "two decimals NOK",
+			amount:    "100.50",
+			currency:  "NOK",
+			wantMinor: 10050,
+		},
+		{
+			name:      "integer NOK",
+			amount:    "100",
+			currency:  "NOK",
+			wantMinor: 10000,
+		},
+		{
+			name:      "one decimal NOK",
+			amount:    "0.1",
+			currency:  "NOK",
+			wantMinor: 10,
+		},
+		{
+			name:      "database scale with trailing zeros",
+			amount:    "-250.0000",
+			currency:  "NOK",
+			wantMinor: -25000,
+		},
+		{
+			name:      "JPY has no minor units",
+			amount:    "1500",
+			currency:  "JPY",
+			wantMinor: 1500,
+		},
+		{
+			name:      "JPY with zero padding",
+			amount:    "1500.0000",
+			currency:  "JPY",
+			wantMinor: 1500,
+		},
+		{
+			name:      "surrounding whitespace",
+			amount:    " 42.00 ",
+			currency:  "EUR",
+			wantMinor: 4200,
+		},
+		{
+			name:     "too many decimals NOK",
+			amount:   "1.005",
+			currency: "NOK",
+			wantErr:  ErrAmountPrecision,
+		},
+		{
+			name:     "fractional JPY",
+			amount:   "1.5",
+			currency: "JPY",
+			wantErr:  ErrAmountPrecision,
+		},
+		{
+			name:     "unsupported currency",
+			amount:   "1.00",
+			currency: "XXX",
+			wantErr:  ErrUnsupportedCurrency,
+		},
+		{
+			name:     "empty",
+			amount:   "",
+			currency: "NOK",
+			wantErr:  ErrInvalidAmount,
+		},
+		{
+			name:     "exponent",
+			amount:   "1e3",
+			currency: "NOK",
+			wantErr:  ErrInvalidAmount,
+		},
+		{
+			name:     "missing integer part",
+			amount:   ".50",
+			currency: "NOK",
+			wantErr:  ErrInvalidAmount,
+		},
+		{
+			name:     "trailing point",
+			amount:   "5.",
+			currency: "NOK",
+			wantErr:  ErrInvalidAmount,
+		},
+		{
+			name:     "exceeds DECIMAL(19,4)",
+			amount:   "1000000000000000",
+			currency: "NOK",
+			wantErr:  ErrInvalidAmount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.amount, tt.currency)
+			if err != tt.wantErr {
+				t.Fatalf("ParseMoney(%q, %q) error = %v, wantErr %v", tt.amount, tt.currency, err, tt.wantErr)
+			}
+			if err == nil && got.MinorUnits() != tt.wantMinor {
+				t.Errorf("ParseMoney(%q, %q) minor = %d, want %d", tt.amount, tt.currency, got.MinorUnits(), tt.wantMinor)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+	}{
+		{10050, "NOK", "100.50"},
+		{5, "NOK", "0.05"},
+		{-5, "NOK", "-0.05"},
+		{0, "EUR", "0.00"},
+		{-25000, "USD", "-250.00"},
+		{1500, "JPY", "1500"},
+	}
+
+	for _, tt := range tests {
+		m, err := NewMoney(tt.minor, tt.currency)
+		if err != nil {
+			t.Fatalf("NewMoney(%d, %q) error = %v", tt.minor, tt.currency, err)
+		}
+		if got := m.String(); got != tt.want {
+			t.Errorf("NewMoney(%d, %q).String() = %q, want %q", tt.minor, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	a, _ := ParseMoney("0.10", "NOK")
+	b, _ := ParseMoney("0.20", "NOK")
+	want, _ := ParseMoney("0.30", "NOK")
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if cmp, _ := sum.Cmp(want); cmp != 0 {
+		t.Errorf("0.10 + 0.20 = %s, want exactly 0.30", sum)
+	}
+
+	diff, err := a.Sub(b)
+	if err != nil {
+		t.Fatalf("Sub() error = %v", err)
+	}
+	if !diff.IsNegative() || diff.String() != "-0.10" {
+		t.Errorf("0.10 - 0.20 = %s, want -0.10", diff)
+	}
+
+	if !a.Neg().Neg().IsPositive() {
+		t.Error("Neg().Neg() should restore the sign")
+	}
+}
+
+func TestMoney_CurrencyMismatch(t *testing.T) {
+	nok, _ := ParseMoney("1.00", "NOK")
+	eur, _ := ParseMoney("1.00", "EUR")
+
+	if _, err := nok.Add(eur); err != ErrCurrencyMismatch {
+		t.Errorf("Add() error = %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := nok.Cmp(eur); err != ErrCurrencyMismatch {
+		t.Errorf("Cmp() error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoney_MarshalJSON(t *testing.T) {
+	m, _ := ParseMoney("1234.5", "NOK")
+
+	data, err := json.Marshal(AccountBalance{Balance: m, Currency: "NOK"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded["balance"] != "1234.50" {
+		t.Errorf("balance = %v, want \"1234.50\"", decoded["balance"])
+	}
+}
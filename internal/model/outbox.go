@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEntry is a transaction waiting to be published to the processing queue
+type OutboxEntry struct {
+	ID              uuid.UUID       `json:"id"`
+	TransactionID   uuid.UUID       `json:"transaction_id"`
+	TransactionType TransactionType `json:"transaction_type"`
+	Attempts        int             `json:"attempts"`
+	CreatedAt       time.Time       `json:"created_at"`
+	PublishedAt     *time.Time      `json:"published_at,omitempty"`
+	LastError       string          `json:"last_error,omitempty"`
+}
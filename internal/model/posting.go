@@ -0,0 +1,131 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostingLeg is one line of a journal: an amount added to or taken from an account
+// Role names the account in failure reasons, e.g. "source account is frozen", and matches its transaction party role
+type PostingLeg struct {
+	AccountID uuid.UUID
+	Amount    Money // Positive = credit, negative = debit
+	Role      string
+}
+
+// TransferLegs returns the two legs of a plain transfer: amount from one account to another
+func TransferLegs(fromAccountID, toAccountID uuid.UUID, amount Money) []PostingLeg {
+	return []PostingLeg{
+		{AccountID: fromAccountID, Amount: amount.Neg(), Role: "source"},
+		{AccountID: toAccountID, Amount: amount, Role: "destination"},
+	}
+}
+
+// FeeLegs returns the two legs of a fee: paid from the source account into the bank's revenue account
+// The source leg has role "source", like the transfer it is charged on, and the revenue leg role "fee"
+func FeeLegs(fromAccountID, revenueAccountID uuid.UUID, fee Money) []PostingLeg {
+	return []PostingLeg{
+		{AccountID: fromAccountID, Amount: fee.Neg(), Role: "source"},
+		{AccountID: revenueAccountID, Amount: fee, Role: "fee"},
+	}
+}
+
+// ValidateLegs checks that a journal can be booked
+// It needs at least two legs, none of them zero, and the legs in each currency must sum to zero
+func ValidateLegs(legs []PostingLeg) error {
+	if len(legs) < 2 {
+		return ErrInvalidPosting
+	}
+
+	sums := make(map[string]Money)
+	for _, leg := range legs {
+		if leg.AccountID == uuid.Nil || leg.Amount.IsZero() {
+			return ErrInvalidPosting
+		}
+		currency := leg.Amount.Currency()
+		sum, ok := sums[currency]
+		if !ok {
+			sum = ZeroMoney(currency)
+		}
+		sum, err := sum.Add(leg.Amount)
+		if err != nil {
+			return err
+		}
+		sums[currency] = sum
+	}
+
+	for _, sum := range sums {
+		if !sum.IsZero() {
+			return ErrUnbalancedPosting
+		}
+	}
+	return nil
+}
+
+// NetByAccount sums the legs per account, in the order the accounts first appear
+// An account debited in one leg and credited in another, such as a fee refund, nets out
+func NetByAccount(legs []PostingLeg) ([]uuid.UUID, map[uuid.UUID]Money, error) {
+	var order []uuid.UUID
+	net := make(map[uuid.UUID]Money, len(legs))
+	for _, leg := range legs {
+		sum, ok := net[leg.AccountID]
+		if !ok {
+			order = append(order, leg.AccountID)
+			sum = ZeroMoney(leg.Amount.Currency())
+		}
+		sum, err := sum.Add(leg.Amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		net[leg.AccountID] = sum
+	}
+	return order, net, nil
+}
+
+// PartiesFor returns the transaction parties of a posting: one per account and role
+// A transaction can have any number of parties, e.g. a source, a destination and a fee account
+func PartiesFor(transactionID uuid.UUID, legs []PostingLeg) []TransactionParty {
+	type party struct {
+		accountID uuid.UUID
+		role      string
+	}
+	seen := make(map[party]bool, len(legs))
+
+	var parties []TransactionParty
+	for _, leg := range legs {
+		key := party{leg.AccountID, leg.Role}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		parties = append(parties, TransactionParty{
+			ID:            uuid.New(),
+			TransactionID: transactionID,
+			AccountID:     leg.AccountID,
+			Role:          leg.Role,
+		})
+	}
+	return parties
+}
+
+// NewLedgerEntries creates one ledger entry per leg, all stamped with the same time
+// Negative legs become debits and positive legs credits
+func NewLedgerEntries(transactionID uuid.UUID, legs []PostingLeg, now time.Time) []LedgerEntry {
+	entries := make([]LedgerEntry, len(legs))
+	for i, leg := range legs {
+		entryType := LedgerEntryTypeCredit
+		if leg.Amount.IsNegative() {
+			entryType = LedgerEntryTypeDebit
+		}
+		entries[i] = LedgerEntry{
+			ID:            uuid.New(),
+			TransactionID: transactionID,
+			AccountID:     leg.AccountID,
+			Amount:        leg.Amount,
+			EntryType:     entryType,
+			CreatedAt:     now,
+		}
+	}
+	return entries
+}
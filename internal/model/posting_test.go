@@ -0,0 +1,160 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func mustMoney(amount, currency string) Money {
+	m, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestValidateLegs(t *testing.T) {
+	from, to, revenue := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		legs    []PostingLeg
+		wantErr error
+	}{
+		{"transfer", TransferLegs(from, to, mustMoney("100.00", "NOK")), nil},
+		{"transfer with fee", []PostingLeg{
+			{AccountID: from, Amount: mustMoney("-102.50", "NOK")},
+			{AccountID: to, Amount: mustMoney("100.00", "NOK")},
+			{AccountID: revenue, Amount: mustMoney("2.50", "NOK")},
+		}, nil},
+		{"balanced in each of two currencies", []PostingLeg{
+			{AccountID: from, Amount: mustMoney("-10.00", "EUR")},
+			{AccountID: to, Amount: mustMoney("10.00", "EUR")},
+			{AccountID: revenue, Amount: mustMoney("-115.00", "NOK")},
+			{AccountID: from, Amount: mustMoney("115.00", "NOK")},
+		}, nil},
+		{"single leg", []PostingLeg{{AccountID: from, Amount: mustMoney("-1.00", "NOK")}}, ErrInvalidPosting},
+		{"zero leg", []PostingLeg{
+			{AccountID: from, Amount: mustMoney("-1.00", "NOK")},
+			{AccountID: to, Amount: mustMoney("1.00", "NOK")},
+			{AccountID: revenue, Amount: ZeroMoney("NOK")},
+		}, ErrInvalidPosting},
+		{"missing account", []PostingLeg{
+			{AccountID: from, Amount: mustMoney("-1.00", "NOK")},
+			{Amount: mustMoney("1.00", "NOK")},
+		}, ErrInvalidPosting},
+		{"one minor unit off", []PostingLeg{
+			{AccountID: from, Amount: mustMoney("-100.00", "NOK")},
+			{AccountID: to, Amount: mustMoney("99.99", "NOK")},
+		}, ErrUnbalancedPosting},
+		{"balanced only across currencies", []PostingLeg{
+			{AccountID: from, Amount: mustMoney("-10.00", "EUR")},
+			{AccountID: to, Amount: mustMoney("10.00", "NOK")},
+		}, ErrUnbalancedPosting},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLegs(tt.legs); err != tt.wantErr {
+				t.Errorf("ValidateLegs() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNetByAccount(t *testing.T) {
+	from, to, revenue := uuid.New(), uuid.New(), uuid.New()
+	legs := []PostingLeg{
+		{AccountID: from, Amount: mustMoney("-100.00", "NOK")},
+		{AccountID: to, Amount: mustMoney("100.00", "NOK")},
+		{AccountID: from, Amount: mustMoney("-2.50", "NOK")},
+		{AccountID: revenue, Amount: mustMoney("2.50", "NOK")},
+	}
+
+	order, net, err := NetByAccount(legs)
+	if err != nil {
+		t.Fatalf("NetByAccount() error = %v", err)
+	}
+	if len(order) != 3 || order[0] != from || order[1] != to || order[2] != revenue {
+		t.Errorf("NetByAccount() order = %v, want [from to revenue]", order)
+	}
+	if got := net[from].String(); got != "-102.50" {
+		t.Errorf("net[from] = %s, want -102.50", got)
+	}
+	if got := net[revenue].String(); got != "2.50" {
+		t.Errorf("net[revenue] = %s, want 2.50", got)
+	}
+}
+
+func TestPartiesFor(t *testing.T) {
+	txID, from, to, revenue := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	legs := append(TransferLegs(from, to, mustMoney("100.00", "NOK")), FeeLegs(from, revenue, mustMoney("2.50", "NOK"))...)
+	if err := ValidateLegs(legs); err != nil {
+		t.Fatalf("ValidateLegs(transfer with fee) error = %v", err)
+	}
+
+	parties := PartiesFor(txID, legs)
+	if len(parties) != 3 {
+		t.Fatalf("PartiesFor() returned %d parties, want 3", len(parties))
+	}
+	for i, want := range []struct {
+		account uuid.UUID
+		role    string
+	}{{from, "source"}, {to, "destination"}, {revenue, "fee"}} {
+		if parties[i].TransactionID != txID || parties[i].AccountID != want.account || parties[i].Role != want.role {
+			t.Errorf("party %d = %+v, want %s party", i, parties[i], want.role)
+		}
+	}
+}
+
+func TestNewLedgerEntries_Transfer(t *testing.T) {
+	txID := uuid.New()
+	fromID := uuid.New()
+	toID := uuid.New()
+	now := time.Now()
+
+	entries := NewLedgerEntries(txID, TransferLegs(fromID, toID, mustMoney("100.00", "NOK")), now)
+
+	if len(entries) != 2 {
+		t.Fatalf("NewLedgerEntries() returned %d entries, want 2", len(entries))
+	}
+
+	debit, credit := entries[0], entries[1]
+	if debit.TransactionID != txID || debit.AccountID != fromID || debit.Amount.String() != "-100.00" || debit.EntryType != LedgerEntryTypeDebit {
+		t.Errorf("debit entry = %+v, want -100.00 debit of the source account", debit)
+	}
+	if credit.TransactionID != txID || credit.AccountID != toID || credit.Amount.String() != "100.00" || credit.EntryType != LedgerEntryTypeCredit {
+		t.Errorf("credit entry = %+v, want 100.00 credit of the destination account", credit)
+	}
+	if debit.ID == credit.ID {
+		t.Error("debit and credit entries have the same ID")
+	}
+	if !debit.CreatedAt.Equal(now) || !credit.CreatedAt.Equal(now) {
+		t.Error("entries are not stamped with the given time")
+	}
+}
+
+func TestNewLedgerEntries_SumToZero(t *testing.T) {
+	// Core double-entry principle: the entries of a valid posting sum to exactly zero
+	legs := []PostingLeg{
+		{AccountID: uuid.New(), Amount: mustMoney("-999999.99", "NOK")},
+		{AccountID: uuid.New(), Amount: mustMoney("999999.00", "NOK")},
+		{AccountID: uuid.New(), Amount: mustMoney("0.99", "NOK")},
+	}
+	if err := ValidateLegs(legs); err != nil {
+		t.Fatalf("ValidateLegs() error = %v", err)
+	}
+
+	sum := ZeroMoney("NOK")
+	for _, entry := range NewLedgerEntries(uuid.New(), legs, time.Now()) {
+		var err error
+		if sum, err = sum.Add(entry.Amount); err != nil {
+			t.Fatalf("failed to add amount %s: %v", entry.Amount, err)
+		}
+	}
+	if !sum.IsZero() {
+		t.Errorf("entries sum = %v, want 0 (double-entry principle violated)", sum)
+	}
+}
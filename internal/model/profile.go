@@ -0,0 +1,181 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // Timezone validation must not depend on the host's zoneinfo
+)
+
+// Maximum lengths, matching the customers table columns
+const (
+	maxNameLength       = 100
+	maxAddressLength    = 255
+	maxCityLength       = 100
+	maxPostalCodeLength = 20
+	maxTimezoneLength   = 50
+)
+
+var (
+	// e164Pattern matches an E.164 phone number: '+', country code, up to 15 digits in total
+	e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	// languagePattern matches a lowercase ISO 639-1 language code
+	languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+)
+
+// iso3166Alpha2 holds the officially assigned ISO 3166-1 alpha-2 country codes
+var iso3166Alpha2 = func() map[string]bool {
+	codes := `
+		AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ
+		BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM
+		DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS
+		GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN
+		KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ
+		MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM
+		PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV
+		SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI
+		VN VU WF WS YE YT ZA ZM ZW`
+
+	set := make(map[string]bool)
+	for _, code := range strings.Fields(codes) {
+		set[code] = true
+	}
+	return set
+}()
+
+// UpdateProfileRequest is the payload for PATCH /v1/me
+// Nil fields are left unchanged. For optional fields an empty string clears the value.
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name,omitempty"`
+	LastName  *string `json:"last_name,omitempty"`
+
+	Phone *string `json:"phone,omitempty"` // E.164, e.g. +4712345678
+
+	AddressLine1 *string `json:"address_line1,omitempty"`
+	AddressLine2 *string `json:"address_line2,omitempty"`
+	City         *string `json:"city,omitempty"`
+	PostalCode   *string `json:"postal_code,omitempty"`
+	Country      *string `json:"country,omitempty"` // ISO 3166-1 alpha-2, e.g. NO
+
+	DateOfBirth *string `json:"date_of_birth,omitempty"` // YYYY-MM-DD
+
+	PreferredLanguage *string `json:"preferred_language,omitempty"` // ISO 639-1, e.g. en
+	Timezone          *string `json:"timezone,omitempty"`           // IANA name, e.g. Europe/Oslo
+}
+
+// Normalize trims whitespace from every field and uppercases the country code
+func (r *UpdateProfileRequest) Normalize() {
+	for _, field := range []*string{
+		r.FirstName, r.LastName, r.Phone,
+		r.AddressLine1, r.AddressLine2, r.City, r.PostalCode, r.Country,
+		r.DateOfBirth, r.PreferredLanguage, r.Timezone,
+	} {
+		if field != nil {
+			*field = strings.TrimSpace(*field)
+		}
+	}
+	if r.Country != nil {
+		*r.Country = strings.ToUpper(*r.Country)
+	}
+	if r.PreferredLanguage != nil {
+		*r.PreferredLanguage = strings.ToLower(*r.PreferredLanguage)
+	}
+}
+
+// IsEmpty returns true if the request changes nothing
+func (r UpdateProfileRequest) IsEmpty() bool {
+	return r.FirstName == nil && r.LastName == nil && r.Phone == nil &&
+		r.AddressLine1 == nil && r.AddressLine2 == nil && r.City == nil &&
+		r.PostalCode == nil && r.Country == nil && r.DateOfBirth == nil &&
+		r.PreferredLanguage == nil && r.Timezone == nil
+}
+
+// Validate checks every field that is being changed
+func (r UpdateProfileRequest) Validate() error {
+	if r.FirstName != nil && *r.FirstName == "" {
+		return ErrFirstNameRequired
+	}
+	if r.LastName != nil && *r.LastName == "" {
+		return ErrLastNameRequired
+	}
+
+	lengths := []struct {
+		name  string
+		value *string
+		max   int
+	}{
+		{"first_name", r.FirstName, maxNameLength},
+		{"last_name", r.LastName, maxNameLength},
+		{"address_line1", r.AddressLine1, maxAddressLength},
+		{"address_line2", r.AddressLine2, maxAddressLength},
+		{"city", r.City, maxCityLength},
+		{"postal_code", r.PostalCode, maxPostalCodeLength},
+	}
+	for _, f := range lengths {
+		if f.value != nil && len(*f.value) > f.max {
+			return fmt.Errorf("%w: %s must be at most %d characters", ErrFieldTooLong, f.name, f.max)
+		}
+	}
+
+	if r.Phone != nil && *r.Phone != "" && !e164Pattern.MatchString(*r.Phone) {
+		return ErrInvalidPhone
+	}
+	if r.Country != nil && *r.Country != "" && !iso3166Alpha2[*r.Country] {
+		return ErrInvalidCountry
+	}
+	if r.DateOfBirth != nil && *r.DateOfBirth != "" {
+		if _, err := ParseDateOfBirth(*r.DateOfBirth, time.Now()); err != nil {
+			return err
+		}
+	}
+	if r.PreferredLanguage != nil && !languagePattern.MatchString(*r.PreferredLanguage) {
+		return ErrInvalidLanguage
+	}
+	if r.Timezone != nil && !IsValidTimezone(*r.Timezone) {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// ParseDateOfBirth parses a YYYY-MM-DD date that must be in the past and no earlier than 1900
+func ParseDateOfBirth(value string, now time.Time) (time.Time, error) {
+	dob, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidDateOfBirth
+	}
+	if dob.Year() < 1900 || !dob.Before(now) {
+		return time.Time{}, ErrInvalidDateOfBirth
+	}
+	return dob, nil
+}
+
+// IsValidTimezone reports whether name is an IANA time zone such as "Europe/Oslo" or "UTC"
+func IsValidTimezone(name string) bool {
+	// LoadLocation also accepts "" and "Local", which are not zone names
+	if name == "" || name == "Local" || len(name) > maxTimezoneLength {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// ChangePasswordRequest is the payload for POST /v1/me/password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// Validate checks the current password is supplied and the new one meets the password rules
+func (r ChangePasswordRequest) Validate() error {
+	if r.CurrentPassword == "" {
+		return ErrPasswordRequired
+	}
+	if err := ValidatePassword(r.NewPassword); err != nil {
+		return err
+	}
+	if r.NewPassword == r.CurrentPassword {
+		return ErrPasswordUnchanged
+	}
+	return nil
+}
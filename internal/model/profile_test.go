@@ -0,0 +1,128 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestUpdateProfileRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request UpdateProfileRequest
+		wantErr error
+	}{
+		{"empty", UpdateProfileRequest{}, nil},
+		{"valid full", UpdateProfileRequest{
+			FirstName:         strPtr("Kari"),
+			LastName:          strPtr("Nordmann"),
+			Phone:             strPtr("+4791234567"),
+			AddressLine1:      strPtr("Karl Johans gate 1"),
+			City:              strPtr("Oslo"),
+			PostalCode:        strPtr("0154"),
+			Country:           strPtr("NO"),
+			DateOfBirth:       strPtr("1990-05-17"),
+			PreferredLanguage: strPtr("nb"),
+			Timezone:          strPtr("Europe/Oslo"),
+		}, nil},
+		{"clear optional fields", UpdateProfileRequest{Phone: strPtr(""), Country: strPtr(""), DateOfBirth: strPtr("")}, nil},
+		{"empty first name", UpdateProfileRequest{FirstName: strPtr("")}, ErrFirstNameRequired},
+		{"empty last name", UpdateProfileRequest{LastName: strPtr("")}, ErrLastNameRequired},
+		{"long city", UpdateProfileRequest{City: strPtr(strings.Repeat("a", 101))}, ErrFieldTooLong},
+		{"phone without plus", UpdateProfileRequest{Phone: strPtr("4791234567")}, ErrInvalidPhone},
+		{"phone with spaces", UpdateProfileRequest{Phone: strPtr("+47 912 34 567")}, ErrInvalidPhone},
+		{"phone too long", UpdateProfileRequest{Phone: strPtr("+1234567890123456")}, ErrInvalidPhone},
+		{"phone leading zero", UpdateProfileRequest{Phone: strPtr("+0123456")}, ErrInvalidPhone},
+		{"unknown country", UpdateProfileRequest{Country: strPtr("XX")}, ErrInvalidCountry},
+		{"alpha-3 country", UpdateProfileRequest{Country: strPtr("NOR")}, ErrInvalidCountry},
+		{"bad date", UpdateProfileRequest{DateOfBirth: strPtr("17.05.1990")}, ErrInvalidDateOfBirth},
+		{"future date", UpdateProfileRequest{DateOfBirth: strPtr("2999-01-01")}, ErrInvalidDateOfBirth},
+		{"too old", UpdateProfileRequest{DateOfBirth: strPtr("1850-01-01")}, ErrInvalidDateOfBirth},
+		{"bad language", UpdateProfileRequest{PreferredLanguage: strPtr("eng")}, ErrInvalidLanguage},
+		{"empty language", UpdateProfileRequest{PreferredLanguage: strPtr("")}, ErrInvalidLanguage},
+		{"unknown timezone", UpdateProfileRequest{Timezone: strPtr("Europe/Atlantis")}, ErrInvalidTimezone},
+		{"local timezone", UpdateProfileRequest{Timezone: strPtr("Local")}, ErrInvalidTimezone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.request.Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateProfileRequest_Normalize(t *testing.T) {
+	req := UpdateProfileRequest{
+		FirstName:         strPtr("  Kari "),
+		Country:           strPtr(" no"),
+		PreferredLanguage: strPtr("EN"),
+	}
+	req.Normalize()
+
+	if *req.FirstName != "Kari" {
+		t.Errorf("FirstName = %q, want %q", *req.FirstName, "Kari")
+	}
+	if *req.Country != "NO" {
+		t.Errorf("Country = %q, want %q", *req.Country, "NO")
+	}
+	if *req.PreferredLanguage != "en" {
+		t.Errorf("PreferredLanguage = %q, want %q", *req.PreferredLanguage, "en")
+	}
+	if req.Phone != nil {
+		t.Error("Normalize set a field that was not in the request")
+	}
+	if req.IsEmpty() {
+		t.Error("IsEmpty() = true for a request with fields")
+	}
+}
+
+func TestISO3166Alpha2(t *testing.T) {
+	if len(iso3166Alpha2) != 249 {
+		t.Errorf("len(iso3166Alpha2) = %d, want 249", len(iso3166Alpha2))
+	}
+	for _, code := range []string{"NO", "SE", "DK", "US", "GB", "AX", "SJ"} {
+		if !iso3166Alpha2[code] {
+			t.Errorf("%s missing", code)
+		}
+	}
+}
+
+func TestParseDateOfBirth(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := ParseDateOfBirth("2024-06-01", now); err != nil {
+		t.Errorf("today: error = %v, want nil", err)
+	}
+	if _, err := ParseDateOfBirth("2024-06-02", now); err != ErrInvalidDateOfBirth {
+		t.Errorf("tomorrow: error = %v, want ErrInvalidDateOfBirth", err)
+	}
+	if _, err := ParseDateOfBirth("2023-02-30", now); err != ErrInvalidDateOfBirth {
+		t.Errorf("invalid day: error = %v, want ErrInvalidDateOfBirth", err)
+	}
+}
+
+func TestChangePasswordRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request ChangePasswordRequest
+		wantErr error
+	}{
+		{"valid", ChangePasswordRequest{CurrentPassword: "OldSecret1", NewPassword: "NewSecret2"}, nil},
+		{"missing current", ChangePasswordRequest{NewPassword: "NewSecret2"}, ErrPasswordRequired},
+		{"weak new", ChangePasswordRequest{CurrentPassword: "OldSecret1", NewPassword: "password"}, ErrPasswordTooWeak},
+		{"unchanged", ChangePasswordRequest{CurrentPassword: "Secret123", NewPassword: "Secret123"}, ErrPasswordUnchanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.request.Validate(); err != tt.wantErr {
+				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is the server-side record of an issued refresh token
+// Only the SHA-256 hash of the token is stored. Tokens from one login share a FamilyID,
+// which is also the ID of the session they belong to.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id"`
+	CustomerID uuid.UUID  `json:"customer_id"`
+	FamilyID   uuid.UUID  `json:"family_id"`
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`     // Set when rotated; a used token must never be presented again
+	ReplacedBy *uuid.UUID `json:"replaced_by,omitempty"` // The token issued in exchange for this one
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsUsed returns true if the token has already been exchanged for a new one
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+// IsRevoked returns true if the token was revoked by logout or reuse detection
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired returns true if the token is past its expiry at the given time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
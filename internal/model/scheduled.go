@@ -0,0 +1,233 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleFrequency is how often a scheduled transfer repeats
+type ScheduleFrequency string
+
+const (
+	ScheduleFrequencyOnce    ScheduleFrequency = "once"
+	ScheduleFrequencyWeekly  ScheduleFrequency = "weekly"
+	ScheduleFrequencyMonthly ScheduleFrequency = "monthly"
+)
+
+// ScheduledTransferStatus represents the lifecycle of a standing order
+type ScheduledTransferStatus string
+
+const (
+	ScheduledTransferStatusActive    ScheduledTransferStatus = "active"
+	ScheduledTransferStatusCompleted ScheduledTransferStatus = "completed" // Every occurrence has been materialized
+	ScheduledTransferStatusCancelled ScheduledTransferStatus = "cancelled"
+)
+
+// ScheduledTransfer is a standing order: a transfer on a future date, optionally repeated
+// Dates are local dates in Timezone, the customer's time zone when the order was created,
+// held as midnight UTC like Customer.DateOfBirth
+type ScheduledTransfer struct {
+	ID            uuid.UUID               `json:"id"`
+	CustomerID    uuid.UUID               `json:"customer_id"`
+	FromAccountID uuid.UUID               `json:"from_account_id"`
+	ToAccountID   uuid.UUID               `json:"to_account_id"`
+	Amount        Money                   `json:"amount"`
+	Currency      string                  `json:"currency"`
+	Reference     string                  `json:"reference,omitempty"`
+	Frequency     ScheduleFrequency       `json:"frequency"`
+	StartDate     time.Time               `json:"start_date"`
+	EndDate       *time.Time              `json:"end_date,omitempty"`
+	Timezone      string                  `json:"timezone"`
+	Status        ScheduledTransferStatus `json:"status"`
+	Occurrences   int                     `json:"occurrences"`             // Occurrences materialized so far
+	NextRunDate   *time.Time              `json:"next_run_date,omitempty"` // Date of the next occurrence; nil once no longer active
+	NextRunAt     *time.Time              `json:"next_run_at,omitempty"`   // When the next occurrence becomes due
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+	CancelledAt   *time.Time              `json:"cancelled_at,omitempty"`
+}
+
+// OccurrenceDate returns the date of the n-th occurrence (counting from 0) of a schedule starting on start
+// Monthly schedules keep the start date's day of the month, moved to the last day in shorter months,
+// so a schedule starting on 31 January runs on 28 or 29 February and 31 March
+func OccurrenceDate(start time.Time, frequency ScheduleFrequency, n int) time.Time {
+	switch frequency {
+	case ScheduleFrequencyWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case ScheduleFrequencyMonthly:
+		year, month, day := start.Date()
+		first := time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+	default:
+		return start
+	}
+}
+
+// LocalDate returns the calendar date at now in a time zone, as midnight UTC
+// An unknown time zone falls back to UTC
+func LocalDate(now time.Time, timezone string) time.Time {
+	year, month, day := now.In(loadLocation(timezone)).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Occurrence returns the date of the n-th occurrence, or false if the schedule ends before it
+func (s *ScheduledTransfer) Occurrence(n int) (time.Time, bool) {
+	if s.Frequency == ScheduleFrequencyOnce && n > 0 {
+		return time.Time{}, false
+	}
+	date := OccurrenceDate(s.StartDate, s.Frequency, n)
+	if s.EndDate != nil && date.After(*s.EndDate) {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// RunAt returns when an occurrence date becomes due: midnight at the start of that day in the schedule's time zone
+func (s *ScheduledTransfer) RunAt(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, loadLocation(s.Timezone))
+}
+
+// Advance points the schedule at its n-th occurrence, or marks it completed if there is none
+func (s *ScheduledTransfer) Advance(n int) {
+	s.Occurrences = n
+	date, ok := s.Occurrence(n)
+	if !ok {
+		s.Status = ScheduledTransferStatusCompleted
+		s.NextRunDate = nil
+		s.NextRunAt = nil
+		return
+	}
+
+	runAt := s.RunAt(date)
+	s.NextRunDate = &date
+	s.NextRunAt = &runAt
+}
+
+// Activate checks the start date against today in the schedule's time zone and points it at its first occurrence
+// Starting today is allowed; the first occurrence is then due straight away
+func (s *ScheduledTransfer) Activate(now time.Time) error {
+	if s.StartDate.Before(LocalDate(now, s.Timezone)) {
+		return ErrScheduleStartInPast
+	}
+	s.Status = ScheduledTransferStatusActive
+	s.Advance(0)
+	return nil
+}
+
+// IdempotencyKey returns the key of the transfer made for an occurrence
+// It is derived from the schedule and the date, so an occurrence can only ever create one transfer
+func (s *ScheduledTransfer) IdempotencyKey(date time.Time) string {
+	return "scheduled:" + s.ID.String() + ":" + date.Format(time.DateOnly)
+}
+
+// loadLocation returns the named time zone, or UTC if it is unknown
+func loadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ScheduledOccurrence is one materialized occurrence of a scheduled transfer
+// Status and ErrorMessage are those of the transfer it created
+type ScheduledOccurrence struct {
+	ID                  uuid.UUID         `json:"id"`
+	ScheduledTransferID uuid.UUID         `json:"scheduled_transfer_id"`
+	OccurrenceDate      time.Time         `json:"occurrence_date"`
+	TransactionID       uuid.UUID         `json:"transaction_id"`
+	Status              TransactionStatus `json:"status"`
+	ErrorMessage        string            `json:"error_message,omitempty"`
+	CreatedAt           time.Time         `json:"created_at"`
+}
+
+// FailedScheduledOccurrence is one line of the failed occurrence report
+type FailedScheduledOccurrence struct {
+	ScheduledOccurrence
+	CustomerID    uuid.UUID `json:"customer_id"`
+	FromAccountID uuid.UUID `json:"from_account_id"`
+	ToAccountID   uuid.UUID `json:"to_account_id"`
+	Amount        Money     `json:"amount"`
+	Currency      string    `json:"currency"`
+	Reference     string    `json:"reference,omitempty"`
+}
+
+// CreateScheduledTransferRequest is the payload for creating a standing order
+// The transfer fields are those of CreateTransferRequest; dates are YYYY-MM-DD in the customer's time zone
+type CreateScheduledTransferRequest struct {
+	CreateTransferRequest
+	Frequency ScheduleFrequency `json:"frequency"`
+	StartDate string            `json:"start_date"`
+	EndDate   string            `json:"end_date,omitempty"` // Optional for weekly and monthly; not allowed for once
+}
+
+// Validate checks the transfer fields and the schedule
+// Whether start_date is in the past depends on the customer's time zone and is checked by Activate
+func (r CreateScheduledTransferRequest) Validate() error {
+	if err := r.CreateTransferRequest.Validate(); err != nil {
+		return err
+	}
+	_, _, err := r.Dates()
+	return err
+}
+
+// Dates parses the start and optional end date
+func (r CreateScheduledTransferRequest) Dates() (time.Time, *time.Time, error) {
+	switch r.Frequency {
+	case ScheduleFrequencyOnce, ScheduleFrequencyWeekly, ScheduleFrequencyMonthly:
+	default:
+		return time.Time{}, nil, ErrInvalidFrequency
+	}
+
+	start, err := time.Parse(time.DateOnly, r.StartDate)
+	if err != nil {
+		return time.Time{}, nil, ErrInvalidScheduleDate
+	}
+	if r.EndDate == "" {
+		return start, nil, nil
+	}
+
+	if r.Frequency == ScheduleFrequencyOnce {
+		return time.Time{}, nil, ErrScheduleEndDateOnce
+	}
+	end, err := time.Parse(time.DateOnly, r.EndDate)
+	if err != nil {
+		return time.Time{}, nil, ErrInvalidScheduleDate
+	}
+	if end.Before(start) {
+		return time.Time{}, nil, ErrScheduleEndBeforeStart
+	}
+	return start, &end, nil
+}
+
+// Transfer builds the pending transfer for an occurrence, ready for the transaction repository
+func (s *ScheduledTransfer) Transfer(date, now time.Time) (Transaction, []TransactionParty) {
+	txID := uuid.New()
+	tx := Transaction{
+		ID:             txID,
+		IdempotencyKey: s.IdempotencyKey(date),
+		Type:           TransactionTypeTransfer,
+		Status:         TransactionStatusPending,
+		Reference:      s.Reference,
+		InitiatedAt:    now,
+		Metadata: map[string]any{
+			"scheduled_transfer_id": s.ID.String(),
+			"occurrence_date":       date.Format(time.DateOnly),
+		},
+		Amount:        s.Amount.String(),
+		Currency:      s.Currency,
+		FromAccountID: &s.FromAccountID,
+		ToAccountID:   &s.ToAccountID,
+	}
+
+	parties := []TransactionParty{
+		{ID: uuid.New(), TransactionID: txID, AccountID: s.FromAccountID, Role: "source"},
+		{ID: uuid.New(), TransactionID: txID, AccountID: s.ToAccountID, Role: "destination"},
+	}
+	return tx, parties
+}
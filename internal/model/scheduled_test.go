@@ -0,0 +1,179 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func mustDate(s string) time.Time {
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestOccurrenceDate(t *testing.T) {
+	tests := []struct {
+		start     string
+		frequency ScheduleFrequency
+		n         int
+		want      string
+	}{
+		{"2026-11-02", ScheduleFrequencyOnce, 0, "2026-11-02"},
+		{"2026-11-02", ScheduleFrequencyWeekly, 0, "2026-11-02"},
+		{"2026-11-02", ScheduleFrequencyWeekly, 5, "2026-12-07"},
+		{"2026-11-15", ScheduleFrequencyMonthly, 2, "2027-01-15"},
+		{"2027-01-31", ScheduleFrequencyMonthly, 1, "2027-02-28"},
+		{"2027-01-31", ScheduleFrequencyMonthly, 2, "2027-03-31"},
+		{"2027-01-31", ScheduleFrequencyMonthly, 3, "2027-04-30"},
+		{"2028-01-30", ScheduleFrequencyMonthly, 1, "2028-02-29"},
+	}
+
+	for _, tt := range tests {
+		got := OccurrenceDate(mustDate(tt.start), tt.frequency, tt.n)
+		if got.Format(time.DateOnly) != tt.want {
+			t.Errorf("OccurrenceDate(%s, %s, %d) = %s, want %s", tt.start, tt.frequency, tt.n, got.Format(time.DateOnly), tt.want)
+		}
+	}
+}
+
+func TestScheduledTransfer_Occurrence(t *testing.T) {
+	end := mustDate("2026-12-01")
+	weekly := &ScheduledTransfer{Frequency: ScheduleFrequencyWeekly, StartDate: mustDate("2026-11-10"), EndDate: &end}
+
+	if got, ok := weekly.Occurrence(2); !ok || got != mustDate("2026-11-24") {
+		t.Errorf("Occurrence(2) = (%s, %v), want (2026-11-24, true)", got.Format(time.DateOnly), ok)
+	}
+	if got, ok := weekly.Occurrence(3); !ok || got != end {
+		t.Errorf("Occurrence(3) = (%s, %v), want the end date itself", got.Format(time.DateOnly), ok)
+	}
+	if _, ok := weekly.Occurrence(4); ok {
+		t.Error("Occurrence(4) is after the end date but was returned")
+	}
+
+	once := &ScheduledTransfer{Frequency: ScheduleFrequencyOnce, StartDate: mustDate("2026-11-10")}
+	if _, ok := once.Occurrence(0); !ok {
+		t.Error("one-off Occurrence(0) was not returned")
+	}
+	if _, ok := once.Occurrence(1); ok {
+		t.Error("one-off Occurrence(1) was returned")
+	}
+}
+
+func TestScheduledTransfer_RunAt(t *testing.T) {
+	s := &ScheduledTransfer{Timezone: "Europe/Oslo"}
+
+	// Midnight in Oslo is 23:00 UTC the day before in winter and 22:00 in summer
+	if got, want := s.RunAt(mustDate("2026-12-01")).UTC(), time.Date(2026, 11, 30, 23, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("RunAt(winter) = %s, want %s", got, want)
+	}
+	if got, want := s.RunAt(mustDate("2026-07-01")).UTC(), time.Date(2026, 6, 30, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("RunAt(summer) = %s, want %s", got, want)
+	}
+}
+
+func TestScheduledTransfer_Advance(t *testing.T) {
+	end := mustDate("2026-12-10")
+	s := &ScheduledTransfer{
+		Frequency: ScheduleFrequencyMonthly,
+		StartDate: mustDate("2026-11-10"),
+		EndDate:   &end,
+		Timezone:  "UTC",
+		Status:    ScheduledTransferStatusActive,
+	}
+
+	s.Advance(1)
+	if s.Status != ScheduledTransferStatusActive || s.NextRunDate == nil || *s.NextRunDate != end {
+		t.Fatalf("Advance(1) = %s next %v, want active next 2026-12-10", s.Status, s.NextRunDate)
+	}
+	if s.NextRunAt == nil || !s.NextRunAt.Equal(end) {
+		t.Errorf("NextRunAt = %v, want %s", s.NextRunAt, end)
+	}
+
+	s.Advance(2)
+	if s.Status != ScheduledTransferStatusCompleted || s.NextRunDate != nil || s.NextRunAt != nil {
+		t.Errorf("Advance(2) = %s next %v, want completed with no next run", s.Status, s.NextRunDate)
+	}
+	if s.Occurrences != 2 {
+		t.Errorf("Occurrences = %d, want 2", s.Occurrences)
+	}
+}
+
+func TestScheduledTransfer_Activate(t *testing.T) {
+	// 23:30 UTC on 15 November is already 16 November in Oslo
+	now := time.Date(2026, 11, 15, 23, 30, 0, 0, time.UTC)
+
+	oslo := &ScheduledTransfer{Frequency: ScheduleFrequencyOnce, StartDate: mustDate("2026-11-15"), Timezone: "Europe/Oslo"}
+	if err := oslo.Activate(now); err != ErrScheduleStartInPast {
+		t.Errorf("Activate(yesterday in Oslo) error = %v, want %v", err, ErrScheduleStartInPast)
+	}
+
+	utc := &ScheduledTransfer{Frequency: ScheduleFrequencyOnce, StartDate: mustDate("2026-11-15"), Timezone: "UTC"}
+	if err := utc.Activate(now); err != nil {
+		t.Fatalf("Activate(today in UTC) error = %v", err)
+	}
+	if utc.Status != ScheduledTransferStatusActive || utc.NextRunAt == nil || utc.NextRunAt.After(now) {
+		t.Errorf("Activate(today) = %s next %v, want active and due now", utc.Status, utc.NextRunAt)
+	}
+}
+
+func TestScheduledTransfer_IdempotencyKey(t *testing.T) {
+	id := uuid.MustParse("6f1c2f7e-2d8a-4d4e-9a51-0f3c1b2a9e10")
+	s := &ScheduledTransfer{ID: id}
+
+	want := "scheduled:6f1c2f7e-2d8a-4d4e-9a51-0f3c1b2a9e10:2026-12-01"
+	if got := s.IdempotencyKey(mustDate("2026-12-01")); got != want {
+		t.Errorf("IdempotencyKey() = %q, want %q", got, want)
+	}
+}
+
+func TestCreateScheduledTransferRequest_Validate(t *testing.T) {
+	transfer := CreateTransferRequest{
+		FromAccountID: uuid.New(),
+		ToAccountID:   uuid.New(),
+		Amount:        "12500.00",
+		Currency:      "NOK",
+	}
+
+	tests := []struct {
+		name      string
+		frequency ScheduleFrequency
+		start     string
+		end       string
+		wantErr   error
+	}{
+		{"one-off", ScheduleFrequencyOnce, "2026-12-01", "", nil},
+		{"monthly without end", ScheduleFrequencyMonthly, "2026-12-01", "", nil},
+		{"weekly with end", ScheduleFrequencyWeekly, "2026-12-01", "2027-06-01", nil},
+		{"end on start", ScheduleFrequencyWeekly, "2026-12-01", "2026-12-01", nil},
+		{"unknown frequency", "daily", "2026-12-01", "", ErrInvalidFrequency},
+		{"missing start", ScheduleFrequencyOnce, "", "", ErrInvalidScheduleDate},
+		{"bad start", ScheduleFrequencyOnce, "01.12.2026", "", ErrInvalidScheduleDate},
+		{"bad end", ScheduleFrequencyMonthly, "2026-12-01", "2027-13-01", ErrInvalidScheduleDate},
+		{"end before start", ScheduleFrequencyMonthly, "2026-12-01", "2026-11-30", ErrScheduleEndBeforeStart},
+		{"one-off with end", ScheduleFrequencyOnce, "2026-12-01", "2026-12-31", ErrScheduleEndDateOnce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CreateScheduledTransferRequest{
+				CreateTransferRequest: transfer,
+				Frequency:             tt.frequency,
+				StartDate:             tt.start,
+				EndDate:               tt.end,
+			}
+			if err := req.Validate(); err != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	invalid := CreateScheduledTransferRequest{CreateTransferRequest: transfer, Frequency: ScheduleFrequencyOnce, StartDate: "2026-12-01"}
+	invalid.Amount = ""
+	if err := invalid.Validate(); err != ErrInvalidAmount {
+		t.Errorf("Validate() with no amount error = %v, want %v", err, ErrInvalidAmount)
+	}
+}
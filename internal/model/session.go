@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one login on one device
+// Its ID is the family ID of the refresh tokens issued from that login
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	CustomerID uuid.UUID  `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current"` // True for the session making the request
+}
+
+// ClientInfo describes the device a request came from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// MaxUserAgentLength matches the sessions.user_agent column
+const MaxUserAgentLength = 500
+
+// Truncated returns the client info cut to fit the sessions table
+func (c ClientInfo) Truncated() ClientInfo {
+	if len(c.UserAgent) > MaxUserAgentLength {
+		c.UserAgent = c.UserAgent[:MaxUserAgentLength]
+	}
+	return c
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ const (
 	TransactionTypeTransfer   TransactionType = "transfer"
 	TransactionTypeDeposit    TransactionType = "deposit"
 	TransactionTypeWithdrawal TransactionType = "withdrawal"
+	TransactionTypeReversal   TransactionType = "reversal" // Mirrors the ledger entries of a completed transaction
 )
 
 // TransactionStatus represents the current status of a transaction
@@ -23,6 +25,8 @@ const (
 	TransactionStatusProcessing TransactionStatus = "processing"
 	TransactionStatusCompleted  TransactionStatus = "completed"
 	TransactionStatusFailed     TransactionStatus = "failed"
+	TransactionStatusCancelled  TransactionStatus = "cancelled" // Withdrawn by the customer while still pending
+	TransactionStatusReversed   TransactionStatus = "reversed"  // Completed, then undone by a reversal
 )
 
 // Transaction represents a financial transaction
@@ -42,6 +46,9 @@ type Transaction struct {
 	Currency      string     `json:"currency,omitempty"`
 	FromAccountID *uuid.UUID `json:"from_account_id,omitempty"`
 	ToAccountID   *uuid.UUID `json:"to_account_id,omitempty"`
+	FeeAmount     string     `json:"fee_amount,omitempty"`  // Charged to the source on top of Amount and paid to the bank's revenue account
+	ReversalOf    *uuid.UUID `json:"reversal_of,omitempty"` // Set on a reversal: the transaction it undoes
+	ReversedBy    *uuid.UUID `json:"reversed_by,omitempty"` // Set on a reversed transaction: its reversal
 }
 
 // TransactionParty represents a participant in a transaction
@@ -49,7 +56,7 @@ type TransactionParty struct {
 	ID            uuid.UUID `json:"id"`
 	TransactionID uuid.UUID `json:"transaction_id"`
 	AccountID     uuid.UUID `json:"account_id"`
-	Role          string    `json:"role"` // "source" or "destination"
+	Role          string    `json:"role"` // "source", "destination" or "fee", matching the role of its posting leg
 }
 
 // LedgerEntryType represents the type of ledger entry
@@ -65,28 +72,36 @@ type LedgerEntry struct {
 	ID            uuid.UUID       `json:"id"`
 	TransactionID uuid.UUID       `json:"transaction_id"`
 	AccountID     uuid.UUID       `json:"account_id"`
-	Amount        string          `json:"amount"` // Positive = credit, negative = debit
+	Amount        Money           `json:"amount"` // Positive = credit, negative = debit
 	EntryType     LedgerEntryType `json:"entry_type"`
 	CreatedAt     time.Time       `json:"created_at"`
 }
 
 // CreateTransferRequest is the payload for creating a new transfer
+// The destination is given either as to_account_id or as to_account_number (account number or IBAN)
 type CreateTransferRequest struct {
-	FromAccountID uuid.UUID `json:"from_account_id"`
-	ToAccountID   uuid.UUID `json:"to_account_id"`
-	Amount        string    `json:"amount"`
-	Currency      string    `json:"currency"`
-	Reference     string    `json:"reference,omitempty"`
+	FromAccountID   uuid.UUID `json:"from_account_id"`
+	ToAccountID     uuid.UUID `json:"to_account_id"`
+	ToAccountNumber string    `json:"to_account_number,omitempty"`
+	Amount          string    `json:"amount"`
+	Currency        string    `json:"currency"`
+	Reference       string    `json:"reference,omitempty"`
 }
 
 // Validate checks if the transfer request is valid
+// A destination given by account number is resolved later, so the same-account check
+// only covers to_account_id here
 func (r CreateTransferRequest) Validate() error {
 	if r.FromAccountID == uuid.Nil {
 		return ErrInvalidFromAccount
 	}
-	if r.ToAccountID == uuid.Nil {
+	hasNumber := strings.TrimSpace(r.ToAccountNumber) != ""
+	if r.ToAccountID == uuid.Nil && !hasNumber {
 		return ErrInvalidToAccount
 	}
+	if r.ToAccountID != uuid.Nil && hasNumber {
+		return ErrAmbiguousDestination
+	}
 	if r.FromAccountID == r.ToAccountID {
 		return ErrSameAccount
 	}
@@ -96,14 +111,68 @@ func (r CreateTransferRequest) Validate() error {
 	if len(r.Currency) != 3 {
 		return ErrInvalidCurrency
 	}
+	if _, err := ParseMoney(r.Amount, r.Currency); err != nil {
+		return err
+	}
 	return nil
 }
 
+// CreateFundingRequest is the payload for depositing to or withdrawing from an account
+// The counterparty is always the bank equity account in the same currency
+type CreateFundingRequest struct {
+	Amount    string `json:"amount"`
+	Currency  string `json:"currency"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// Validate checks if the deposit/withdrawal request is valid
+func (r CreateFundingRequest) Validate() error {
+	if r.Amount == "" {
+		return ErrInvalidAmount
+	}
+	if len(r.Currency) != 3 {
+		return ErrInvalidCurrency
+	}
+	if _, err := ParseMoney(r.Amount, r.Currency); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReverseTransactionRequest is the admin payload for reversing a completed transaction
+type ReverseTransactionRequest struct {
+	Reason string `json:"reason"`
+	Force  bool   `json:"force"` // Book the reversal even if it takes an account beyond its available balance
+}
+
+// Validate checks a reason is given; it is stored on the reversal
+func (r ReverseTransactionRequest) Validate() error {
+	reason := strings.TrimSpace(r.Reason)
+	if reason == "" {
+		return ErrStatusReasonRequired
+	}
+	if len(reason) > MaxStatusReasonLength {
+		return ErrStatusReasonTooLong
+	}
+	return nil
+}
+
+// IsReversible reports whether an operator may reverse the transaction
+// Only completed transactions can be reversed, and a reversal itself cannot
+func (t *Transaction) IsReversible() bool {
+	return t.Status == TransactionStatusCompleted && t.Type != TransactionTypeReversal
+}
+
 // TransferResponse is the response after creating a transfer
+// The destination's account number and masked holder name are included when the request named them
 type TransferResponse struct {
-	TransactionID uuid.UUID         `json:"transaction_id"`
-	Status        TransactionStatus `json:"status"`
-	CreatedAt     time.Time         `json:"created_at"`
+	TransactionID   uuid.UUID         `json:"transaction_id"`
+	Status          TransactionStatus `json:"status"`
+	CreatedAt       time.Time         `json:"created_at"`
+	ToAccountNumber string            `json:"to_account_number,omitempty"`
+	ToHolderName    string            `json:"to_holder_name,omitempty"`
+	ErrorMessage    string            `json:"error_message,omitempty"` // Why a synchronously processed transfer failed
+	Fee             string            `json:"fee,omitempty"`           // Charged on top of the amount
 }
 
 // TransactionDetail provides full details of a transaction including parties
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -55,6 +56,37 @@ func TestCreateTransferRequest_Validate(t *testing.T) {
 			},
 			wantErr: ErrSameAccount,
 		},
+		{
+			name: "destination by account number",
+			request: CreateTransferRequest{
+				FromAccountID:   validFromID,
+				ToAccountNumber: "9710.00.00001",
+				Amount:          "100.00",
+				Currency:        "NOK",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "destination by both id and account number",
+			request: CreateTransferRequest{
+				FromAccountID:   validFromID,
+				ToAccountID:     validToID,
+				ToAccountNumber: "97100000001",
+				Amount:          "100.00",
+				Currency:        "NOK",
+			},
+			wantErr: ErrAmbiguousDestination,
+		},
+		{
+			name: "blank account number",
+			request: CreateTransferRequest{
+				FromAccountID:   validFromID,
+				ToAccountNumber: "   ",
+				Amount:          "100.00",
+				Currency:        "NOK",
+			},
+			wantErr: ErrInvalidToAccount,
+		},
 		{
 			name: "empty amount",
 			request: CreateTransferRequest{
@@ -85,6 +117,26 @@ func TestCreateTransferRequest_Validate(t *testing.T) {
 			},
 			wantErr: ErrInvalidCurrency,
 		},
+		{
+			name: "amount more precise than currency",
+			request: CreateTransferRequest{
+				FromAccountID: validFromID,
+				ToAccountID:   validToID,
+				Amount:        "100.001",
+				Currency:      "NOK",
+			},
+			wantErr: ErrAmountPrecision,
+		},
+		{
+			name: "unsupported currency",
+			request: CreateTransferRequest{
+				FromAccountID: validFromID,
+				ToAccountID:   validToID,
+				Amount:        "100.00",
+				Currency:      "XYZ",
+			},
+			wantErr: ErrUnsupportedCurrency,
+		},
 		{
 			name: "valid request with reference",
 			request: CreateTransferRequest{
@@ -107,3 +159,93 @@ func TestCreateTransferRequest_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateFundingRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request CreateFundingRequest
+		wantErr error
+	}{
+		{
+			name:    "valid request",
+			request: CreateFundingRequest{Amount: "500.00", Currency: "NOK"},
+			wantErr: nil,
+		},
+		{
+			name:    "valid request with reference",
+			request: CreateFundingRequest{Amount: "500", Currency: "EUR", Reference: "Initial funding"},
+			wantErr: nil,
+		},
+		{
+			name:    "empty amount",
+			request: CreateFundingRequest{Amount: "", Currency: "NOK"},
+			wantErr: ErrInvalidAmount,
+		},
+		{
+			name:    "invalid currency",
+			request: CreateFundingRequest{Amount: "500.00", Currency: "NO"},
+			wantErr: ErrInvalidCurrency,
+		},
+		{
+			name:    "amount more precise than currency",
+			request: CreateFundingRequest{Amount: "5.5", Currency: "JPY"},
+			wantErr: ErrAmountPrecision,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if err != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReverseTransactionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		reason  string
+		wantErr error
+	}{
+		{"with reason", "Duplicate payment", nil},
+		{"without reason", "", ErrStatusReasonRequired},
+		{"blank reason", "  ", ErrStatusReasonRequired},
+		{"reason too long", strings.Repeat("x", MaxStatusReasonLength+1), ErrStatusReasonTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ReverseTransactionRequest{Reason: tt.reason}.Validate()
+			if err != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransaction_IsReversible(t *testing.T) {
+	tests := []struct {
+		name   string
+		txType TransactionType
+		status TransactionStatus
+		want   bool
+	}{
+		{"completed transfer", TransactionTypeTransfer, TransactionStatusCompleted, true},
+		{"completed deposit", TransactionTypeDeposit, TransactionStatusCompleted, true},
+		{"pending transfer", TransactionTypeTransfer, TransactionStatusPending, false},
+		{"failed transfer", TransactionTypeTransfer, TransactionStatusFailed, false},
+		{"already reversed", TransactionTypeTransfer, TransactionStatusReversed, false},
+		{"reversal", TransactionTypeReversal, TransactionStatusCompleted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &Transaction{Type: tt.txType, Status: tt.status}
+			if got := tx.IsReversible(); got != tt.want {
+				t.Errorf("IsReversible() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
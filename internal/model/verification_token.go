@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenPurpose is what a verification token may be used for
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+)
+
+// VerificationToken is the server-side record of a token sent by email
+// Only the SHA-256 hash of the token is stored. A token is single-use and
+// issuing a new one for the same purpose invalidates older ones.
+type VerificationToken struct {
+	ID         uuid.UUID    `json:"id"`
+	CustomerID uuid.UUID    `json:"customer_id"`
+	Purpose    TokenPurpose `json:"purpose"`
+	Email      string       `json:"email"` // Address the token was sent to
+	TokenHash  string       `json:"-"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UsedAt     *time.Time   `json:"used_at,omitempty"`
+}
+
+// EmailRequest is the payload for endpoints that send an email to an address
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
+// Validate checks that the email address is plausible
+func (r EmailRequest) Validate() error {
+	if r.Email == "" || !isValidEmail(r.Email) {
+		return ErrInvalidEmail
+	}
+	return nil
+}
+
+// VerifyEmailRequest is the payload for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// Validate checks that a token was supplied
+func (r VerifyEmailRequest) Validate() error {
+	if r.Token == "" {
+		return ErrVerificationTokenInvalid
+	}
+	return nil
+}
+
+// ResetPasswordRequest is the payload for setting a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// Validate checks the token is present and the new password meets the password rules
+func (r ResetPasswordRequest) Validate() error {
+	if r.Token == "" {
+		return ErrVerificationTokenInvalid
+	}
+	return ValidatePassword(r.NewPassword)
+}
@@ -0,0 +1,256 @@
+package pii
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// formatVersion prefixes every ciphertext so the format can change later
+const formatVersion = "v1"
+
+// KeySize is the size of key-encryption keys and data keys (AES-256)
+const KeySize = 32
+
+var (
+	ErrMalformedCiphertext = errors.New("malformed ciphertext")
+	ErrUnknownKey          = errors.New("ciphertext uses an unknown key")
+	ErrDecrypt             = errors.New("failed to decrypt: wrong key or tampered data")
+)
+
+// Cipher encrypts values with envelope encryption
+//
+// Every value gets a fresh random data key (DEK). The value is sealed with the DEK
+// using AES-256-GCM, and the DEK is sealed with a key-encryption key (KEK) from config.
+// The result is a self-describing string:
+//
+//	v1:<kek id>:<base64 sealed DEK>:<base64 nonce+sealed value>
+//
+// Several KEKs can be loaded at once. New values use the active one, and values under
+// an older KEK still decrypt, so keys can be rotated by re-wrapping the DEKs (see Rewrap).
+type Cipher struct {
+	activeID string
+	keks     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+// NewCipher creates a Cipher from KEKs by ID, the ID of the KEK used for new values,
+// and a separate key for blind indexes
+func NewCipher(keys map[string][]byte, activeID string, indexKey []byte) (*Cipher, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key is required")
+	}
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active key %q is not configured", activeID)
+	}
+	if len(indexKey) < KeySize {
+		return nil, fmt.Errorf("index key must be at least %d bytes", KeySize)
+	}
+
+	keks := make(map[string]cipher.AEAD, len(keys))
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		keks[id] = aead
+	}
+
+	return &Cipher{activeID: activeID, keks: keks, indexKey: indexKey}, nil
+}
+
+// ActiveKeyID returns the ID of the KEK used for new values
+func (c *Cipher) ActiveKeyID() string {
+	return c.activeID
+}
+
+// Encrypt seals plaintext under a new data key wrapped by the active KEK
+// context is authenticated but not stored; the same context must be given to Decrypt.
+// Binding a value to its row and column (e.g. "customers.national_id_number:<id>")
+// stops a ciphertext from being copied into another row.
+func (c *Cipher) Encrypt(plaintext, context string) (string, error) {
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	wrapped, err := c.wrap(c.activeID, dek)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(aead, []byte(plaintext), []byte(context))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		formatVersion,
+		c.activeID,
+		base64.RawStdEncoding.EncodeToString(wrapped),
+		base64.RawStdEncoding.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+// Decrypt opens a value produced by Encrypt with the same context
+func (c *Cipher) Decrypt(ciphertext, context string) (string, error) {
+	keyID, wrapped, sealed, err := parse(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	dek, err := c.unwrap(keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, sealed, []byte(context))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap re-seals a value's data key under the active KEK
+// The value itself is not decrypted, so no context is needed. Values already
+// under the active KEK are returned unchanged.
+func (c *Cipher) Rewrap(ciphertext string) (string, error) {
+	keyID, wrapped, sealed, err := parse(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if keyID == c.activeID {
+		return ciphertext, nil
+	}
+
+	dek, err := c.unwrap(keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	rewrapped, err := c.wrap(c.activeID, dek)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{
+		formatVersion,
+		c.activeID,
+		base64.RawStdEncoding.EncodeToString(rewrapped),
+		base64.RawStdEncoding.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+// BlindIndex returns a keyed hash of a value for exact-match lookups
+// Equal values give equal indexes, but without the index key the index reveals nothing
+// about the value. context separates indexes of different fields.
+func (c *Cipher) BlindIndex(value, context string) string {
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write([]byte(context))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// KeyID returns the ID of the KEK a ciphertext was sealed under
+// ok is false if the value is not a ciphertext, e.g. legacy plaintext
+func KeyID(value string) (id string, ok bool) {
+	keyID, _, _, err := parse(value)
+	if err != nil {
+		return "", false
+	}
+	return keyID, true
+}
+
+// IsEncrypted reports whether a stored value is a ciphertext rather than legacy plaintext
+func IsEncrypted(value string) bool {
+	_, ok := KeyID(value)
+	return ok
+}
+
+// wrap seals a data key with a KEK; the key ID is authenticated so a sealed DEK cannot be relabeled
+func (c *Cipher) wrap(keyID string, dek []byte) ([]byte, error) {
+	kek, ok := c.keks[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return seal(kek, dek, []byte(formatVersion+":"+keyID))
+}
+
+// unwrap opens a sealed data key
+func (c *Cipher) unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := c.keks[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return open(kek, wrapped, []byte(formatVersion+":"+keyID))
+}
+
+// parse splits a ciphertext into its key ID, sealed DEK and sealed value
+func parse(ciphertext string) (keyID string, wrapped, sealed []byte, err error) {
+	parts := strings.Split(ciphertext, ":")
+	if len(parts) != 4 || parts[0] != formatVersion || parts[1] == "" {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+
+	wrapped, err = base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+	sealed, err = base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+	return parts[1], wrapped, sealed, nil
+}
+
+// newGCM creates an AES-GCM AEAD for a 256-bit key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce and returns nonce+ciphertext
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts nonce+ciphertext produced by seal
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,185 @@
+package pii
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func newTestCipher(t *testing.T, keys map[string][]byte, active string) *Cipher {
+	t.Helper()
+	c, err := NewCipher(keys, active, testKey(0xff))
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	return c
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+
+	ciphertext, err := c.Encrypt("01019012345", "customers.national_id_number:abc")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if strings.Contains(ciphertext, "01019012345") {
+		t.Fatal("ciphertext contains the plaintext")
+	}
+	if !strings.HasPrefix(ciphertext, "v1:k1:") {
+		t.Errorf("ciphertext = %q, want prefix v1:k1:", ciphertext)
+	}
+
+	got, err := c.Decrypt(ciphertext, "customers.national_id_number:abc")
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if got != "01019012345" {
+		t.Errorf("Decrypt() = %q, want %q", got, "01019012345")
+	}
+
+	// Every encryption uses a fresh data key and nonce
+	again, _ := c.Encrypt("01019012345", "customers.national_id_number:abc")
+	if again == ciphertext {
+		t.Error("two encryptions of the same value are identical")
+	}
+}
+
+func TestCipher_ContextIsBound(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	ciphertext, _ := c.Encrypt("secret", "customers.national_id_number:row-1")
+
+	if _, err := c.Decrypt(ciphertext, "customers.national_id_number:row-2"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Decrypt with another context: error = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestCipher_Tampering(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k1")
+	ciphertext, _ := c.Encrypt("secret", "ctx")
+	parts := strings.Split(ciphertext, ":")
+
+	// Relabeling the key ID must not let another KEK open the data key
+	relabeled := strings.Join([]string{parts[0], "k2", parts[2], parts[3]}, ":")
+	if _, err := c.Decrypt(relabeled, "ctx"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("relabeled key ID: error = %v, want ErrDecrypt", err)
+	}
+
+	sealed, _ := base64.RawStdEncoding.DecodeString(parts[3])
+	sealed[len(sealed)-1] ^= 1
+	flipped := strings.Join([]string{parts[0], parts[1], parts[2], base64.RawStdEncoding.EncodeToString(sealed)}, ":")
+	if _, err := c.Decrypt(flipped, "ctx"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("flipped bit: error = %v, want ErrDecrypt", err)
+	}
+
+	for _, bad := range []string{"", "plaintext", "v1:k1:abc", "v2:k1:YQ:YQ", "v1:k1:!!:YQ"} {
+		if _, err := c.Decrypt(bad, "ctx"); !errors.Is(err, ErrMalformedCiphertext) {
+			t.Errorf("Decrypt(%q) error = %v, want ErrMalformedCiphertext", bad, err)
+		}
+	}
+}
+
+func TestCipher_Rotation(t *testing.T) {
+	old := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+	ciphertext, _ := old.Encrypt("secret", "ctx")
+
+	// After rotation both keys are loaded and k2 is active
+	rotated := newTestCipher(t, map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, "k2")
+	if got, err := rotated.Decrypt(ciphertext, "ctx"); err != nil || got != "secret" {
+		t.Fatalf("Decrypt under old key = (%q, %v), want (secret, nil)", got, err)
+	}
+
+	rewrapped, err := rotated.Rewrap(ciphertext)
+	if err != nil {
+		t.Fatalf("Rewrap() error = %v", err)
+	}
+	if id, _ := KeyID(rewrapped); id != "k2" {
+		t.Errorf("KeyID(rewrapped) = %q, want k2", id)
+	}
+	if again, _ := rotated.Rewrap(rewrapped); again != rewrapped {
+		t.Error("Rewrap changed a value already under the active key")
+	}
+
+	// Once k1 is retired, the re-wrapped value still opens
+	retired := newTestCipher(t, map[string][]byte{"k2": testKey(2)}, "k2")
+	if got, err := retired.Decrypt(rewrapped, "ctx"); err != nil || got != "secret" {
+		t.Errorf("Decrypt after retiring k1 = (%q, %v), want (secret, nil)", got, err)
+	}
+	if _, err := retired.Decrypt(ciphertext, "ctx"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt under retired key: error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestCipher_BlindIndex(t *testing.T) {
+	c := newTestCipher(t, map[string][]byte{"k1": testKey(1)}, "k1")
+
+	a := c.BlindIndex("01019012345", "national_id")
+	if len(a) != 64 {
+		t.Errorf("len(index) = %d, want 64 hex characters", len(a))
+	}
+	if c.BlindIndex("01019012345", "national_id") != a {
+		t.Error("BlindIndex is not deterministic")
+	}
+	if c.BlindIndex("01019012346", "national_id") == a {
+		t.Error("different values have the same index")
+	}
+	if c.BlindIndex("01019012345", "other_field") == a {
+		t.Error("different contexts have the same index")
+	}
+
+	// The index depends on the index key, not the KEKs
+	other, _ := NewCipher(map[string][]byte{"k9": testKey(9)}, "k9", testKey(0xff))
+	if other.BlindIndex("01019012345", "national_id") != a {
+		t.Error("rotating KEKs changed the blind index")
+	}
+}
+
+func TestNewCipher_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     map[string][]byte
+		active   string
+		indexKey []byte
+	}{
+		{"no keys", map[string][]byte{}, "k1", testKey(0xff)},
+		{"unknown active", map[string][]byte{"k1": testKey(1)}, "k2", testKey(0xff)},
+		{"short key", map[string][]byte{"k1": []byte("short")}, "k1", testKey(0xff)},
+		{"colon in ID", map[string][]byte{"k:1": testKey(1)}, "k:1", testKey(0xff)},
+		{"short index key", map[string][]byte{"k1": testKey(1)}, "k1", []byte("short")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCipher(tt.keys, tt.active, tt.indexKey); err == nil {
+				t.Error("NewCipher() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	k1 := base64.StdEncoding.EncodeToString(testKey(1))
+	k2 := base64.StdEncoding.EncodeToString(testKey(2))
+
+	keys, first, err := ParseKeys("2024-06:" + k2 + ", 2023-01:" + k1)
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+	if first != "2024-06" {
+		t.Errorf("first = %q, want 2024-06", first)
+	}
+	if !bytes.Equal(keys["2023-01"], testKey(1)) || !bytes.Equal(keys["2024-06"], testKey(2)) {
+		t.Error("keys not decoded correctly")
+	}
+
+	for _, bad := range []string{"", "nokey", ":" + k1, "k1:not base64!", "k1:" + k1 + ",k1:" + k2} {
+		if _, _, err := ParseKeys(bad); err == nil {
+			t.Errorf("ParseKeys(%q) error = nil, want an error", bad)
+		}
+	}
+}
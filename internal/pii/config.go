@@ -0,0 +1,97 @@
+package pii
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// devKeyID is the key ID used when no keys are configured
+const devKeyID = "dev"
+
+// ParseKeys parses KEKs in the form "id1:base64key1,id2:base64key2"
+// Returns the keys by ID and the first ID, the default active key
+func ParseKeys(s string) (map[string][]byte, string, error) {
+	keys := make(map[string][]byte)
+	var first string
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, "", fmt.Errorf("invalid key entry %q: expected id:base64key", entry)
+		}
+		if _, dup := keys[id]; dup {
+			return nil, "", fmt.Errorf("duplicate key ID %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", fmt.Errorf("key %q is not valid base64: %w", id, err)
+		}
+		keys[id] = key
+		if first == "" {
+			first = id
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, "", fmt.Errorf("no keys configured")
+	}
+	return keys, first, nil
+}
+
+// FromEnv creates a Cipher from the environment
+//
+//	PII_KEYS          KEKs as "id:base64key,..." (32-byte keys, e.g. openssl rand -base64 32)
+//	PII_ACTIVE_KEY_ID KEK for new values; defaults to the first key in PII_KEYS
+//	PII_INDEX_KEY     base64 key for blind indexes; never rotated by re-encryption
+//
+// Without PII_KEYS and PII_INDEX_KEY, fixed development keys are used with a warning.
+func FromEnv() (*Cipher, error) {
+	rawKeys := os.Getenv("PII_KEYS")
+	rawIndexKey := os.Getenv("PII_INDEX_KEY")
+
+	var keys map[string][]byte
+	var activeID string
+	if rawKeys == "" {
+		// Default for local development - CHANGE IN PRODUCTION!
+		log.Println("WARNING: Using development PII encryption key. Set PII_KEYS environment variable in production!")
+		keys = map[string][]byte{devKeyID: devKey("fjord-dev-pii-kek")}
+		activeID = devKeyID
+	} else {
+		var err error
+		keys, activeID, err = ParseKeys(rawKeys)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PII_KEYS: %w", err)
+		}
+	}
+	if id := os.Getenv("PII_ACTIVE_KEY_ID"); id != "" {
+		activeID = id
+	}
+
+	var indexKey []byte
+	if rawIndexKey == "" {
+		log.Println("WARNING: Using development PII index key. Set PII_INDEX_KEY environment variable in production!")
+		indexKey = devKey("fjord-dev-pii-index")
+	} else {
+		var err error
+		indexKey, err = base64.StdEncoding.DecodeString(rawIndexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PII_INDEX_KEY: %w", err)
+		}
+	}
+
+	return NewCipher(keys, activeID, indexKey)
+}
+
+// devKey derives a fixed development key from a label
+func devKey(label string) []byte {
+	sum := sha256.Sum256([]byte(label))
+	return sum[:]
+}
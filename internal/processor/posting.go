@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/simonkvalheim/hm9-banking/internal/journal"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// Posting is a journal booked under one transaction
+// It may have any number of legs, as long as they sum to zero in each currency: a transfer has
+// two, a transfer with a fee three, a split payment one debit and several credits.
+type Posting struct {
+	TransactionID uuid.UUID
+	Legs          []model.PostingLeg
+
+	// Check runs with every account locked, after the status checks and before the funds check,
+	// e.g. to enforce transfer limits. It returns a rejection reason, or "" to go ahead.
+	Check func(accounts map[uuid.UUID]*model.Account) (string, error)
+}
+
+// Post books a posting inside dbTx, which the caller commits, through journal.Book
+// The balances of all accounts in the legs are locked in a fixed order and held until commit.
+// Each account must be active and in its leg's currency, and customer accounts debited overall
+// need the funds, including any overdraft; system accounts may go negative.
+// If a rule fails, nothing is booked and the reason is returned, e.g. "insufficient funds",
+// so the caller can record it on the transaction. Once booked, the entries are summed again
+// in the database and the posting is rejected with an error unless they balance per currency.
+func (p *TransferProcessor) Post(ctx context.Context, dbTx pgx.Tx, posting Posting) (string, error) {
+	return journal.Book(ctx, dbTx, journal.Posting{
+		TransactionID: posting.TransactionID,
+		Legs:          posting.Legs,
+		Check: func(balances map[uuid.UUID]model.Money) (string, error) {
+			return p.checkPosting(ctx, dbTx, posting, balances)
+		},
+	})
+}
+
+// checkPosting applies the processor's rules to a posting whose balances are locked
+// Returns the reason the posting is rejected, or "" if it may be booked
+func (p *TransferProcessor) checkPosting(ctx context.Context, dbTx pgx.Tx, posting Posting, balances map[uuid.UUID]model.Money) (string, error) {
+	accountIDs, net, err := model.NetByAccount(posting.Legs)
+	if err != nil {
+		return "", err
+	}
+
+	// Accounts are read after the balance locks: closing an account takes the same lock,
+	// so a posting queued before an account was frozen or closed sees its new status
+	accounts := make(map[uuid.UUID]*model.Account, len(accountIDs))
+	for _, id := range accountIDs {
+		account, err := p.getAccount(ctx, dbTx, id)
+		if err != nil {
+			return "", fmt.Errorf("failed to get account %s: %w", id, err)
+		}
+		accounts[id] = account
+	}
+
+	if reason := unavailableReason(posting.Legs, accounts); reason != "" {
+		return reason, nil
+	}
+
+	if posting.Check != nil {
+		if reason, err := posting.Check(accounts); err != nil || reason != "" {
+			return reason, err
+		}
+	}
+
+	for _, id := range accountIDs {
+		account := accounts[id]
+		if account.IsSystemAccount() || !net[id].IsNegative() {
+			continue
+		}
+		if !hasSufficientFunds(account.AvailableBalance(balances[id]), net[id].Neg()) {
+			return "insufficient funds", nil
+		}
+	}
+
+	return "", nil
+}
+
+// unavailableReason explains why a posting's accounts cannot be booked, or returns ""
+// Frozen and closed accounts can neither send nor receive money, and each leg must be in its
+// account's currency. Accounts are checked in leg order and named by their leg's role.
+func unavailableReason(legs []model.PostingLeg, accounts map[uuid.UUID]*model.Account) string {
+	for _, leg := range legs {
+		account := accounts[leg.AccountID]
+		name := "account"
+		if leg.Role != "" {
+			name = leg.Role + " account"
+		}
+
+		switch account.Status {
+		case model.AccountStatusActive:
+		case model.AccountStatusFrozen:
+			return name + " is frozen"
+		case model.AccountStatusClosed:
+			return name + " is closed"
+		default:
+			return name + " is not active"
+		}
+
+		if account.Currency != leg.Amount.Currency() {
+			return model.ErrCurrencyMismatch.Error()
+		}
+	}
+	return ""
+}
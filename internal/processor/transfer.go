@@ -2,6 +2,7 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/simonkvalheim/hm9-banking/internal/limits"
 	"github.com/simonkvalheim/hm9-banking/internal/model"
 )
 
@@ -54,12 +56,15 @@ func (p *TransferProcessor) Process(ctx context.Context, transactionID uuid.UUID
 		return nil, fmt.Errorf("failed to get parties: %w", err)
 	}
 
-	var sourceAccountID, destAccountID uuid.UUID
+	var sourceAccountID, destAccountID, feeAccountID uuid.UUID
 	for _, party := range parties {
-		if party.Role == "source" {
+		switch party.Role {
+		case "source":
 			sourceAccountID = party.AccountID
-		} else if party.Role == "destination" {
+		case "destination":
 			destAccountID = party.AccountID
+		case "fee":
+			feeAccountID = party.AccountID
 		}
 	}
 
@@ -74,9 +79,9 @@ func (p *TransferProcessor) Process(ctx context.Context, transactionID uuid.UUID
 		return &ProcessResult{Success: false, ErrorMessage: "invalid transaction parties"}, nil
 	}
 
-	// Step 3: Extract amount from metadata
-	amount := tx.Amount
-	if amount == "" {
+	// Step 3: Parse the amount into an exact decimal in the transaction currency
+	amount, err := model.ParseMoney(tx.Amount, tx.Currency)
+	if err != nil || !amount.IsPositive() {
 		if err := p.failTransaction(ctx, dbTx, transactionID, "invalid amount in transaction"); err != nil {
 			return nil, err
 		}
@@ -86,30 +91,58 @@ func (p *TransferProcessor) Process(ctx context.Context, transactionID uuid.UUID
 		return &ProcessResult{Success: false, ErrorMessage: "invalid amount"}, nil
 	}
 
-	// Step 4: Check sufficient balance (with row lock on ledger entries)
-	balance, err := p.getBalanceForUpdate(ctx, dbTx, sourceAccountID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get balance: %w", err)
+	// A fee fixed when the transfer was created is paid from the source to the fee party (bank revenue)
+	legs := model.TransferLegs(sourceAccountID, destAccountID, amount)
+	if tx.FeeAmount != "" {
+		fee, err := model.ParseMoney(tx.FeeAmount, tx.Currency)
+		if err != nil || !fee.IsPositive() || feeAccountID == uuid.Nil {
+			if err := p.failTransaction(ctx, dbTx, transactionID, "invalid fee in transaction"); err != nil {
+				return nil, err
+			}
+			if err := dbTx.Commit(ctx); err != nil {
+				return nil, fmt.Errorf("failed to commit: %w", err)
+			}
+			return &ProcessResult{Success: false, ErrorMessage: "invalid fee"}, nil
+		}
+		legs = append(legs, model.FeeLegs(sourceAccountID, feeAccountID, fee)...)
 	}
 
-	if !hasSufficientFunds(balance, amount) {
-		// Mark as failed - insufficient funds
-		if err := p.failTransaction(ctx, dbTx, transactionID, "insufficient funds"); err != nil {
+	// Step 4: Book the transfer as a posting: two legs, plus two more for a fee
+	// Post locks the balances, checks account status and funds (amount plus fee), and books balanced entries.
+	// Outgoing limits are checked under the source balance lock, so two transfers from one
+	// account cannot both fit into the same remaining headroom. Fees do not count against them.
+	reason, err := p.Post(ctx, dbTx, Posting{
+		TransactionID: transactionID,
+		Legs:          legs,
+		Check: func(accounts map[uuid.UUID]*model.Account) (string, error) {
+			source := accounts[sourceAccountID]
+			if source.IsSystemAccount() {
+				return "", nil
+			}
+			violation, err := p.checkLimits(ctx, dbTx, source, tx, amount)
+			if err != nil {
+				return "", fmt.Errorf("failed to check transfer limits: %w", err)
+			}
+			if violation != nil {
+				return violation.Reason(), nil
+			}
+			return "", nil
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to post transfer: %w", err)
+	}
+	if reason != "" {
+		if err := p.failTransaction(ctx, dbTx, transactionID, reason); err != nil {
 			return nil, err
 		}
 		if err := dbTx.Commit(ctx); err != nil {
 			return nil, fmt.Errorf("failed to commit: %w", err)
 		}
-		return &ProcessResult{Success: false, ErrorMessage: "insufficient funds"}, nil
+		return &ProcessResult{Success: false, ErrorMessage: reason}, nil
 	}
 
-	// Step 5: Create ledger entries (double-entry bookkeeping)
-	entries := buildTransferEntries(transactionID, sourceAccountID, destAccountID, amount)
-	if err := p.createLedgerEntries(ctx, dbTx, entries); err != nil {
-		return nil, fmt.Errorf("failed to create ledger entries: %w", err)
-	}
-
-	// Step 6: Mark transaction as completed
+	// Step 5: Mark transaction as completed
 	if err := p.completeTransaction(ctx, dbTx, transactionID); err != nil {
 		return nil, fmt.Errorf("failed to complete transaction: %w", err)
 	}
@@ -122,6 +155,26 @@ func (p *TransferProcessor) Process(ctx context.Context, transactionID uuid.UUID
 	return &ProcessResult{Success: true}, nil
 }
 
+// SourceAccountID returns the account a transaction debits
+// Returns uuid.Nil if the transaction has no source party
+func (p *TransferProcessor) SourceAccountID(ctx context.Context, transactionID uuid.UUID) (uuid.UUID, error) {
+	query := `
+		SELECT account_id
+		FROM transaction_parties
+		WHERE transaction_id = $1 AND role = 'source'
+	`
+
+	var accountID uuid.UUID
+	err := p.db.QueryRow(ctx, query, transactionID).Scan(&accountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, nil
+		}
+		return uuid.Nil, fmt.Errorf("failed to get source account: %w", err)
+	}
+	return accountID, nil
+}
+
 // claimTransaction atomically claims a pending transaction for processing
 func (p *TransferProcessor) claimTransaction(ctx context.Context, dbTx pgx.Tx, id uuid.UUID) (*model.Transaction, error) {
 	now := time.Now()
@@ -130,11 +183,11 @@ func (p *TransferProcessor) claimTransaction(ctx context.Context, dbTx pgx.Tx, i
 		UPDATE transactions
 		SET status = $1, processed_at = $2
 		WHERE id = $3 AND status = $4
-		RETURNING id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, error_message, metadata, amount, currency, from_account_id, to_account_id
+		RETURNING id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, error_message, metadata, amount, currency, from_account_id, to_account_id, fee_amount
 	`
 
 	tx := &model.Transaction{}
-	var reference, errorMessage, amount, currency *string
+	var reference, errorMessage, amount, currency, feeAmount *string
 	err := dbTx.QueryRow(ctx, query,
 		model.TransactionStatusProcessing,
 		now,
@@ -155,6 +208,7 @@ func (p *TransferProcessor) claimTransaction(ctx context.Context, dbTx pgx.Tx, i
 		&currency,
 		&tx.FromAccountID,
 		&tx.ToAccountID,
+		&feeAmount,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -175,6 +229,9 @@ func (p *TransferProcessor) claimTransaction(ctx context.Context, dbTx pgx.Tx, i
 	if currency != nil {
 		tx.Currency = *currency
 	}
+	if feeAmount != nil {
+		tx.FeeAmount = *feeAmount
+	}
 
 	return tx, nil
 }
@@ -205,46 +262,53 @@ func (p *TransferProcessor) getParties(ctx context.Context, dbTx pgx.Tx, transac
 	return parties, nil
 }
 
-// getBalanceForUpdate gets the current balance with a lock to prevent concurrent modifications
-func (p *TransferProcessor) getBalanceForUpdate(ctx context.Context, dbTx pgx.Tx, accountID uuid.UUID) (float64, error) {
-	// Use FOR UPDATE to lock relevant rows and prevent race conditions
+// getAccount retrieves an account within the db transaction
+func (p *TransferProcessor) getAccount(ctx context.Context, dbTx pgx.Tx, accountID uuid.UUID) (*model.Account, error) {
 	query := `
-		SELECT COALESCE(SUM(amount), 0)
-		FROM ledger_entries
-		WHERE account_id = $1
+		SELECT id, account_number, account_type, currency, status, overdraft_limit::text, customer_id, created_at, updated_at
+		FROM accounts
+		WHERE id = $1
 	`
 
-	var balance float64
-	err := dbTx.QueryRow(ctx, query, accountID).Scan(&balance)
+	account := &model.Account{}
+	var overdraftLimit string
+	err := dbTx.QueryRow(ctx, query, accountID).Scan(
+		&account.ID,
+		&account.AccountNumber,
+		&account.AccountType,
+		&account.Currency,
+		&account.Status,
+		&overdraftLimit,
+		&account.CustomerID,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
 	if err != nil {
-		return 0, err
+		if err == pgx.ErrNoRows {
+			return nil, model.ErrAccountNotFound
+		}
+		return nil, err
 	}
 
-	return balance, nil
-}
+	if account.OverdraftLimit, err = model.ParseMoney(overdraftLimit, account.Currency); err != nil {
+		return nil, err
+	}
 
-// createLedgerEntries inserts the ledger entries
-func (p *TransferProcessor) createLedgerEntries(ctx context.Context, dbTx pgx.Tx, entries []model.LedgerEntry) error {
-	query := `
-		INSERT INTO ledger_entries (id, transaction_id, account_id, amount, entry_type, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
+	return account, nil
+}
 
-	for _, entry := range entries {
-		_, err := dbTx.Exec(ctx, query,
-			entry.ID,
-			entry.TransactionID,
-			entry.AccountID,
-			entry.Amount,
-			entry.EntryType,
-			entry.CreatedAt,
-		)
-		if err != nil {
-			return err
-		}
+// checkLimits checks the transaction against the source account's transfer limits
+// Returns the broken limit, or nil if the transaction fits
+func (p *TransferProcessor) checkLimits(ctx context.Context, dbTx pgx.Tx, source *model.Account, tx *model.Transaction, amount model.Money) (*limits.Violation, error) {
+	accountLimits, err := limits.Load(ctx, dbTx, source)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	usage, err := limits.LoadUsage(ctx, dbTx, source, tx.ID, tx.InitiatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return limits.Check(accountLimits, usage, amount)
 }
 
 // completeTransaction marks the transaction as completed
@@ -291,35 +355,11 @@ func (p *TransferProcessor) failTransaction(ctx context.Context, dbTx pgx.Tx, id
 }
 
 // hasSufficientFunds checks if the balance covers the transfer amount
-func hasSufficientFunds(balance float64, amountStr string) bool {
-	var amount float64
-	_, err := fmt.Sscanf(amountStr, "%f", &amount)
+// Amounts in different currencies never cover each other
+func hasSufficientFunds(balance, amount model.Money) bool {
+	cmp, err := balance.Cmp(amount)
 	if err != nil {
 		return false
 	}
-	return balance >= amount
-}
-
-// buildTransferEntries creates balanced ledger entries for a transfer
-func buildTransferEntries(transactionID, fromAccountID, toAccountID uuid.UUID, amount string) []model.LedgerEntry {
-	now := time.Now()
-
-	return []model.LedgerEntry{
-		{
-			ID:            uuid.New(),
-			TransactionID: transactionID,
-			AccountID:     fromAccountID,
-			Amount:        "-" + amount, // Debit (negative)
-			EntryType:     model.LedgerEntryTypeDebit,
-			CreatedAt:     now,
-		},
-		{
-			ID:            uuid.New(),
-			TransactionID: transactionID,
-			AccountID:     toAccountID,
-			Amount:        amount, // Credit (positive)
-			EntryType:     model.LedgerEntryTypeCredit,
-			CreatedAt:     now,
-		},
-	}
+	return cmp >= 0
 }
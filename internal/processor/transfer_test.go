@@ -1,7 +1,6 @@
 package processor
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/google/uuid"
@@ -9,66 +8,75 @@ import (
 	"github.com/simonkvalheim/hm9-banking/internal/model"
 )
 
+func mustMoney(t *testing.T, amount, currency string) model.Money {
+	t.Helper()
+	m, err := model.ParseMoney(amount, currency)
+	if err != nil {
+		t.Fatalf("ParseMoney(%q, %q) error = %v", amount, currency, err)
+	}
+	return m
+}
+
 func TestHasSufficientFunds(t *testing.T) {
 	tests := []struct {
 		name    string
-		balance float64
+		balance string
 		amount  string
 		want    bool
 	}{
 		{
 			name:    "sufficient funds - exact",
-			balance: 100.00,
+			balance: "100.00",
 			amount:  "100.00",
 			want:    true,
 		},
 		{
 			name:    "sufficient funds - more than needed",
-			balance: 150.00,
+			balance: "150.00",
 			amount:  "100.00",
 			want:    true,
 		},
 		{
 			name:    "insufficient funds",
-			balance: 50.00,
+			balance: "50.00",
 			amount:  "100.00",
 			want:    false,
 		},
 		{
 			name:    "zero balance",
-			balance: 0.00,
+			balance: "0.00",
 			amount:  "100.00",
 			want:    false,
 		},
 		{
 			name:    "negative balance",
-			balance: -50.00,
+			balance: "-50.00",
 			amount:  "100.00",
 			want:    false,
 		},
 		{
 			name:    "small amount",
-			balance: 0.01,
+			balance: "0.01",
 			amount:  "0.01",
 			want:    true,
 		},
 		{
-			name:    "invalid amount format",
-			balance: 100.00,
-			amount:  "invalid",
+			name:    "one minor unit short",
+			balance: "100.09",
+			amount:  "100.10",
 			want:    false,
 		},
 		{
-			name:    "empty amount",
-			balance: 100.00,
-			amount:  "",
-			want:    false,
+			name:    "sum of tenths that float64 rounds below 0.3",
+			balance: "0.30",
+			amount:  "0.30",
+			want:    true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := hasSufficientFunds(tt.balance, tt.amount)
+			got := hasSufficientFunds(mustMoney(t, tt.balance, "NOK"), mustMoney(t, tt.amount, "NOK"))
 			if got != tt.want {
 				t.Errorf("hasSufficientFunds(%v, %v) = %v, want %v", tt.balance, tt.amount, got, tt.want)
 			}
@@ -76,86 +84,58 @@ func TestHasSufficientFunds(t *testing.T) {
 	}
 }
 
-func TestBuildTransferEntries(t *testing.T) {
-	txID := uuid.New()
-	fromID := uuid.New()
-	toID := uuid.New()
-	amount := "100.00"
-
-	entries := buildTransferEntries(txID, fromID, toID, amount)
-
-	// Should create exactly 2 entries
-	if len(entries) != 2 {
-		t.Fatalf("buildTransferEntries() returned %d entries, want 2", len(entries))
-	}
-
-	// First entry should be debit (negative) from source
-	debitEntry := entries[0]
-	if debitEntry.TransactionID != txID {
-		t.Errorf("debit entry transaction ID = %v, want %v", debitEntry.TransactionID, txID)
-	}
-	if debitEntry.AccountID != fromID {
-		t.Errorf("debit entry account ID = %v, want %v", debitEntry.AccountID, fromID)
-	}
-	if debitEntry.Amount != "-100.00" {
-		t.Errorf("debit entry amount = %v, want -100.00", debitEntry.Amount)
-	}
-	if debitEntry.EntryType != model.LedgerEntryTypeDebit {
-		t.Errorf("debit entry type = %v, want %v", debitEntry.EntryType, model.LedgerEntryTypeDebit)
+func TestHasSufficientFunds_CurrencyMismatch(t *testing.T) {
+	if hasSufficientFunds(mustMoney(t, "1000", "EUR"), mustMoney(t, "1", "NOK")) {
+		t.Error("hasSufficientFunds() = true for mismatched currencies, want false")
 	}
+}
 
-	// Second entry should be credit (positive) to destination
-	creditEntry := entries[1]
-	if creditEntry.TransactionID != txID {
-		t.Errorf("credit entry transaction ID = %v, want %v", creditEntry.TransactionID, txID)
-	}
-	if creditEntry.AccountID != toID {
-		t.Errorf("credit entry account ID = %v, want %v", creditEntry.AccountID, toID)
-	}
-	if creditEntry.Amount != "100.00" {
-		t.Errorf("credit entry amount = %v, want 100.00", creditEntry.Amount)
-	}
-	if creditEntry.EntryType != model.LedgerEntryTypeCredit {
-		t.Errorf("credit entry type = %v, want %v", creditEntry.EntryType, model.LedgerEntryTypeCredit)
+func TestUnavailableReason(t *testing.T) {
+	tests := []struct {
+		name         string
+		source, dest model.AccountStatus
+		want         string
+	}{
+		{"both active", model.AccountStatusActive, model.AccountStatusActive, ""},
+		{"source frozen", model.AccountStatusFrozen, model.AccountStatusActive, "source account is frozen"},
+		{"destination frozen", model.AccountStatusActive, model.AccountStatusFrozen, "destination account is frozen"},
+		{"source closed", model.AccountStatusClosed, model.AccountStatusActive, "source account is closed"},
+		{"destination closed", model.AccountStatusActive, model.AccountStatusClosed, "destination account is closed"},
+		{"unknown status", "dormant", model.AccountStatusActive, "source account is not active"},
 	}
 
-	// Entries should have unique IDs
-	if debitEntry.ID == creditEntry.ID {
-		t.Error("debit and credit entries have the same ID")
-	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &model.Account{ID: uuid.New(), Currency: "NOK", Status: tt.source}
+			dest := &model.Account{ID: uuid.New(), Currency: "NOK", Status: tt.dest}
+			legs := model.TransferLegs(source.ID, dest.ID, mustMoney(t, "100.00", "NOK"))
+			accounts := map[uuid.UUID]*model.Account{source.ID: source, dest.ID: dest}
 
-	// Entries should have same timestamp (approximately)
-	if debitEntry.CreatedAt != creditEntry.CreatedAt {
-		t.Error("debit and credit entries have different timestamps")
+			if got := unavailableReason(legs, accounts); got != tt.want {
+				t.Errorf("unavailableReason() = %q, want %q", got, tt.want)
+			}
+		})
 	}
 }
 
-func TestBuildTransferEntries_DoubleEntryBalance(t *testing.T) {
-	// Test that entries sum to zero (core double-entry principle)
-	txID := uuid.New()
-	fromID := uuid.New()
-	toID := uuid.New()
+func TestUnavailableReason_MultiLeg(t *testing.T) {
+	source := &model.Account{ID: uuid.New(), Currency: "NOK", Status: model.AccountStatusActive}
+	dest := &model.Account{ID: uuid.New(), Currency: "NOK", Status: model.AccountStatusActive}
+	revenue := &model.Account{ID: uuid.New(), Currency: "NOK", Status: model.AccountStatusFrozen}
+	accounts := map[uuid.UUID]*model.Account{source.ID: source, dest.ID: dest, revenue.ID: revenue}
 
-	testAmounts := []string{"100.00", "0.01", "999999.99", "1.50"}
-
-	for _, amount := range testAmounts {
-		t.Run("amount_"+amount, func(t *testing.T) {
-			entries := buildTransferEntries(txID, fromID, toID, amount)
-
-			// Parse amounts and verify they sum to zero
-			var sum float64
-			for _, entry := range entries {
-				var val float64
-				_, err := fmt.Sscanf(entry.Amount, "%f", &val)
-				if err != nil {
-					t.Fatalf("failed to parse amount %s: %v", entry.Amount, err)
-				}
-				sum += val
-			}
+	legs := []model.PostingLeg{
+		{AccountID: source.ID, Amount: mustMoney(t, "-105.00", "NOK"), Role: "source"},
+		{AccountID: dest.ID, Amount: mustMoney(t, "100.00", "NOK"), Role: "destination"},
+		{AccountID: revenue.ID, Amount: mustMoney(t, "5.00", "NOK"), Role: "fee"},
+	}
+	if got, want := unavailableReason(legs, accounts), "fee account is frozen"; got != want {
+		t.Errorf("unavailableReason() = %q, want %q", got, want)
+	}
 
-			if sum != 0 {
-				t.Errorf("entries sum = %v, want 0 (double-entry principle violated)", sum)
-			}
-		})
+	revenue.Status = model.AccountStatusActive
+	dest.Currency = "EUR"
+	if got, want := unavailableReason(legs, accounts), model.ErrCurrencyMismatch.Error(); got != want {
+		t.Errorf("unavailableReason() with a leg in the wrong currency = %q, want %q", got, want)
 	}
 }
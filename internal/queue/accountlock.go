@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// accountLocks serializes work per account within one worker process
+// Transfers from one account are never processed concurrently. Waiters are released in the order
+// they reach the lock, which is not necessarily the order their messages were pulled: each consumer
+// looks up the source account first, and those lookups can finish in any order.
+type accountLocks struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]*accountLock
+}
+
+// accountLock is a single account's lock, removed once nobody holds or waits for it
+type accountLock struct {
+	ch   chan struct{}
+	refs int
+}
+
+// newAccountLocks creates an empty set of account locks
+func newAccountLocks() *accountLocks {
+	return &accountLocks{locks: make(map[uuid.UUID]*accountLock)}
+}
+
+// Lock blocks until the account is free and returns a function that releases it
+// uuid.Nil is never locked
+func (l *accountLocks) Lock(accountID uuid.UUID) (unlock func()) {
+	if accountID == uuid.Nil {
+		return func() {}
+	}
+
+	l.mu.Lock()
+	lock, ok := l.locks[accountID]
+	if !ok {
+		lock = &accountLock{ch: make(chan struct{}, 1)}
+		l.locks[accountID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.ch <- struct{}{}
+
+	return func() {
+		<-lock.ch
+
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, accountID)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// size returns the number of accounts currently held or waited for
+func (l *accountLocks) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.locks)
+}
@@ -0,0 +1,77 @@
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAccountLocks_SerializesSameAccount(t *testing.T) {
+	locks := newAccountLocks()
+	accountID := uuid.New()
+
+	var active, maxActive int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.Lock(accountID)
+			defer unlock()
+
+			n := atomic.AddInt32(&active, 1)
+			for {
+				m := atomic.LoadInt32(&maxActive)
+				if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&active, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 1 {
+		t.Errorf("max concurrent holders = %d, want 1", maxActive)
+	}
+	if locks.size() != 0 {
+		t.Errorf("size() = %d after all unlocks, want 0", locks.size())
+	}
+}
+
+func TestAccountLocks_DifferentAccountsDoNotBlock(t *testing.T) {
+	locks := newAccountLocks()
+
+	unlockA := locks.Lock(uuid.New())
+	defer unlockA()
+
+	done := make(chan struct{})
+	go func() {
+		unlockB := locks.Lock(uuid.New())
+		unlockB()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lock on a different account blocked")
+	}
+}
+
+func TestAccountLocks_NilAccountNeverBlocks(t *testing.T) {
+	locks := newAccountLocks()
+
+	unlock1 := locks.Lock(uuid.Nil)
+	unlock2 := locks.Lock(uuid.Nil)
+	unlock1()
+	unlock2()
+
+	if locks.size() != 0 {
+		t.Errorf("size() = %d, want 0", locks.size())
+	}
+}
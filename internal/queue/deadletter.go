@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// moveScript atomically moves a message from one list to another, replacing its payload
+// KEYS[1] = source list, KEYS[2] = leases set, KEYS[3] = destination list
+// ARGV[1] = raw message in the source list, ARGV[2] = message to push
+// Returns 0 if the message was no longer in the source list (e.g. already handled elsewhere)
+var moveScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('RPUSH', KEYS[3], ARGV[2])
+return 1
+`)
+
+// retryScript atomically moves a message from the processing list to the delayed set
+// KEYS[1] = processing list, KEYS[2] = leases set, KEYS[3] = delayed set
+// ARGV[1] = raw message, ARGV[2] = message to retry, ARGV[3] = unix time (ms) it is due
+var retryScript = redis.NewScript(`
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('ZREM', KEYS[2], ARGV[1])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[2])
+return 1
+`)
+
+// promoteScript moves due messages from the delayed set back onto the pending queue
+// KEYS[1] = delayed set, KEYS[2] = pending list
+// ARGV[1] = current unix time (ms), ARGV[2] = max messages to move
+var promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, msg in ipairs(due) do
+	redis.call('ZREM', KEYS[1], msg)
+	redis.call('RPUSH', KEYS[2], msg)
+end
+return #due
+`)
+
+// QueueStats holds the number of messages in each stage of the queue
+type QueueStats struct {
+	Pending    int64
+	Processing int64
+	Delayed    int64
+	Dead       int64
+}
+
+// GetQueueStats returns the current size of every queue stage
+func GetQueueStats(ctx context.Context, client *redis.Client) (*QueueStats, error) {
+	pipe := client.Pipeline()
+	pending := pipe.LLen(ctx, QueueName)
+	processing := pipe.LLen(ctx, ProcessingQueueName)
+	delayed := pipe.ZCard(ctx, DelayedQueueName)
+	dead := pipe.LLen(ctx, DeadQueueName)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read queue stats: %w", err)
+	}
+
+	return &QueueStats{
+		Pending:    pending.Val(),
+		Processing: processing.Val(),
+		Delayed:    delayed.Val(),
+		Dead:       dead.Val(),
+	}, nil
+}
+
+// ListDead returns up to limit messages from the dead-letter queue, oldest first
+func ListDead(ctx context.Context, client *redis.Client, limit int64) ([]TransactionMessage, error) {
+	raws, err := client.LRange(ctx, DeadQueueName, 0, limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter queue: %w", err)
+	}
+
+	messages := make([]TransactionMessage, 0, len(raws))
+	for _, raw := range raws {
+		var msg TransactionMessage
+		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal dead message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// RequeueDead moves dead messages back onto the pending queue with their attempt count reset
+// If transactionID is uuid.Nil every dead message is requeued
+// Returns the number of messages requeued
+func RequeueDead(ctx context.Context, client *redis.Client, transactionID uuid.UUID) (int, error) {
+	raws, err := client.LRange(ctx, DeadQueueName, 0, -1).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read dead-letter queue: %w", err)
+	}
+
+	requeued := 0
+	for _, raw := range raws {
+		var msg TransactionMessage
+		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+			return requeued, fmt.Errorf("failed to unmarshal dead message: %w", err)
+		}
+		if transactionID != uuid.Nil && msg.TransactionID != transactionID {
+			continue
+		}
+
+		msg.Attempts = 0
+		msg.LastError = ""
+		msg.FailedAt = nil
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return requeued, fmt.Errorf("failed to marshal message: %w", err)
+		}
+
+		moved, err := moveScript.Run(ctx, client, []string{DeadQueueName, LeasesKey, QueueName}, raw, data).Int()
+		if err != nil {
+			return requeued, fmt.Errorf("failed to requeue transaction %s: %w", msg.TransactionID, err)
+		}
+		requeued += moved
+	}
+
+	return requeued, nil
+}
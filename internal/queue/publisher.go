@@ -13,13 +13,24 @@ import (
 const (
 	// QueueName is the Redis list key for pending transactions
 	QueueName = "transactions:pending"
+	// ProcessingQueueName holds messages a worker has taken but not yet acknowledged
+	ProcessingQueueName = "transactions:processing"
+	// LeasesKey is a sorted set of processing messages scored by their visibility deadline
+	LeasesKey = "transactions:processing:leases"
+	// DelayedQueueName is a sorted set of messages waiting for a retry, scored by when they are due
+	DelayedQueueName = "transactions:delayed"
+	// DeadQueueName is the Redis list key for messages that exhausted their retries
+	DeadQueueName = "transactions:dead"
 )
 
 // TransactionMessage is the message published to the queue
 type TransactionMessage struct {
-	TransactionID uuid.UUID `json:"transaction_id"`
-	Type          string    `json:"type"`
-	PublishedAt   time.Time `json:"published_at"`
+	TransactionID uuid.UUID  `json:"transaction_id"`
+	Type          string     `json:"type"`
+	PublishedAt   time.Time  `json:"published_at"`
+	Attempts      int        `json:"attempts,omitempty"`   // Failed processing attempts so far
+	LastError     string     `json:"last_error,omitempty"` // Error from the most recent failed attempt
+	FailedAt      *time.Time `json:"failed_at,omitempty"`  // When the most recent attempt failed
 }
 
 // Publisher handles publishing messages to Redis
@@ -53,6 +64,41 @@ func (p *Publisher) PublishTransaction(ctx context.Context, transactionID uuid.U
 	return nil
 }
 
+// HeldTransactions are the transactions the queue holds a message for outside the pending list
+type HeldTransactions struct {
+	InFlight map[uuid.UUID]bool // Taken by a worker and leased, or waiting out a retry backoff
+	Dead     map[uuid.UUID]bool // In the dead-letter queue, waiting for an operator
+}
+
+// HeldTransactionIDs returns the transactions in the processing list, the delayed set and the dead-letter queue
+// Publishing any of them again would give the transaction a second message
+func (p *Publisher) HeldTransactionIDs(ctx context.Context) (*HeldTransactions, error) {
+	pipe := p.client.Pipeline()
+	processing := pipe.LRange(ctx, ProcessingQueueName, 0, -1)
+	delayed := pipe.ZRange(ctx, DelayedQueueName, 0, -1)
+	dead := pipe.LRange(ctx, DeadQueueName, 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to read queued messages: %w", err)
+	}
+
+	held := &HeldTransactions{InFlight: make(map[uuid.UUID]bool), Dead: make(map[uuid.UUID]bool)}
+	addTransactionIDs(held.InFlight, processing.Val())
+	addTransactionIDs(held.InFlight, delayed.Val())
+	addTransactionIDs(held.Dead, dead.Val())
+	return held, nil
+}
+
+// addTransactionIDs adds the transaction ID of each raw message to ids, skipping messages that do not parse
+func addTransactionIDs(ids map[uuid.UUID]bool, raws []string) {
+	for _, raw := range raws {
+		var msg TransactionMessage
+		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+			continue
+		}
+		ids[msg.TransactionID] = true
+	}
+}
+
 // QueueLength returns the current number of messages in the queue
 func (p *Publisher) QueueLength(ctx context.Context) (int64, error) {
 	return p.client.LLen(ctx, QueueName).Result()
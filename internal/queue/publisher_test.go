@@ -0,0 +1,26 @@
+package queue
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAddTransactionIDs(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	raw := func(id uuid.UUID, attempts int) string {
+		data, err := json.Marshal(TransactionMessage{TransactionID: id, Type: "transfer", Attempts: attempts})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		return string(data)
+	}
+
+	ids := map[uuid.UUID]bool{}
+	addTransactionIDs(ids, []string{raw(first, 0), "not json", raw(second, 2), raw(first, 1)})
+
+	if len(ids) != 2 || !ids[first] || !ids[second] {
+		t.Errorf("addTransactionIDs() = %v, want %s and %s", ids, first, second)
+	}
+}
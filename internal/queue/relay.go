@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/repository"
+)
+
+const (
+	// DefaultRelayBatchSize is the number of outbox entries published per poll
+	DefaultRelayBatchSize = 100
+	// relayBaseDelay is the first retry delay after a failed publish
+	relayBaseDelay = 1 * time.Second
+	// relayMaxDelay caps the exponential backoff between publish attempts
+	relayMaxDelay = 5 * time.Minute
+)
+
+// OutboxRelay publishes unsent transaction outbox entries to the processing queue
+// It guarantees at-least-once delivery: the processor's claim step makes duplicates harmless
+type OutboxRelay struct {
+	outboxRepo *repository.OutboxRepository
+	publisher  *Publisher
+	interval   time.Duration
+	batchSize  int
+}
+
+// NewOutboxRelay creates a new OutboxRelay that polls the outbox every interval
+func NewOutboxRelay(outboxRepo *repository.OutboxRepository, publisher *Publisher, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		interval:   interval,
+		batchSize:  DefaultRelayBatchSize,
+	}
+}
+
+// Start polls the outbox until ctx is cancelled
+func (r *OutboxRelay) Start(ctx context.Context) {
+	log.Printf("Outbox relay started (interval: %s)", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopping")
+			return
+		case <-ticker.C:
+			// Drain full batches before waiting for the next tick
+			for {
+				published, failed, err := r.RelayOnce(ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						log.Printf("Outbox relay error: %v", err)
+					}
+					break
+				}
+				if published+failed > 0 {
+					log.Printf("Outbox relay: %d published, %d failed", published, failed)
+				}
+				if published+failed < r.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// RelayOnce publishes a single batch of due outbox entries
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (published, failed int, err error) {
+	return r.outboxRepo.ProcessUnsent(ctx, r.batchSize, func(entry model.OutboxEntry) error {
+		return r.publisher.PublishTransaction(ctx, entry.TransactionID, string(entry.TransactionType))
+	}, relayRetryDelay)
+}
+
+// relayRetryDelay returns the exponential backoff delay after the given number of failed attempts
+func relayRetryDelay(attempts int) time.Duration {
+	return backoff(attempts, relayBaseDelay, relayMaxDelay)
+}
+
+// backoff returns base doubled for every failed attempt after the first, capped at max
+func backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
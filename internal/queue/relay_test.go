@@ -0,0 +1,26 @@
+package queue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelayRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 1 * time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 3, want: 4 * time.Second},
+		{attempts: 9, want: 256 * time.Second},
+		{attempts: 10, want: 5 * time.Minute},
+		{attempts: 100, want: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := relayRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("relayRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
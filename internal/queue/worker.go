@@ -3,7 +3,9 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -11,86 +13,218 @@ import (
 	"github.com/simonkvalheim/hm9-banking/internal/processor"
 )
 
+const (
+	// DefaultMaxAttempts is how many times a message is processed before it is dead-lettered
+	DefaultMaxAttempts = 5
+	// DefaultVisibilityTimeout is how long a worker may hold a message before it is redelivered
+	DefaultVisibilityTimeout = 5 * time.Minute
+	// DefaultConcurrency is the number of messages processed in parallel
+	DefaultConcurrency = 1
+	// DefaultShutdownTimeout is how long in-flight messages may run after shutdown is requested
+	DefaultShutdownTimeout = 30 * time.Second
+
+	// retryBaseDelay is the first retry delay after a failed processing attempt
+	retryBaseDelay = 2 * time.Second
+	// retryMaxDelay caps the exponential backoff between processing attempts
+	retryMaxDelay = 10 * time.Minute
+	// promoteBatchSize is the max number of due retries moved back to the queue per tick
+	promoteBatchSize = 100
+	// leaseCheckInterval is how often expired processing leases are looked for
+	leaseCheckInterval = 15 * time.Second
+)
+
+// WorkerConfig controls concurrency, retry and redelivery behaviour
+type WorkerConfig struct {
+	Concurrency       int           // Number of messages processed in parallel
+	MaxAttempts       int           // Attempts before a message moves to the dead-letter queue
+	VisibilityTimeout time.Duration // Time before an unacknowledged message is redelivered
+	ShutdownTimeout   time.Duration // Time in-flight messages may run after shutdown is requested
+}
+
+// DefaultWorkerConfig returns the default worker settings
+func DefaultWorkerConfig() WorkerConfig {
+	return WorkerConfig{
+		Concurrency:       DefaultConcurrency,
+		MaxAttempts:       DefaultMaxAttempts,
+		VisibilityTimeout: DefaultVisibilityTimeout,
+		ShutdownTimeout:   DefaultShutdownTimeout,
+	}
+}
+
 // Worker consumes messages from the queue and processes transactions
+// Delivery is at-least-once: a message is moved to a processing list while it is
+// being handled and only removed once processing succeeds, is retried, or is dead-lettered.
+// A pool of cfg.Concurrency consumers shares one Redis client and one TransferProcessor;
+// transfers from the same source account are never processed concurrently.
 type Worker struct {
 	client    *redis.Client
 	processor *processor.TransferProcessor
-	stopCh    chan struct{}
+	cfg       WorkerConfig
+	locks     *accountLocks
+
+	stopOnce   sync.Once
+	stopCh     chan struct{}
+	doneCh     chan struct{}
+	processCtx context.Context    // Context for in-flight processing, independent of Start's ctx
+	abortDrain context.CancelFunc // Cancels in-flight processing once the shutdown deadline passes
 }
 
 // NewWorker creates a new Worker
-func NewWorker(client *redis.Client, proc *processor.TransferProcessor) *Worker {
+func NewWorker(client *redis.Client, proc *processor.TransferProcessor, cfg WorkerConfig) *Worker {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	processCtx, abortDrain := context.WithCancel(context.Background())
 	return &Worker{
-		client:    client,
-		processor: proc,
-		stopCh:    make(chan struct{}),
+		client:     client,
+		processor:  proc,
+		cfg:        cfg,
+		locks:      newAccountLocks(),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+		processCtx: processCtx,
+		abortDrain: abortDrain,
 	}
 }
 
 // Start begins consuming messages from the queue
-// This runs in a loop until Stop() is called
+// It blocks until Stop() or Shutdown() is called (or ctx is cancelled) and all
+// in-flight messages have finished. Cancelling ctx stops pulling new messages but
+// does not cancel messages already being processed.
 func (w *Worker) Start(ctx context.Context) {
-	log.Println("Worker started, listening for transactions...")
+	log.Printf("Worker started, listening for transactions (concurrency: %d, max attempts: %d, visibility timeout: %s)...",
+		w.cfg.Concurrency, w.cfg.MaxAttempts, w.cfg.VisibilityTimeout)
+
+	pullCtx, stopPull := context.WithCancel(ctx)
+	defer stopPull()
+	defer close(w.doneCh)
+
+	go w.maintain(pullCtx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.consume(pullCtx, w.processCtx)
+		}()
+	}
+
+	// Stop pulling as soon as a stop is requested
+	select {
+	case <-pullCtx.Done():
+	case <-w.stopCh:
+		stopPull()
+	}
 
+	wg.Wait()
+	log.Println("Worker drained")
+}
+
+// consume pulls and handles messages until pullCtx is cancelled
+func (w *Worker) consume(pullCtx, processCtx context.Context) {
 	for {
-		select {
-		case <-ctx.Done():
-			log.Println("Worker stopping due to context cancellation")
-			return
-		case <-w.stopCh:
-			log.Println("Worker stopping due to stop signal")
-			return
-		default:
-			// Use BLPOP for blocking pop with timeout
-			// This waits up to 5 seconds for a message, then loops to check for stop signal
-			result, err := w.client.BLPop(ctx, 5*time.Second, QueueName).Result()
-			if err != nil {
-				if err == redis.Nil {
-					// Timeout, no message available - continue loop
-					continue
-				}
-				if ctx.Err() != nil {
-					// Context cancelled
-					return
-				}
-				log.Printf("Error reading from queue: %v", err)
-				time.Sleep(1 * time.Second) // Brief pause before retry
-				continue
+		// Use BLMOVE so the message stays in the processing list until acknowledged
+		// This waits up to 5 seconds for a message, then loops to check for stop signal
+		raw, err := w.client.BLMove(pullCtx, QueueName, ProcessingQueueName, "LEFT", "RIGHT", 5*time.Second).Result()
+		if err != nil {
+			if pullCtx.Err() != nil {
+				// Stopping - a message moved just before cancellation is redelivered after its lease
+				return
 			}
-
-			// result[0] is the queue name, result[1] is the message
-			if len(result) < 2 {
+			if err == redis.Nil {
+				// Timeout, no message available - continue loop
 				continue
 			}
-
-			w.processMessage(ctx, result[1])
+			log.Printf("Error reading from queue: %v", err)
+			time.Sleep(1 * time.Second) // Brief pause before retry
+			continue
 		}
+
+		w.handle(processCtx, raw)
 	}
 }
 
-// Stop signals the worker to stop processing
+// Stop signals the worker to stop pulling new messages
+// Messages already being processed run to completion
 func (w *Worker) Stop() {
-	close(w.stopCh)
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+	})
 }
 
-// processMessage handles a single message from the queue
-func (w *Worker) processMessage(ctx context.Context, data string) {
+// Shutdown stops pulling new messages and waits up to timeout for in-flight messages to finish
+// If the deadline passes, in-flight processing is cancelled (its database transactions roll back
+// and the messages are redelivered after their lease expires) and an error is returned.
+func (w *Worker) Shutdown(timeout time.Duration) error {
+	w.Stop()
+
+	select {
+	case <-w.doneCh:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	w.abortDrain()
+	<-w.doneCh
+	return fmt.Errorf("in-flight messages did not finish within %s and were cancelled", timeout)
+}
+
+// ProcessOne processes a single message synchronously (useful for testing)
+func (w *Worker) ProcessOne(ctx context.Context) error {
+	raw, err := w.client.LMove(ctx, QueueName, ProcessingQueueName, "LEFT", "RIGHT").Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil // No message available
+		}
+		return err
+	}
+
+	w.handle(ctx, raw)
+	return nil
+}
+
+// handle leases, processes and then acknowledges, retries or dead-letters a message
+func (w *Worker) handle(ctx context.Context, raw string) {
+	deadline := time.Now().Add(w.cfg.VisibilityTimeout)
+	if err := w.client.ZAdd(ctx, LeasesKey, redis.Z{Score: float64(deadline.UnixMilli()), Member: raw}).Err(); err != nil {
+		// Without a lease the message is still recovered by the lease check
+		log.Printf("Failed to record lease: %v", err)
+	}
+
 	var msg TransactionMessage
-	if err := json.Unmarshal([]byte(data), &msg); err != nil {
-		log.Printf("Failed to unmarshal message: %v", err)
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		// A malformed message can never succeed and has no transaction to requeue
+		log.Printf("Failed to unmarshal message, dropping it: %v", err)
+		w.ack(ctx, raw)
 		return
 	}
 
-	log.Printf("Processing transaction %s (type: %s)", msg.TransactionID, msg.Type)
+	// Serialize transfers from the same source account within this process
+	// Messages for one account may reach the lock out of pull order, since the lookup comes first
+	sourceAccountID, err := w.processor.SourceAccountID(ctx, msg.TransactionID)
+	if err != nil {
+		w.fail(ctx, raw, msg, err)
+		return
+	}
+	unlock := w.locks.Lock(sourceAccountID)
+	defer unlock()
+
+	if err := w.processMessage(ctx, msg); err != nil {
+		w.fail(ctx, raw, msg, err)
+		return
+	}
+	w.ack(ctx, raw)
+}
+
+// processMessage handles a single message from the queue
+// An error means the transaction was left untouched and processing should be retried
+func (w *Worker) processMessage(ctx context.Context, msg TransactionMessage) error {
+	log.Printf("Processing transaction %s (type: %s, attempt: %d)", msg.TransactionID, msg.Type, msg.Attempts+1)
 
 	result, err := w.processor.Process(ctx, msg.TransactionID)
 	if err != nil {
-		log.Printf("Failed to process transaction %s: %v", msg.TransactionID, err)
-		// In production, you might want to:
-		// - Retry with exponential backoff
-		// - Move to a dead-letter queue after max retries
-		// - Send alerts
-		return
+		return err
 	}
 
 	if result.Success {
@@ -98,18 +232,122 @@ func (w *Worker) processMessage(ctx context.Context, data string) {
 	} else {
 		log.Printf("Transaction %s failed: %s", msg.TransactionID, result.ErrorMessage)
 	}
+	return nil
 }
 
-// ProcessOne processes a single message synchronously (useful for testing)
-func (w *Worker) ProcessOne(ctx context.Context) error {
-	result, err := w.client.LPop(ctx, QueueName).Result()
+// ack removes a handled message from the processing list
+func (w *Worker) ack(ctx context.Context, raw string) {
+	pipe := w.client.TxPipeline()
+	pipe.LRem(ctx, ProcessingQueueName, 1, raw)
+	pipe.ZRem(ctx, LeasesKey, raw)
+	if _, err := pipe.Exec(ctx); err != nil {
+		// The lease check will redeliver it; the processor ignores already-processed transactions
+		log.Printf("Failed to acknowledge message: %v", err)
+	}
+}
+
+// fail schedules a retry with exponential backoff, or dead-letters the message
+// once it has used up its attempts
+func (w *Worker) fail(ctx context.Context, raw string, msg TransactionMessage, cause error) {
+	next, dead := nextAttempt(msg, cause, time.Now(), w.cfg.MaxAttempts)
+	data, err := json.Marshal(next)
+	if err != nil {
+		log.Printf("Failed to marshal message for transaction %s: %v", msg.TransactionID, err)
+		return
+	}
+
+	if dead {
+		log.Printf("Transaction %s failed %d times, moving to %s: %v", msg.TransactionID, next.Attempts, DeadQueueName, cause)
+		err = moveScript.Run(ctx, w.client, []string{ProcessingQueueName, LeasesKey, DeadQueueName}, raw, data).Err()
+	} else {
+		delay := retryDelay(next.Attempts)
+		log.Printf("Transaction %s failed (attempt %d/%d), retrying in %s: %v",
+			msg.TransactionID, next.Attempts, w.cfg.MaxAttempts, delay, cause)
+		dueAt := next.FailedAt.Add(delay).UnixMilli()
+		err = retryScript.Run(ctx, w.client, []string{ProcessingQueueName, LeasesKey, DelayedQueueName}, raw, data, dueAt).Err()
+	}
+	if err != nil {
+		// The message stays in the processing list and is redelivered once its lease expires
+		log.Printf("Failed to reschedule transaction %s: %v", msg.TransactionID, err)
+	}
+}
+
+// maintain promotes due retries and redelivers messages whose lease expired
+func (w *Worker) maintain(ctx context.Context) {
+	promoteTicker := time.NewTicker(1 * time.Second)
+	defer promoteTicker.Stop()
+	leaseTicker := time.NewTicker(leaseCheckInterval)
+	defer leaseTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stopCh:
+			return
+		case <-promoteTicker.C:
+			if err := w.promoteDue(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to promote delayed messages: %v", err)
+			}
+		case <-leaseTicker.C:
+			if err := w.recoverExpired(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to recover expired messages: %v", err)
+			}
+		}
+	}
+}
+
+// promoteDue moves retries whose backoff has elapsed back onto the pending queue
+func (w *Worker) promoteDue(ctx context.Context) error {
+	now := time.Now().UnixMilli()
+	return promoteScript.Run(ctx, w.client, []string{DelayedQueueName, QueueName}, now, promoteBatchSize).Err()
+}
+
+// recoverExpired treats messages held past their visibility timeout as failed attempts
+// This covers workers that crashed or hung mid-flight
+func (w *Worker) recoverExpired(ctx context.Context) error {
+	raws, err := w.client.LRange(ctx, ProcessingQueueName, 0, -1).Result()
 	if err != nil {
+		return fmt.Errorf("failed to read processing list: %w", err)
+	}
+
+	now := time.Now()
+	for _, raw := range raws {
+		score, err := w.client.ZScore(ctx, LeasesKey, raw).Result()
 		if err == redis.Nil {
-			return nil // No message available
+			// Worker died between taking the message and recording its lease
+			deadline := now.Add(w.cfg.VisibilityTimeout)
+			w.client.ZAddNX(ctx, LeasesKey, redis.Z{Score: float64(deadline.UnixMilli()), Member: raw})
+			continue
 		}
-		return err
+		if err != nil {
+			return fmt.Errorf("failed to read lease: %w", err)
+		}
+		if int64(score) > now.UnixMilli() {
+			continue
+		}
+
+		var msg TransactionMessage
+		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+			w.ack(ctx, raw)
+			continue
+		}
+		w.fail(ctx, raw, msg, fmt.Errorf("visibility timeout of %s expired", w.cfg.VisibilityTimeout))
 	}
 
-	w.processMessage(ctx, result)
 	return nil
 }
+
+// nextAttempt records a failed attempt on a message
+// Returns true if the message has used up its attempts and should be dead-lettered
+func nextAttempt(msg TransactionMessage, cause error, now time.Time, maxAttempts int) (TransactionMessage, bool) {
+	msg.Attempts++
+	msg.LastError = cause.Error()
+	msg.FailedAt = &now
+	return msg, msg.Attempts >= maxAttempts
+}
+
+// retryDelay returns the exponential backoff delay after the given number of failed attempts
+func retryDelay(attempts int) time.Duration {
+	return backoff(attempts, retryBaseDelay, retryMaxDelay)
+}
@@ -0,0 +1,59 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNextAttempt(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	msg := TransactionMessage{TransactionID: uuid.New(), Type: "transfer"}
+
+	next, dead := nextAttempt(msg, errors.New("connection refused"), now, 3)
+	if dead {
+		t.Fatal("first failure should not dead-letter")
+	}
+	if next.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", next.Attempts)
+	}
+	if next.LastError != "connection refused" {
+		t.Errorf("LastError = %q, want %q", next.LastError, "connection refused")
+	}
+	if next.FailedAt == nil || !next.FailedAt.Equal(now) {
+		t.Errorf("FailedAt = %v, want %v", next.FailedAt, now)
+	}
+	if msg.Attempts != 0 {
+		t.Error("nextAttempt should not modify the original message")
+	}
+
+	next, _ = nextAttempt(next, errors.New("timeout"), now, 3)
+	next, dead = nextAttempt(next, errors.New("timeout"), now, 3)
+	if !dead {
+		t.Error("third failure with max 3 attempts should dead-letter")
+	}
+	if next.Attempts != 3 {
+		t.Errorf("Attempts = %d, want 3", next.Attempts)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 2 * time.Second},
+		{attempts: 2, want: 4 * time.Second},
+		{attempts: 5, want: 32 * time.Second},
+		{attempts: 9, want: 512 * time.Second},
+		{attempts: 10, want: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
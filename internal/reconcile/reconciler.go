@@ -0,0 +1,89 @@
+package reconcile
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/repository"
+)
+
+// Reconciler periodically re-derives account balances from the ledger
+// and reports (optionally repairs) any drift in the materialized account_balances table
+type Reconciler struct {
+	repo     *repository.BalanceRepository
+	interval time.Duration
+	repair   bool
+}
+
+// NewReconciler creates a new Reconciler
+// If repair is true, drifted balances are rebuilt from ledger entries
+func NewReconciler(repo *repository.BalanceRepository, interval time.Duration, repair bool) *Reconciler {
+	return &Reconciler{
+		repo:     repo,
+		interval: interval,
+		repair:   repair,
+	}
+}
+
+// Report is the outcome of a single reconciliation run
+type Report struct {
+	RanAt    time.Time
+	Drifts   []model.BalanceDrift
+	Repaired int
+}
+
+// RunOnce compares every materialized balance with its ledger sum
+func (r *Reconciler) RunOnce(ctx context.Context) (*Report, error) {
+	report := &Report{RanAt: time.Now()}
+
+	drifts, err := r.repo.FindDrift(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Drifts = drifts
+
+	for _, drift := range drifts {
+		log.Printf("Balance drift on account %s: stored=%s derived=%s difference=%s",
+			drift.AccountID, drift.Stored, drift.Derived, drift.Difference)
+
+		if !r.repair {
+			continue
+		}
+		if err := r.repo.Rebuild(ctx, drift.AccountID); err != nil {
+			log.Printf("Failed to rebuild balance for account %s: %v", drift.AccountID, err)
+			continue
+		}
+		report.Repaired++
+	}
+
+	return report, nil
+}
+
+// Start runs reconciliation immediately and then on every interval until ctx is cancelled
+func (r *Reconciler) Start(ctx context.Context) {
+	log.Printf("Balance reconciler started (interval: %s, repair: %t)", r.interval, r.repair)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := r.RunOnce(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Balance reconciliation failed: %v", err)
+		} else {
+			log.Printf("Balance reconciliation complete: %d drifted, %d repaired", len(report.Drifts), report.Repaired)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Balance reconciler stopping")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/simonkvalheim/hm9-banking/internal/accountnumber"
 	"github.com/simonkvalheim/hm9-banking/internal/model"
 )
 
@@ -25,24 +26,32 @@ func NewAccountRepository(db *pgxpool.Pool) *AccountRepository {
 
 // Create inserts a new account into the database
 func (r *AccountRepository) Create(ctx context.Context, req model.CreateAccountRequest) (*model.Account, error) {
+	bban, iban, err := r.allocateAccountNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	account := &model.Account{
-		ID:            uuid.New(),
-		AccountNumber: generateAccountNumber(),
-		AccountType:   req.AccountType,
-		Currency:      req.Currency,
-		Status:        model.AccountStatusActive,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		ID:             uuid.New(),
+		AccountNumber:  bban,
+		IBAN:           iban,
+		AccountType:    req.AccountType,
+		Currency:       req.Currency,
+		Status:         model.AccountStatusActive,
+		OverdraftLimit: model.ZeroMoney(req.Currency),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
 	query := `
-		INSERT INTO accounts (id, account_number, account_type, currency, status, customer_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO accounts (id, account_number, iban, account_type, currency, status, customer_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err = r.db.Exec(ctx, query,
 		account.ID,
 		account.AccountNumber,
+		account.IBAN,
 		account.AccountType,
 		account.Currency,
 		account.Status,
@@ -59,25 +68,33 @@ func (r *AccountRepository) Create(ctx context.Context, req model.CreateAccountR
 
 // CreateForCustomer inserts a new account linked to a customer
 func (r *AccountRepository) CreateForCustomer(ctx context.Context, req model.CreateAccountRequest, customerID uuid.UUID) (*model.Account, error) {
+	bban, iban, err := r.allocateAccountNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	account := &model.Account{
-		ID:            uuid.New(),
-		AccountNumber: generateAccountNumber(),
-		AccountType:   req.AccountType,
-		Currency:      req.Currency,
-		Status:        model.AccountStatusActive,
-		CustomerID:    &customerID,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		ID:             uuid.New(),
+		AccountNumber:  bban,
+		IBAN:           iban,
+		AccountType:    req.AccountType,
+		Currency:       req.Currency,
+		Status:         model.AccountStatusActive,
+		CustomerID:     &customerID,
+		OverdraftLimit: model.ZeroMoney(req.Currency),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
 	}
 
 	query := `
-		INSERT INTO accounts (id, account_number, account_type, currency, status, customer_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO accounts (id, account_number, iban, account_type, currency, status, customer_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err = r.db.Exec(ctx, query,
 		account.ID,
 		account.AccountNumber,
+		account.IBAN,
 		account.AccountType,
 		account.Currency,
 		account.Status,
@@ -94,60 +111,55 @@ func (r *AccountRepository) CreateForCustomer(ctx context.Context, req model.Cre
 
 // GetByID retrieves an account by its ID
 func (r *AccountRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Account, error) {
-	query := `
-		SELECT id, account_number, account_type, currency, status, customer_id, created_at, updated_at
-		FROM accounts
-		WHERE id = $1
-	`
+	return scanAccount(r.db.QueryRow(ctx, accountColumns+` FROM accounts WHERE id = $1`, id))
+}
 
-	account := &model.Account{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&account.ID,
-		&account.AccountNumber,
-		&account.AccountType,
-		&account.Currency,
-		&account.Status,
-		&account.CustomerID,
-		&account.CreatedAt,
-		&account.UpdatedAt,
-	)
+// GetByAccountNumber retrieves an account by its account number or IBAN
+// Input is validated first (MOD11 for account numbers, MOD 97-10 for IBANs) and may contain
+// spaces or dots. A number the account had before migration 000014 renumbered it is also found;
+// anything else returns ErrInvalidAccountNumber if it is not a valid Norwegian number.
+func (r *AccountRepository) GetByAccountNumber(ctx context.Context, accountNumber string) (*model.Account, error) {
+	bban, err := accountnumber.Parse(accountNumber)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, model.ErrAccountNotFound
+		account, err := scanAccount(r.db.QueryRow(ctx, accountColumns+` FROM accounts WHERE previous_account_number = $1`,
+			accountnumber.Normalize(accountNumber)))
+		if errors.Is(err, model.ErrAccountNotFound) {
+			return nil, model.ErrInvalidAccountNumber
 		}
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return account, err
 	}
 
-	return account, nil
+	return scanAccount(r.db.QueryRow(ctx, accountColumns+` FROM accounts WHERE account_number = $1`, bban))
 }
 
-// GetByAccountNumber retrieves an account by its account number
-func (r *AccountRepository) GetByAccountNumber(ctx context.Context, accountNumber string) (*model.Account, error) {
+// GetHolder returns the account's number and its owner's masked name
+// System accounts have no owner and return ErrAccountNotFound
+func (r *AccountRepository) GetHolder(ctx context.Context, accountID uuid.UUID) (*model.AccountHolder, error) {
 	query := `
-		SELECT id, account_number, account_type, currency, status, customer_id, created_at, updated_at
-		FROM accounts
-		WHERE account_number = $1
+		SELECT a.account_number, COALESCE(a.iban, ''), a.currency, c.first_name, c.last_name
+		FROM accounts a
+		JOIN customers c ON c.id = a.customer_id
+		WHERE a.id = $1
 	`
 
-	account := &model.Account{}
-	err := r.db.QueryRow(ctx, query, accountNumber).Scan(
-		&account.ID,
-		&account.AccountNumber,
-		&account.AccountType,
-		&account.Currency,
-		&account.Status,
-		&account.CustomerID,
-		&account.CreatedAt,
-		&account.UpdatedAt,
+	holder := &model.AccountHolder{}
+	var firstName, lastName string
+	err := r.db.QueryRow(ctx, query, accountID).Scan(
+		&holder.AccountNumber,
+		&holder.IBAN,
+		&holder.Currency,
+		&firstName,
+		&lastName,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, model.ErrAccountNotFound
 		}
-		return nil, fmt.Errorf("failed to get account by account number: %w", err)
+		return nil, fmt.Errorf("failed to get account holder: %w", err)
 	}
 
-	return account, nil
+	holder.HolderName = model.MaskedHolderName(firstName, lastName)
+	return holder, nil
 }
 
 // List retrieves all accounts (with a limit for safety)
@@ -156,8 +168,7 @@ func (r *AccountRepository) List(ctx context.Context, limit int) ([]model.Accoun
 		limit = 100
 	}
 
-	query := `
-		SELECT id, account_number, account_type, currency, status, customer_id, created_at, updated_at
+	query := accountColumns + `
 		FROM accounts
 		ORDER BY created_at DESC
 		LIMIT $1
@@ -171,21 +182,11 @@ func (r *AccountRepository) List(ctx context.Context, limit int) ([]model.Accoun
 
 	var accounts []model.Account
 	for rows.Next() {
-		var account model.Account
-		err := rows.Scan(
-			&account.ID,
-			&account.AccountNumber,
-			&account.AccountType,
-			&account.Currency,
-			&account.Status,
-			&account.CustomerID,
-			&account.CreatedAt,
-			&account.UpdatedAt,
-		)
+		account, err := scanAccount(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan account: %w", err)
+			return nil, err
 		}
-		accounts = append(accounts, account)
+		accounts = append(accounts, *account)
 	}
 
 	return accounts, nil
@@ -193,8 +194,7 @@ func (r *AccountRepository) List(ctx context.Context, limit int) ([]model.Accoun
 
 // GetByCustomerID retrieves all accounts belonging to a customer
 func (r *AccountRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID) ([]model.Account, error) {
-	query := `
-		SELECT id, account_number, account_type, currency, status, customer_id, created_at, updated_at
+	query := accountColumns + `
 		FROM accounts
 		WHERE customer_id = $1
 		ORDER BY created_at DESC
@@ -208,33 +208,24 @@ func (r *AccountRepository) GetByCustomerID(ctx context.Context, customerID uuid
 
 	var accounts []model.Account
 	for rows.Next() {
-		var account model.Account
-		err := rows.Scan(
-			&account.ID,
-			&account.AccountNumber,
-			&account.AccountType,
-			&account.Currency,
-			&account.Status,
-			&account.CustomerID,
-			&account.CreatedAt,
-			&account.UpdatedAt,
-		)
+		account, err := scanAccount(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan account: %w", err)
+			return nil, err
 		}
-		accounts = append(accounts, account)
+		accounts = append(accounts, *account)
 	}
 
 	return accounts, nil
 }
 
-// GetBalance calculates the current balance for an account from ledger entries
+// GetBalance returns the current balance for an account from the materialized account_balances table
 func (r *AccountRepository) GetBalance(ctx context.Context, id uuid.UUID) (*model.AccountBalance, error) {
 	return r.GetBalanceAtTime(ctx, id, nil)
 }
 
-// GetBalanceAtTime calculates the balance for an account at a specific point in time
-// If asOf is nil, returns the current balance
+// GetBalanceAtTime returns the balance for an account at a specific point in time
+// If asOf is nil, returns the current balance from account_balances;
+// point-in-time queries are always calculated from ledger entries
 func (r *AccountRepository) GetBalanceAtTime(ctx context.Context, id uuid.UUID, asOf *time.Time) (*model.AccountBalance, error) {
 	// First check the account exists and get its currency
 	account, err := r.GetByID(ctx, id)
@@ -248,18 +239,16 @@ func (r *AccountRepository) GetBalanceAtTime(ctx context.Context, id uuid.UUID,
 	if asOf != nil {
 		// Point-in-time balance query
 		query = `
-			SELECT COALESCE(SUM(amount), 0) AS balance
+			SELECT COALESCE(SUM(amount), 0)::text AS balance
 			FROM ledger_entries
 			WHERE account_id = $1
 			  AND created_at <= $2
 		`
 		args = []any{id, *asOf}
 	} else {
-		// Current balance query
+		// Current balance query (accounts without a balance row have never been posted to)
 		query = `
-			SELECT COALESCE(SUM(amount), 0) AS balance
-			FROM ledger_entries
-			WHERE account_id = $1
+			SELECT COALESCE((SELECT balance FROM account_balances WHERE account_id = $1), 0)::text AS balance
 		`
 		args = []any{id}
 	}
@@ -270,6 +259,11 @@ func (r *AccountRepository) GetBalanceAtTime(ctx context.Context, id uuid.UUID,
 		return nil, fmt.Errorf("failed to get balance: %w", err)
 	}
 
+	amount, err := model.ParseMoney(balance, account.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse balance: %w", err)
+	}
+
 	// Set AsOf to now if not specified
 	timestamp := time.Now()
 	if asOf != nil {
@@ -277,20 +271,37 @@ func (r *AccountRepository) GetBalanceAtTime(ctx context.Context, id uuid.UUID,
 	}
 
 	return &model.AccountBalance{
-		AccountID: id,
-		Balance:   balance,
-		Currency:  account.Currency,
-		AsOf:      timestamp,
+		AccountID:        id,
+		Balance:          amount,
+		AvailableBalance: account.AvailableBalance(amount),
+		OverdraftLimit:   account.OverdraftLimit,
+		Currency:         account.Currency,
+		AsOf:             timestamp,
 	}, nil
 }
 
-// generateAccountNumber creates a simple account number
-// In production, this would follow a specific format (e.g., IBAN)
-func generateAccountNumber() string {
-	// Format: NO + 2 check digits + 10 digit account number
-	// This is a simplified version - real IBAN/BBAN has specific check digit algorithms
-	return fmt.Sprintf("NO%02d%010d", 
-		time.Now().UnixNano()%100,
-		time.Now().UnixNano()%10000000000,
-	)
-}
\ No newline at end of file
+// allocateAccountNumber takes the next serial from account_number_seq and returns its BBAN and IBAN
+// The sequence makes numbers unique across concurrent creations; serials without a
+// valid MOD11 check digit are skipped, which leaves gaps in the sequence
+func (r *AccountRepository) allocateAccountNumber(ctx context.Context) (string, string, error) {
+	for {
+		var serial int64
+		if err := r.db.QueryRow(ctx, `SELECT nextval('account_number_seq')`).Scan(&serial); err != nil {
+			return "", "", fmt.Errorf("failed to allocate account number: %w", err)
+		}
+
+		bban, err := accountnumber.FromSerial(serial)
+		if errors.Is(err, accountnumber.ErrNoCheckDigit) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("failed to allocate account number: %w", err)
+		}
+
+		iban, err := accountnumber.IBAN(bban)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to allocate account number: %w", err)
+		}
+		return bban, iban, nil
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// SetOverdraftLimit arranges, changes or removes (zero) an account's overdraft
+// Lowering the limit below the current overdraft is allowed: the balance stays where it is,
+// and outgoing transfers fail until the account is back within its limit
+func (r *AccountRepository) SetOverdraftLimit(ctx context.Context, accountID uuid.UUID, limit model.Money) (*model.Account, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	account, err := lockAccount(ctx, dbTx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.AccountType != model.AccountTypeChecking {
+		return nil, model.ErrOverdraftNotAllowed
+	}
+	if account.Status == model.AccountStatusClosed {
+		return nil, model.ErrAccountClosed
+	}
+	if limit.Currency() != account.Currency {
+		return nil, model.ErrCurrencyMismatch
+	}
+
+	err = dbTx.QueryRow(ctx, `
+		UPDATE accounts
+		SET overdraft_limit = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING updated_at
+	`, limit.String(), accountID).Scan(&account.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set overdraft limit: %w", err)
+	}
+	account.OverdraftLimit = limit
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return account, nil
+}
+
+// ListOverdrawn returns every customer account with a negative balance, most overdrawn first per currency
+func (r *AccountRepository) ListOverdrawn(ctx context.Context) ([]model.OverdrawnAccount, error) {
+	query := `
+		SELECT a.id, a.account_number, a.customer_id, a.account_type, a.status, a.currency,
+		       ab.balance::text, a.overdraft_limit::text
+		FROM account_balances ab
+		JOIN accounts a ON a.id = ab.account_id
+		WHERE ab.balance < 0 AND a.account_type <> $1
+		ORDER BY a.currency, ab.balance, a.id
+	`
+
+	rows, err := r.db.Query(ctx, query, model.AccountTypeEquity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list overdrawn accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var overdrawn []model.OverdrawnAccount
+	for rows.Next() {
+		var o model.OverdrawnAccount
+		var balance, limit string
+		if err := rows.Scan(&o.AccountID, &o.AccountNumber, &o.CustomerID, &o.AccountType, &o.Status, &o.Currency, &balance, &limit); err != nil {
+			return nil, fmt.Errorf("failed to scan overdrawn account: %w", err)
+		}
+
+		if o.Balance, err = model.ParseMoney(balance, o.Currency); err != nil {
+			return nil, fmt.Errorf("failed to parse balance: %w", err)
+		}
+		if o.OverdraftLimit, err = model.ParseMoney(limit, o.Currency); err != nil {
+			return nil, fmt.Errorf("failed to parse overdraft limit: %w", err)
+		}
+		account := model.Account{OverdraftLimit: o.OverdraftLimit}
+		o.OverLimit = account.AvailableBalance(o.Balance).IsNegative()
+		overdrawn = append(overdrawn, o)
+	}
+
+	return overdrawn, rows.Err()
+}
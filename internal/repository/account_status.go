@@ -0,0 +1,391 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/simonkvalheim/hm9-banking/internal/journal"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// Freeze blocks all money movement on an active account
+// Transfers already queued against it fail when processed
+func (r *AccountRepository) Freeze(ctx context.Context, accountID uuid.UUID, change model.StatusChange) (*model.Account, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	account, err := lockAccount(ctx, dbTx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsSystemAccount() {
+		return nil, model.ErrSystemAccountStatus
+	}
+	switch account.Status {
+	case model.AccountStatusFrozen:
+		return nil, model.ErrAccountFrozen
+	case model.AccountStatusClosed:
+		return nil, model.ErrAccountClosed
+	}
+
+	if err := setAccountStatus(ctx, dbTx, account, model.AccountStatusFrozen, change, nil); err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return account, nil
+}
+
+// Unfreeze makes a frozen account active again
+// A customer can only lift a freeze they placed themselves; a freeze placed by an admin
+// returns ErrAccountFrozenByBank unless an admin lifts it
+func (r *AccountRepository) Unfreeze(ctx context.Context, accountID uuid.UUID, change model.StatusChange) (*model.Account, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	account, err := lockAccount(ctx, dbTx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	switch account.Status {
+	case model.AccountStatusClosed:
+		return nil, model.ErrAccountClosed
+	case model.AccountStatusActive:
+		return nil, model.ErrAccountNotFrozen
+	}
+
+	if change.Actor != model.StatusActorAdmin {
+		var frozenBy model.StatusActor
+		err := dbTx.QueryRow(ctx, `
+			SELECT actor
+			FROM account_status_history
+			WHERE account_id = $1 AND to_status = $2
+			ORDER BY created_at DESC
+			LIMIT 1
+		`, accountID, model.AccountStatusFrozen).Scan(&frozenBy)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get freeze history: %w", err)
+		}
+		// A frozen account without history was frozen outside the API; treat it as the bank's freeze
+		if frozenBy != model.StatusActorCustomer {
+			return nil, model.ErrAccountFrozenByBank
+		}
+	}
+
+	if err := setAccountStatus(ctx, dbTx, account, model.AccountStatusActive, change, nil); err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return account, nil
+}
+
+// Close permanently closes an account
+// Interest accrued and not yet paid is paid into the account first, including that of the month it
+// is closed in. The balance must then be zero, or positive and swept to sweepTo, which must be another
+// active account of the same customer and currency. The sweep is booked as a completed transfer in the
+// same database transaction as the status change. Customers cannot close a frozen account.
+// Returns ErrAccountHasPendingTransactions while transfers involving the account are in flight.
+func (r *AccountRepository) Close(ctx context.Context, accountID uuid.UUID, sweepTo *uuid.UUID, change model.StatusChange) (*model.AccountClosure, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	account, target, err := lockClosingAccounts(ctx, dbTx, accountID, sweepTo)
+	if err != nil {
+		return nil, err
+	}
+	if account.IsSystemAccount() {
+		return nil, model.ErrSystemAccountStatus
+	}
+	switch account.Status {
+	case model.AccountStatusClosed:
+		return nil, model.ErrAccountClosed
+	case model.AccountStatusFrozen:
+		if change.Actor != model.StatusActorAdmin {
+			return nil, model.ErrAccountFrozen
+		}
+	}
+
+	var pending bool
+	err = dbTx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM transaction_parties tp
+			JOIN transactions t ON t.id = tp.transaction_id
+			WHERE tp.account_id = $1 AND t.status IN ($2, $3)
+		)
+	`, accountID, model.TransactionStatusPending, model.TransactionStatusProcessing).Scan(&pending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending transactions: %w", err)
+	}
+	if pending {
+		return nil, model.ErrAccountHasPendingTransactions
+	}
+
+	lockIDs := []uuid.UUID{account.ID}
+	if sweepTo != nil {
+		if target == nil || !isValidSweepTarget(account, target) {
+			return nil, model.ErrInvalidSweepAccount
+		}
+		lockIDs = append(lockIDs, target.ID)
+	}
+
+	// Interest accrued and not yet paid, including the month being closed in, is paid before the sweep
+	accruals, err := listUnpaidAccruals(ctx, dbTx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	interest, err := model.UnpaidInterest(accruals, account.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum unpaid interest: %w", err)
+	}
+	if len(interest) > 0 {
+		equityID, err := equityAccountID(ctx, dbTx, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		lockIDs = append(lockIDs, equityID)
+	}
+
+	balances, err := journal.LockBalances(ctx, dbTx, lockIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	closure := &model.AccountClosure{Account: account}
+	now := time.Now()
+	for i := range interest {
+		interest[i].CreatedAt = now
+		paid, err := payInterest(ctx, dbTx, account, &interest[i])
+		if err != nil {
+			return nil, err
+		}
+		if paid {
+			closure.Interest = append(closure.Interest, interest[i])
+		}
+	}
+
+	balance, err := closingBalance(balances[account.ID], closure.Interest)
+	if err != nil {
+		return nil, err
+	}
+	if !balance.IsZero() {
+		if balance.IsNegative() || target == nil {
+			return nil, model.ErrBalanceNotZero
+		}
+		closure.SweepTransaction, err = postClosingSweep(ctx, dbTx, account, target, balance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sweepID *uuid.UUID
+	if closure.SweepTransaction != nil {
+		sweepID = &closure.SweepTransaction.ID
+	}
+	if err := setAccountStatus(ctx, dbTx, account, model.AccountStatusClosed, change, sweepID); err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return closure, nil
+}
+
+// ListStatusHistory returns an account's status changes, newest first
+func (r *AccountRepository) ListStatusHistory(ctx context.Context, accountID uuid.UUID) ([]model.AccountStatusChange, error) {
+	query := `
+		SELECT id, account_id, from_status, to_status, actor, actor_customer_id, COALESCE(reason, ''), sweep_transaction_id, created_at
+		FROM account_status_history
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []model.AccountStatusChange{}
+	for rows.Next() {
+		var c model.AccountStatusChange
+		if err := rows.Scan(
+			&c.ID,
+			&c.AccountID,
+			&c.FromStatus,
+			&c.ToStatus,
+			&c.Actor,
+			&c.ActorCustomerID,
+			&c.Reason,
+			&c.SweepTransactionID,
+			&c.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan status history: %w", err)
+		}
+		history = append(history, c)
+	}
+
+	return history, rows.Err()
+}
+
+// accountColumns selects the columns scanned by scanAccount
+const accountColumns = `SELECT id, account_number, COALESCE(iban, ''), account_type, currency, status, overdraft_limit::text, customer_id, created_at, updated_at`
+
+// lockAccount reads an account and locks its row until the transaction ends
+// Status changes take this lock, so two changes to the same account are serialized
+func lockAccount(ctx context.Context, dbTx pgx.Tx, id uuid.UUID) (*model.Account, error) {
+	return scanAccount(dbTx.QueryRow(ctx, accountColumns+` FROM accounts WHERE id = $1 FOR UPDATE`, id))
+}
+
+// lockClosingAccounts locks a closing account FOR UPDATE and its sweep target, if any, FOR SHARE
+// The rows are locked in journal.LockOrder, the order a reversal locks accounts in, so closing an
+// account cannot deadlock with a reversal touching both. FOR SHARE keeps the target from being
+// closed or frozen until the sweep commits. A sweep target that does not exist is returned as nil.
+func lockClosingAccounts(ctx context.Context, dbTx pgx.Tx, accountID uuid.UUID, sweepTo *uuid.UUID) (*model.Account, *model.Account, error) {
+	ids := []uuid.UUID{accountID}
+	if sweepTo != nil {
+		ids = append(ids, *sweepTo)
+	}
+
+	var account, target *model.Account
+	for _, id := range journal.LockOrder(ids) {
+		if id == accountID {
+			var err error
+			if account, err = lockAccount(ctx, dbTx, id); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		var err error
+		target, err = scanAccount(dbTx.QueryRow(ctx, accountColumns+` FROM accounts WHERE id = $1 FOR SHARE`, id))
+		if err != nil && !errors.Is(err, model.ErrAccountNotFound) {
+			return nil, nil, err
+		}
+	}
+	return account, target, nil
+}
+
+// scanAccount scans a row selected with accountColumns
+func scanAccount(row pgx.Row) (*model.Account, error) {
+	account := &model.Account{}
+	var overdraftLimit string
+	err := row.Scan(
+		&account.ID,
+		&account.AccountNumber,
+		&account.IBAN,
+		&account.AccountType,
+		&account.Currency,
+		&account.Status,
+		&overdraftLimit,
+		&account.CustomerID,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, model.ErrAccountNotFound
+		}
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if account.OverdraftLimit, err = model.ParseMoney(overdraftLimit, account.Currency); err != nil {
+		return nil, fmt.Errorf("failed to parse overdraft limit: %w", err)
+	}
+	return account, nil
+}
+
+// setAccountStatus updates the account's status and records the change in its history
+func setAccountStatus(ctx context.Context, dbTx pgx.Tx, account *model.Account, to model.AccountStatus, change model.StatusChange, sweepID *uuid.UUID) error {
+	now := time.Now()
+
+	_, err := dbTx.Exec(ctx, `
+		UPDATE accounts SET status = $1, updated_at = $2 WHERE id = $3
+	`, to, now, account.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update account status: %w", err)
+	}
+
+	var reason *string
+	if change.Reason != "" {
+		reason = &change.Reason
+	}
+	_, err = dbTx.Exec(ctx, `
+		INSERT INTO account_status_history (id, account_id, from_status, to_status, actor, actor_customer_id, reason, sweep_transaction_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, uuid.New(), account.ID, account.Status, to, change.Actor, change.CustomerID, reason, sweepID, now)
+	if err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+
+	account.Status = to
+	account.UpdatedAt = now
+	return nil
+}
+
+// postClosingSweep books the remaining balance of a closing account as a completed transfer
+// The idempotency key is derived from the account, so an account can only ever be swept once
+func postClosingSweep(ctx context.Context, dbTx pgx.Tx, from, to *model.Account, amount model.Money) (*model.Transaction, error) {
+	now := time.Now()
+	tx := &model.Transaction{
+		ID:             uuid.New(),
+		IdempotencyKey: "account-closure:" + from.ID.String(),
+		Type:           model.TransactionTypeTransfer,
+		Status:         model.TransactionStatusCompleted,
+		Reference:      "Closing balance of " + from.AccountNumber,
+		InitiatedAt:    now,
+		ProcessedAt:    &now,
+		CompletedAt:    &now,
+		Metadata:       map[string]any{"account_closure": true},
+		Amount:         amount.String(),
+		Currency:       amount.Currency(),
+		FromAccountID:  &from.ID,
+		ToAccountID:    &to.ID,
+	}
+
+	if err := bookTransaction(ctx, dbTx, tx, model.TransferLegs(from.ID, to.ID, amount)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// closingBalance returns the balance a closing account is left with once its unpaid interest is paid
+func closingBalance(balance model.Money, interest []model.InterestCapitalization) (model.Money, error) {
+	for _, c := range interest {
+		var err error
+		if balance, err = balance.Add(c.Amount); err != nil {
+			return model.Money{}, fmt.Errorf("failed to add interest to closing balance: %w", err)
+		}
+	}
+	return balance, nil
+}
+
+// isValidSweepTarget reports whether a closing account's balance may be swept to target
+func isValidSweepTarget(account, target *model.Account) bool {
+	return target.ID != account.ID &&
+		!target.IsSystemAccount() &&
+		target.Status == model.AccountStatusActive &&
+		target.Currency == account.Currency &&
+		account.CustomerID != nil && target.CustomerID != nil &&
+		*target.CustomerID == *account.CustomerID
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func TestIsValidSweepTarget(t *testing.T) {
+	customerID := uuid.New()
+	otherCustomerID := uuid.New()
+
+	closing := &model.Account{
+		ID:          uuid.New(),
+		AccountType: model.AccountTypeChecking,
+		Currency:    "NOK",
+		Status:      model.AccountStatusActive,
+		CustomerID:  &customerID,
+	}
+	target := func(modify func(a *model.Account)) *model.Account {
+		a := &model.Account{
+			ID:          uuid.New(),
+			AccountType: model.AccountTypeSavings,
+			Currency:    "NOK",
+			Status:      model.AccountStatusActive,
+			CustomerID:  &customerID,
+		}
+		if modify != nil {
+			modify(a)
+		}
+		return a
+	}
+
+	tests := []struct {
+		name   string
+		target *model.Account
+		want   bool
+	}{
+		{"same customer and currency", target(nil), true},
+		{"same account", closing, false},
+		{"other currency", target(func(a *model.Account) { a.Currency = "EUR" }), false},
+		{"frozen", target(func(a *model.Account) { a.Status = model.AccountStatusFrozen }), false},
+		{"closed", target(func(a *model.Account) { a.Status = model.AccountStatusClosed }), false},
+		{"other customer", target(func(a *model.Account) { a.CustomerID = &otherCustomerID }), false},
+		{"no customer", target(func(a *model.Account) { a.CustomerID = nil }), false},
+		{"system account", target(func(a *model.Account) { a.AccountType = model.AccountTypeEquity }), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isValidSweepTarget(closing, tt.target); got != tt.want {
+				t.Errorf("isValidSweepTarget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosingBalance_PaysUnpaidInterest(t *testing.T) {
+	// A savings account of 10000.00 NOK at 3 % closed on 16 October: September was accrued but not yet
+	// paid, and 1-15 October is accrued for the month it is closed in
+	accountID := uuid.New()
+	var accruals []model.InterestAccrual
+	for day := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC); day.Before(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)); day = day.AddDate(0, 0, 1) {
+		accruals = append(accruals, model.InterestAccrual{AccountID: accountID, AccrualDate: day, Amount: "0.8219178082"})
+	}
+
+	interest, err := model.UnpaidInterest(accruals, "NOK")
+	if err != nil {
+		t.Fatalf("UnpaidInterest() error = %v", err)
+	}
+	if len(interest) != 2 || interest[0].Amount.String() != "24.66" || interest[1].Amount.String() != "12.33" {
+		t.Fatalf("UnpaidInterest() = %+v, want 24.66 for September and 12.33 for October", interest)
+	}
+
+	sweep, err := closingBalance(mustMoney(t, "10000.00"), interest)
+	if err != nil {
+		t.Fatalf("closingBalance() error = %v", err)
+	}
+	if sweep.String() != "10036.99" {
+		t.Errorf("closingBalance() = %s, want 10036.99", sweep)
+	}
+
+	// An emptied account that is still owed interest has a balance to sweep
+	sweep, err = closingBalance(mustMoney(t, "0.00"), interest[1:])
+	if err != nil {
+		t.Fatalf("closingBalance() error = %v", err)
+	}
+	if sweep.String() != "12.33" {
+		t.Errorf("closingBalance(no balance) = %s, want 12.33", sweep)
+	}
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// BalanceRepository handles the materialized account_balances table
+// ledger_entries stays the source of truth; this repository re-derives and compares
+type BalanceRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewBalanceRepository creates a new BalanceRepository
+func NewBalanceRepository(db *pgxpool.Pool) *BalanceRepository {
+	return &BalanceRepository{db: db}
+}
+
+// FindDrift returns every account whose materialized balance differs from its ledger sum
+// Runs as a single statement, so both sides are read from the same snapshot
+func (r *BalanceRepository) FindDrift(ctx context.Context) ([]model.BalanceDrift, error) {
+	query := `
+		SELECT a.id, a.currency,
+		       COALESCE(ab.balance, 0)::text AS stored,
+		       COALESCE(l.total, 0)::text AS derived
+		FROM accounts a
+		LEFT JOIN account_balances ab ON ab.account_id = a.id
+		LEFT JOIN (
+			SELECT account_id, SUM(amount) AS total
+			FROM ledger_entries
+			GROUP BY account_id
+		) l ON l.account_id = a.id
+		WHERE COALESCE(ab.balance, 0) <> COALESCE(l.total, 0)
+		ORDER BY a.id
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find balance drift: %w", err)
+	}
+	defer rows.Close()
+
+	var drifts []model.BalanceDrift
+	for rows.Next() {
+		var accountID uuid.UUID
+		var currency, stored, derived string
+		if err := rows.Scan(&accountID, &currency, &stored, &derived); err != nil {
+			return nil, fmt.Errorf("failed to scan balance drift: %w", err)
+		}
+
+		drift, err := newBalanceDrift(accountID, currency, stored, derived)
+		if err != nil {
+			return nil, err
+		}
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, rows.Err()
+}
+
+// Rebuild re-derives an account's materialized balance from its ledger entries
+// The balance row is locked so the rebuild cannot interleave with the processor
+func (r *BalanceRepository) Rebuild(ctx context.Context, accountID uuid.UUID) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	_, err = dbTx.Exec(ctx, `
+		INSERT INTO account_balances (account_id) VALUES ($1)
+		ON CONFLICT (account_id) DO NOTHING
+	`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to ensure balance row: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, `SELECT 1 FROM account_balances WHERE account_id = $1 FOR UPDATE`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to lock balance row: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, `
+		UPDATE account_balances
+		SET balance = (SELECT COALESCE(SUM(amount), 0) FROM ledger_entries WHERE account_id = $1),
+		    version = version + 1,
+		    last_entry_id = (
+		        SELECT id FROM ledger_entries
+		        WHERE account_id = $1
+		        ORDER BY created_at DESC, id DESC
+		        LIMIT 1
+		    ),
+		    updated_at = NOW()
+		WHERE account_id = $1
+	`, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild balance: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit balance rebuild: %w", err)
+	}
+
+	return nil
+}
+
+// newBalanceDrift parses stored and derived balances and computes their difference
+func newBalanceDrift(accountID uuid.UUID, currency, stored, derived string) (model.BalanceDrift, error) {
+	storedMoney, err := model.ParseMoney(stored, currency)
+	if err != nil {
+		return model.BalanceDrift{}, fmt.Errorf("failed to parse stored balance: %w", err)
+	}
+	derivedMoney, err := model.ParseMoney(derived, currency)
+	if err != nil {
+		return model.BalanceDrift{}, fmt.Errorf("failed to parse derived balance: %w", err)
+	}
+	difference, err := storedMoney.Sub(derivedMoney)
+	if err != nil {
+		return model.BalanceDrift{}, err
+	}
+
+	return model.BalanceDrift{
+		AccountID:  accountID,
+		Stored:     storedMoney,
+		Derived:    derivedMoney,
+		Difference: difference,
+	}, nil
+}
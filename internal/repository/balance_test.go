@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewBalanceDrift(t *testing.T) {
+	accountID := uuid.New()
+
+	drift, err := newBalanceDrift(accountID, "NOK", "150.0000", "100.2500")
+	if err != nil {
+		t.Fatalf("newBalanceDrift() error = %v", err)
+	}
+
+	if drift.AccountID != accountID {
+		t.Errorf("AccountID = %v, want %v", drift.AccountID, accountID)
+	}
+	if drift.Stored.String() != "150.00" {
+		t.Errorf("Stored = %v, want 150.00", drift.Stored)
+	}
+	if drift.Derived.String() != "100.25" {
+		t.Errorf("Derived = %v, want 100.25", drift.Derived)
+	}
+	if drift.Difference.String() != "49.75" {
+		t.Errorf("Difference = %v, want 49.75", drift.Difference)
+	}
+}
+
+func TestNewBalanceDrift_InvalidAmount(t *testing.T) {
+	if _, err := newBalanceDrift(uuid.New(), "NOK", "1.001", "0"); err == nil {
+		t.Error("newBalanceDrift() error = nil, want error for amount beyond currency scale")
+	}
+}
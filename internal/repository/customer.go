@@ -13,26 +13,38 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/pii"
 )
 
 // CustomerRepository handles database operations for customers
+// The national ID number and date of birth are encrypted and decrypted transparently
 type CustomerRepository struct {
-	db *pgxpool.Pool
+	db     *pgxpool.Pool
+	cipher *pii.Cipher
 }
 
 // NewCustomerRepository creates a new CustomerRepository
-func NewCustomerRepository(db *pgxpool.Pool) *CustomerRepository {
-	return &CustomerRepository{db: db}
+func NewCustomerRepository(db *pgxpool.Pool, cipher *pii.Cipher) *CustomerRepository {
+	return &CustomerRepository{db: db, cipher: cipher}
 }
 
 // Create inserts a new customer into the database
 func (r *CustomerRepository) Create(ctx context.Context, customer *model.Customer) (*model.Customer, error) {
+	encryptedDOB, err := r.encryptDateOfBirth(customer.ID, customer.DateOfBirth)
+	if err != nil {
+		return nil, err
+	}
+	encryptedNationalID, nationalIDIndex, err := r.encryptNationalID(customer.ID, customer.NationalIDNumber)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		INSERT INTO customers (
 			id, email, password_hash, first_name, last_name,
 			phone, phone_verified, email_verified,
 			address_line1, address_line2, city, postal_code, country,
-			date_of_birth, national_id_number,
+			date_of_birth_encrypted, national_id_number, national_id_index,
 			status, failed_login_attempts, locked_until, last_login_at, password_changed_at,
 			preferred_language, timezone,
 			created_at, updated_at
@@ -41,14 +53,14 @@ func (r *CustomerRepository) Create(ctx context.Context, customer *model.Custome
 			$1, $2, $3, $4, $5,
 			$6, $7, $8,
 			$9, $10, $11, $12, $13,
-			$14, $15,
-			$16, $17, $18, $19, $20,
-			$21, $22,
-			$23, $24
+			$14, $15, $16,
+			$17, $18, $19, $20, $21,
+			$22, $23,
+			$24, $25
 		)
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	_, err = r.db.Exec(ctx, query,
 		customer.ID,
 		customer.Email,
 		customer.PasswordHash,
@@ -62,8 +74,9 @@ func (r *CustomerRepository) Create(ctx context.Context, customer *model.Custome
 		customer.City,
 		customer.PostalCode,
 		customer.Country,
-		customer.DateOfBirth,
-		customer.NationalIDNumber,
+		encryptedDOB,
+		encryptedNationalID,
+		nationalIDIndex,
 		customer.Status,
 		customer.FailedLoginAttempts,
 		customer.LockedUntil,
@@ -95,8 +108,9 @@ func (r *CustomerRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.
 			id, email, password_hash, first_name, last_name,
 			phone, phone_verified, email_verified,
 			address_line1, address_line2, city, postal_code, country,
-			date_of_birth, national_id_number,
+			date_of_birth, date_of_birth_encrypted, national_id_number,
 			status, failed_login_attempts, locked_until, last_login_at, password_changed_at,
+			totp_enabled,
 			preferred_language, timezone,
 			created_at, updated_at
 		FROM customers
@@ -104,6 +118,7 @@ func (r *CustomerRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.
 	`
 
 	customer := &model.Customer{}
+	var encryptedDOB *string
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&customer.ID,
 		&customer.Email,
@@ -119,12 +134,14 @@ func (r *CustomerRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.
 		&customer.PostalCode,
 		&customer.Country,
 		&customer.DateOfBirth,
+		&encryptedDOB,
 		&customer.NationalIDNumber,
 		&customer.Status,
 		&customer.FailedLoginAttempts,
 		&customer.LockedUntil,
 		&customer.LastLoginAt,
 		&customer.PasswordChangedAt,
+		&customer.TOTPEnabled,
 		&customer.PreferredLanguage,
 		&customer.Timezone,
 		&customer.CreatedAt,
@@ -137,6 +154,9 @@ func (r *CustomerRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.
 		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
 
+	if err := r.decryptPII(customer, encryptedDOB); err != nil {
+		return nil, err
+	}
 	return customer, nil
 }
 
@@ -147,8 +167,9 @@ func (r *CustomerRepository) GetByEmail(ctx context.Context, email string) (*mod
 			id, email, password_hash, first_name, last_name,
 			phone, phone_verified, email_verified,
 			address_line1, address_line2, city, postal_code, country,
-			date_of_birth, national_id_number,
+			date_of_birth, date_of_birth_encrypted, national_id_number,
 			status, failed_login_attempts, locked_until, last_login_at, password_changed_at,
+			totp_enabled,
 			preferred_language, timezone,
 			created_at, updated_at
 		FROM customers
@@ -156,6 +177,7 @@ func (r *CustomerRepository) GetByEmail(ctx context.Context, email string) (*mod
 	`
 
 	customer := &model.Customer{}
+	var encryptedDOB *string
 	err := r.db.QueryRow(ctx, query, email).Scan(
 		&customer.ID,
 		&customer.Email,
@@ -171,12 +193,14 @@ func (r *CustomerRepository) GetByEmail(ctx context.Context, email string) (*mod
 		&customer.PostalCode,
 		&customer.Country,
 		&customer.DateOfBirth,
+		&encryptedDOB,
 		&customer.NationalIDNumber,
 		&customer.Status,
 		&customer.FailedLoginAttempts,
 		&customer.LockedUntil,
 		&customer.LastLoginAt,
 		&customer.PasswordChangedAt,
+		&customer.TOTPEnabled,
 		&customer.PreferredLanguage,
 		&customer.Timezone,
 		&customer.CreatedAt,
@@ -189,6 +213,9 @@ func (r *CustomerRepository) GetByEmail(ctx context.Context, email string) (*mod
 		return nil, fmt.Errorf("failed to get customer by email: %w", err)
 	}
 
+	if err := r.decryptPII(customer, encryptedDOB); err != nil {
+		return nil, err
+	}
 	return customer, nil
 }
 
@@ -295,3 +322,248 @@ func (r *CustomerRepository) LockAccount(ctx context.Context, id uuid.UUID, unti
 
 	return nil
 }
+
+// GetTOTP retrieves a customer's two-factor authentication state
+func (r *CustomerRepository) GetTOTP(ctx context.Context, id uuid.UUID) (*model.TOTPState, error) {
+	query := `
+		SELECT COALESCE(totp_secret, ''), totp_enabled, totp_last_step, COALESCE(array_length(totp_recovery_codes, 1), 0)
+		FROM customers
+		WHERE id = $1
+	`
+
+	state := &model.TOTPState{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&state.Secret,
+		&state.Enabled,
+		&state.LastStep,
+		&state.RecoveryCodesRemaining,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, model.ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to get two-factor state: %w", err)
+	}
+
+	return state, nil
+}
+
+// SetPendingTOTP stores a new TOTP secret that is not active until confirmed
+// Returns ErrMFAAlreadyEnabled if the customer already has two-factor enabled
+func (r *CustomerRepository) SetPendingTOTP(ctx context.Context, id uuid.UUID, secret string) error {
+	query := `
+		UPDATE customers
+		SET totp_secret = $1, totp_last_step = NULL, updated_at = NOW()
+		WHERE id = $2 AND NOT totp_enabled
+	`
+
+	result, err := r.db.Exec(ctx, query, secret, id)
+	if err != nil {
+		return fmt.Errorf("failed to store two-factor secret: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return model.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// EnableTOTP activates the pending secret and replaces the recovery codes
+// step is the time step of the code that confirmed enrollment, so it cannot be reused
+func (r *CustomerRepository) EnableTOTP(ctx context.Context, id uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	query := `
+		UPDATE customers
+		SET totp_enabled = TRUE, totp_enabled_at = NOW(), totp_last_step = $1,
+			totp_recovery_codes = $2, updated_at = NOW()
+		WHERE id = $3 AND totp_secret IS NOT NULL AND NOT totp_enabled
+	`
+
+	result, err := r.db.Exec(ctx, query, step, recoveryCodeHashes, id)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return model.ErrMFAAlreadyEnabled
+	}
+
+	return nil
+}
+
+// DisableTOTP removes the secret and recovery codes
+func (r *CustomerRepository) DisableTOTP(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE customers
+		SET totp_secret = NULL, totp_enabled = FALSE, totp_enabled_at = NULL, totp_last_step = NULL,
+			totp_recovery_codes = '{}', updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return model.ErrCustomerNotFound
+	}
+
+	return nil
+}
+
+// RecordTOTPStep accepts a TOTP time step if it is newer than the last one used
+// Returns false if the step (or a later one) was already used, i.e. the code is a replay
+func (r *CustomerRepository) RecordTOTPStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	query := `
+		UPDATE customers
+		SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+	`
+
+	result, err := r.db.Exec(ctx, query, step, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode consumes a recovery code by its hash
+// Returns false if the code is not one of the customer's remaining codes
+func (r *CustomerRepository) UseRecoveryCode(ctx context.Context, id uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE customers
+		SET totp_recovery_codes = array_remove(totp_recovery_codes, $1), updated_at = NOW()
+		WHERE id = $2 AND totp_enabled AND $1 = ANY(totp_recovery_codes)
+	`
+
+	result, err := r.db.Exec(ctx, query, codeHash, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// UpdateProfile applies the non-nil fields of a profile update and returns the updated customer
+// Empty strings clear optional fields. Changing the phone number marks it unverified.
+// The request must already be normalized and validated.
+func (r *CustomerRepository) UpdateProfile(ctx context.Context, id uuid.UUID, req model.UpdateProfileRequest) (*model.Customer, error) {
+	if req.IsEmpty() {
+		return r.GetByID(ctx, id)
+	}
+
+	var sets []string
+	args := []any{id}
+	set := func(column string, value any) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	// nullable maps "" to NULL for optional columns
+	nullable := func(value *string) any {
+		if *value == "" {
+			return nil
+		}
+		return *value
+	}
+
+	if req.FirstName != nil {
+		set("first_name", *req.FirstName)
+	}
+	if req.LastName != nil {
+		set("last_name", *req.LastName)
+	}
+	if req.Phone != nil {
+		// SET expressions see the old row, so phone here is the previous number
+		args = append(args, nullable(req.Phone))
+		n := len(args)
+		sets = append(sets,
+			fmt.Sprintf("phone_verified = CASE WHEN phone IS DISTINCT FROM $%d THEN FALSE ELSE phone_verified END", n),
+			fmt.Sprintf("phone = $%d", n),
+		)
+	}
+	if req.AddressLine1 != nil {
+		set("address_line1", nullable(req.AddressLine1))
+	}
+	if req.AddressLine2 != nil {
+		set("address_line2", nullable(req.AddressLine2))
+	}
+	if req.City != nil {
+		set("city", nullable(req.City))
+	}
+	if req.PostalCode != nil {
+		set("postal_code", nullable(req.PostalCode))
+	}
+	if req.Country != nil {
+		set("country", nullable(req.Country))
+	}
+	if req.DateOfBirth != nil {
+		var dob *time.Time
+		if *req.DateOfBirth != "" {
+			parsed, err := time.Parse(time.DateOnly, *req.DateOfBirth)
+			if err != nil {
+				return nil, model.ErrInvalidDateOfBirth
+			}
+			dob = &parsed
+		}
+		encrypted, err := r.encryptDateOfBirth(id, dob)
+		if err != nil {
+			return nil, err
+		}
+		set("date_of_birth_encrypted", encrypted)
+		sets = append(sets, "date_of_birth = NULL") // Drop any legacy plaintext value
+	}
+	if req.PreferredLanguage != nil {
+		set("preferred_language", *req.PreferredLanguage)
+	}
+	if req.Timezone != nil {
+		set("timezone", *req.Timezone)
+	}
+	sets = append(sets, "updated_at = NOW()")
+
+	query := `UPDATE customers SET ` + strings.Join(sets, ", ") + ` WHERE id = $1`
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, model.ErrCustomerNotFound
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// ChangePassword sets a new password hash and revokes every session of the customer
+// Tokens issued before the change stop working: access tokens are rejected with their
+// session, and refresh tokens are revoked with it.
+func (r *CustomerRepository) ChangePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	result, err := dbTx.Exec(ctx, `
+		UPDATE customers
+		SET password_hash = $1, password_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $2
+	`, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrCustomerNotFound
+	}
+
+	if _, err := revokeSessions(ctx, dbTx, `customer_id = $2`, id); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/pii"
+)
+
+// Encryption contexts bind each ciphertext to its column; the customer ID is appended
+const (
+	nationalIDContext  = "customers.national_id_number"
+	dateOfBirthContext = "customers.date_of_birth"
+)
+
+// PIIStatus counts stored PII values by the key that encrypts them
+type PIIStatus struct {
+	ByKey     map[string]int64 // Encrypted values per key ID
+	Plaintext int64            // Values written before encryption, not yet re-encrypted
+}
+
+// ReencryptResult summarizes one batch of ReencryptPII
+type ReencryptResult struct {
+	Scanned   int
+	Updated   int
+	Conflicts int       // Rows changed while being processed; the next run picks them up
+	LastID    uuid.UUID // Pass as afterID to continue with the next batch
+}
+
+// GetByNationalID looks up a customer by national ID number using its blind index
+// Spaces and dashes are ignored, so "010190 12345" finds "01019012345"
+func (r *CustomerRepository) GetByNationalID(ctx context.Context, nationalID string) (*model.Customer, error) {
+	index := r.cipher.BlindIndex(normalizeNationalID(nationalID), nationalIDContext)
+
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM customers WHERE national_id_index = $1`, index).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, model.ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to get customer by national ID: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// GetPIIStatus reports how many values each key encrypts and how many are still plaintext
+func (r *CustomerRepository) GetPIIStatus(ctx context.Context) (*PIIStatus, error) {
+	query := `
+		SELECT key_id, COUNT(*) FROM (
+			SELECT CASE WHEN national_id_number LIKE 'v1:%' THEN split_part(national_id_number, ':', 2) ELSE '' END AS key_id
+			FROM customers WHERE national_id_number IS NOT NULL
+			UNION ALL
+			SELECT split_part(date_of_birth_encrypted, ':', 2)
+			FROM customers WHERE date_of_birth_encrypted IS NOT NULL
+			UNION ALL
+			SELECT '' FROM customers WHERE date_of_birth IS NOT NULL
+		) v
+		GROUP BY key_id
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PII status: %w", err)
+	}
+	defer rows.Close()
+
+	status := &PIIStatus{ByKey: make(map[string]int64)}
+	for rows.Next() {
+		var keyID string
+		var count int64
+		if err := rows.Scan(&keyID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan PII status: %w", err)
+		}
+		if keyID == "" {
+			status.Plaintext += count
+		} else {
+			status.ByKey[keyID] = count
+		}
+	}
+
+	return status, rows.Err()
+}
+
+// ReencryptPII brings up to limit customers after afterID (in ID order) under the active key
+// Legacy plaintext is encrypted and indexed, and values under older keys are re-wrapped.
+// Each row is updated only if it did not change since it was read.
+func (r *CustomerRepository) ReencryptPII(ctx context.Context, afterID uuid.UUID, limit int) (*ReencryptResult, error) {
+	type piiRow struct {
+		id           uuid.UUID
+		dob          *time.Time
+		encryptedDOB *string
+		nationalID   *string
+		index        *string
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, date_of_birth, date_of_birth_encrypted, national_id_number, national_id_index
+		FROM customers
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+	batch, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (piiRow, error) {
+		var p piiRow
+		err := row.Scan(&p.id, &p.dob, &p.encryptedDOB, &p.nationalID, &p.index)
+		return p, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+
+	result := &ReencryptResult{Scanned: len(batch), LastID: afterID}
+	for _, row := range batch {
+		result.LastID = row.id
+
+		encryptedDOB, err := r.reencryptDateOfBirth(row.id, row.dob, row.encryptedDOB)
+		if err != nil {
+			return nil, fmt.Errorf("customer %s: %w", row.id, err)
+		}
+		nationalID, index, err := r.reencryptNationalID(row.id, row.nationalID, row.index)
+		if err != nil {
+			return nil, fmt.Errorf("customer %s: %w", row.id, err)
+		}
+
+		if row.dob == nil && equalPtr(encryptedDOB, row.encryptedDOB) &&
+			equalPtr(nationalID, row.nationalID) && equalPtr(index, row.index) {
+			continue
+		}
+
+		tag, err := r.db.Exec(ctx, `
+			UPDATE customers
+			SET date_of_birth = NULL, date_of_birth_encrypted = $2, national_id_number = $3, national_id_index = $4
+			WHERE id = $1
+				AND date_of_birth IS NOT DISTINCT FROM $5
+				AND date_of_birth_encrypted IS NOT DISTINCT FROM $6
+				AND national_id_number IS NOT DISTINCT FROM $7
+		`, row.id, encryptedDOB, nationalID, index, row.dob, row.encryptedDOB, row.nationalID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-encrypt customer %s: %w", row.id, err)
+		}
+		if tag.RowsAffected() == 0 {
+			result.Conflicts++
+			continue
+		}
+		result.Updated++
+	}
+
+	return result, nil
+}
+
+// decryptPII replaces stored ciphertexts on a scanned customer with their plaintext
+// Legacy rows written before encryption are returned as they are
+func (r *CustomerRepository) decryptPII(customer *model.Customer, encryptedDOB *string) error {
+	if encryptedDOB != nil {
+		plain, err := r.cipher.Decrypt(*encryptedDOB, piiContext(dateOfBirthContext, customer.ID))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt date of birth: %w", err)
+		}
+		dob, err := time.Parse(time.DateOnly, plain)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt date of birth: %w", err)
+		}
+		customer.DateOfBirth = &dob
+	}
+
+	if customer.NationalIDNumber != nil && pii.IsEncrypted(*customer.NationalIDNumber) {
+		plain, err := r.cipher.Decrypt(*customer.NationalIDNumber, piiContext(nationalIDContext, customer.ID))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt national ID: %w", err)
+		}
+		customer.NationalIDNumber = &plain
+	}
+
+	return nil
+}
+
+// encryptDateOfBirth returns the ciphertext for a date of birth, or nil if there is none
+func (r *CustomerRepository) encryptDateOfBirth(customerID uuid.UUID, dob *time.Time) (*string, error) {
+	if dob == nil {
+		return nil, nil
+	}
+	encrypted, err := r.cipher.Encrypt(dob.Format(time.DateOnly), piiContext(dateOfBirthContext, customerID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt date of birth: %w", err)
+	}
+	return &encrypted, nil
+}
+
+// encryptNationalID returns the ciphertext and blind index for a national ID, or nils if there is none
+func (r *CustomerRepository) encryptNationalID(customerID uuid.UUID, nationalID *string) (*string, *string, error) {
+	if nationalID == nil {
+		return nil, nil, nil
+	}
+	encrypted, err := r.cipher.Encrypt(*nationalID, piiContext(nationalIDContext, customerID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt national ID: %w", err)
+	}
+	index := r.cipher.BlindIndex(normalizeNationalID(*nationalID), nationalIDContext)
+	return &encrypted, &index, nil
+}
+
+// reencryptDateOfBirth returns the date of birth ciphertext under the active key
+func (r *CustomerRepository) reencryptDateOfBirth(customerID uuid.UUID, dob *time.Time, encrypted *string) (*string, error) {
+	if encrypted == nil {
+		return r.encryptDateOfBirth(customerID, dob)
+	}
+	rewrapped, err := r.cipher.Rewrap(*encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-wrap date of birth: %w", err)
+	}
+	return &rewrapped, nil
+}
+
+// reencryptNationalID returns the national ID ciphertext under the active key, and its blind index
+func (r *CustomerRepository) reencryptNationalID(customerID uuid.UUID, stored, index *string) (*string, *string, error) {
+	if stored == nil {
+		return nil, nil, nil
+	}
+	if !pii.IsEncrypted(*stored) {
+		return r.encryptNationalID(customerID, stored)
+	}
+
+	rewrapped, err := r.cipher.Rewrap(*stored)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to re-wrap national ID: %w", err)
+	}
+	if index == nil {
+		plain, err := r.cipher.Decrypt(*stored, piiContext(nationalIDContext, customerID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decrypt national ID: %w", err)
+		}
+		computed := r.cipher.BlindIndex(normalizeNationalID(plain), nationalIDContext)
+		index = &computed
+	}
+	return &rewrapped, index, nil
+}
+
+// piiContext binds a ciphertext to a column and customer
+func piiContext(column string, customerID uuid.UUID) string {
+	return column + ":" + customerID.String()
+}
+
+// normalizeNationalID removes spaces, dashes and other separators before indexing
+func normalizeNationalID(s string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s))
+}
+
+// equalPtr reports whether two optional strings are both nil or equal
+func equalPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
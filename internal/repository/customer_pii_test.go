@@ -0,0 +1,19 @@
+package repository
+
+import "testing"
+
+func TestNormalizeNationalID(t *testing.T) {
+	tests := map[string]string{
+		"01019012345":   "01019012345",
+		"010190 12345":  "01019012345",
+		"010190-12345":  "01019012345",
+		" 010190 12345": "01019012345",
+		"ab-12.34":      "AB1234",
+	}
+
+	for in, want := range tests {
+		if got := normalizeNationalID(in); got != want {
+			t.Errorf("normalizeNationalID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// rowQuerier runs a single-row query; both *pgxpool.Pool and pgx.Tx satisfy it
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// FeeRepository handles the fee schedules and works out the fee on a transfer
+// The fee is fixed when a transfer is created and booked with it by the processor
+type FeeRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewFeeRepository creates a new FeeRepository
+func NewFeeRepository(db *pgxpool.Pool) *FeeRepository {
+	return &FeeRepository{db: db}
+}
+
+// Quote returns the fee on a transfer of amount from account, and the revenue account it is paid into
+// The fee is zero, with no revenue account, if no schedule applies or the account is a system account
+func (r *FeeRepository) Quote(ctx context.Context, account *model.Account, amount model.Money) (model.Money, uuid.UUID, error) {
+	return quoteFee(ctx, r.db, account, amount)
+}
+
+// quoteFee works out a transfer fee on the pool or inside a database transaction
+func quoteFee(ctx context.Context, q rowQuerier, account *model.Account, amount model.Money) (model.Money, uuid.UUID, error) {
+	free := model.ZeroMoney(amount.Currency())
+	if account.IsSystemAccount() {
+		return free, uuid.Nil, nil
+	}
+
+	query := `
+		SELECT f.fee_type, f.flat_amount::text, f.percentage::text, f.min_fee::text, f.max_fee::text, a.id
+		FROM fee_schedules f
+		LEFT JOIN accounts a ON a.account_number = $3
+		WHERE f.account_type = $1 AND f.currency = $2
+	`
+
+	var feeType model.FeeType
+	var flatAmount, percentage, minFee, maxFee *string
+	var revenueID *uuid.UUID
+	err := q.QueryRow(ctx, query, account.AccountType, amount.Currency(), model.RevenueAccountNumber(amount.Currency())).
+		Scan(&feeType, &flatAmount, &percentage, &minFee, &maxFee, &revenueID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return free, uuid.Nil, nil
+		}
+		return model.Money{}, uuid.Nil, fmt.Errorf("failed to load fee schedule: %w", err)
+	}
+
+	s, err := parseFeeSchedule(account.AccountType, amount.Currency(), feeType, flatAmount, percentage, minFee, maxFee)
+	if err != nil {
+		return model.Money{}, uuid.Nil, err
+	}
+	fee, err := s.Fee(amount)
+	if err != nil {
+		return model.Money{}, uuid.Nil, fmt.Errorf("failed to calculate fee: %w", err)
+	}
+	if fee.IsZero() {
+		return free, uuid.Nil, nil
+	}
+	if revenueID == nil {
+		return model.Money{}, uuid.Nil, fmt.Errorf("no revenue account %s to pay the fee into", model.RevenueAccountNumber(amount.Currency()))
+	}
+	return fee, *revenueID, nil
+}
+
+// ListSchedules returns the fee schedules per account type and currency
+func (r *FeeRepository) ListSchedules(ctx context.Context) ([]model.FeeSchedule, error) {
+	query := `
+		SELECT account_type, currency, fee_type, flat_amount::text, percentage::text, min_fee::text, max_fee::text, updated_at
+		FROM fee_schedules
+		ORDER BY account_type, currency
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fee schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []model.FeeSchedule
+	for rows.Next() {
+		var accountType model.AccountType
+		var currency string
+		var feeType model.FeeType
+		var flatAmount, percentage, minFee, maxFee *string
+		var updatedAt time.Time
+		err := rows.Scan(&accountType, &currency, &feeType, &flatAmount, &percentage, &minFee, &maxFee, &updatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan fee schedule: %w", err)
+		}
+		s, err := parseFeeSchedule(accountType, currency, feeType, flatAmount, percentage, minFee, maxFee)
+		if err != nil {
+			return nil, err
+		}
+		s.UpdatedAt = updatedAt
+		schedules = append(schedules, *s)
+	}
+
+	return schedules, rows.Err()
+}
+
+// SetSchedule sets the fee schedule for an account type and currency, replacing any earlier one
+// Transfers created before the change keep the fee they were quoted
+func (r *FeeRepository) SetSchedule(ctx context.Context, s model.FeeSchedule) (*model.FeeSchedule, error) {
+	query := `
+		INSERT INTO fee_schedules (account_type, currency, fee_type, flat_amount, percentage, min_fee, max_fee, updated_at)
+		VALUES ($1, $2, $3, $4, $5::numeric, $6, $7, NOW())
+		ON CONFLICT (account_type, currency) DO UPDATE
+		SET fee_type = EXCLUDED.fee_type, flat_amount = EXCLUDED.flat_amount, percentage = EXCLUDED.percentage,
+			min_fee = EXCLUDED.min_fee, max_fee = EXCLUDED.max_fee, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+
+	var percentage *string
+	if s.FeeType == model.FeeTypePercentage {
+		percentage = &s.Percentage
+	}
+
+	err := r.db.QueryRow(ctx, query,
+		s.AccountType,
+		s.Currency,
+		s.FeeType,
+		nullableAmount(s.FlatAmount),
+		percentage,
+		nullableAmount(s.MinFee),
+		nullableAmount(s.MaxFee),
+	).Scan(&s.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set fee schedule: %w", err)
+	}
+
+	return &s, nil
+}
+
+// DeleteSchedule removes the fee schedule for an account type and currency, making such transfers free
+// Returns ErrFeeScheduleNotFound if there was none
+func (r *FeeRepository) DeleteSchedule(ctx context.Context, accountType model.AccountType, currency string) error {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM fee_schedules
+		WHERE account_type = $1 AND currency = $2
+	`, accountType, currency)
+	if err != nil {
+		return fmt.Errorf("failed to delete fee schedule: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrFeeScheduleNotFound
+	}
+	return nil
+}
+
+// parseFeeSchedule converts nullable database amounts to a model.FeeSchedule
+func parseFeeSchedule(accountType model.AccountType, currency string, feeType model.FeeType, flatAmount, percentage, minFee, maxFee *string) (*model.FeeSchedule, error) {
+	s := &model.FeeSchedule{AccountType: accountType, Currency: currency, FeeType: feeType}
+	if percentage != nil {
+		s.Percentage = *percentage
+	}
+
+	for _, field := range []struct {
+		in  *string
+		out **model.Money
+	}{
+		{flatAmount, &s.FlatAmount},
+		{minFee, &s.MinFee},
+		{maxFee, &s.MaxFee},
+	} {
+		if field.in == nil {
+			continue
+		}
+		amount, err := model.ParseMoney(*field.in, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse fee schedule amount: %w", err)
+		}
+		*field.out = &amount
+	}
+
+	return s, nil
+}
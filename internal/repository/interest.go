@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// InterestRepository handles interest rates, daily accruals and the monthly capitalization of interest
+// The interest itself is worked out by the interest package in the worker
+type InterestRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewInterestRepository creates a new InterestRepository
+func NewInterestRepository(db *pgxpool.Pool) *InterestRepository {
+	return &InterestRepository{db: db}
+}
+
+// ListRates returns every interest rate, by account type and currency and then by the date it takes effect
+func (r *InterestRepository) ListRates(ctx context.Context) ([]model.InterestRate, error) {
+	query := `
+		SELECT account_type, currency, annual_rate::text, effective_from, created_at
+		FROM interest_rates
+		ORDER BY account_type, currency, effective_from
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interest rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []model.InterestRate
+	for rows.Next() {
+		var rate model.InterestRate
+		if err := rows.Scan(&rate.AccountType, &rate.Currency, &rate.AnnualRate, &rate.EffectiveFrom, &rate.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan interest rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
+
+// SetRate sets the rate of an account type and currency from a date on, replacing a rate set earlier for that date
+func (r *InterestRepository) SetRate(ctx context.Context, rate model.InterestRate) (*model.InterestRate, error) {
+	query := `
+		INSERT INTO interest_rates (account_type, currency, annual_rate, effective_from, created_at)
+		VALUES ($1, $2, $3::numeric, $4, NOW())
+		ON CONFLICT (account_type, currency, effective_from) DO UPDATE
+		SET annual_rate = EXCLUDED.annual_rate, created_at = EXCLUDED.created_at
+		RETURNING annual_rate::text, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query, rate.AccountType, rate.Currency, rate.AnnualRate, rate.EffectiveFrom).
+		Scan(&rate.AnnualRate, &rate.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set interest rate: %w", err)
+	}
+
+	return &rate, nil
+}
+
+// ListAccounts returns the open customer accounts that have an interest rate, with how far each has been accrued
+func (r *InterestRepository) ListAccounts(ctx context.Context) ([]model.InterestAccount, error) {
+	query := `
+		SELECT a.id, a.account_type, a.currency, a.created_at, MAX(ia.accrual_date)
+		FROM accounts a
+		LEFT JOIN interest_accruals ia ON ia.account_id = a.id
+		WHERE a.customer_id IS NOT NULL
+		  AND a.status <> $1
+		  AND EXISTS (
+		    SELECT 1 FROM interest_rates ir
+		    WHERE ir.account_type = a.account_type AND ir.currency = a.currency
+		  )
+		GROUP BY a.id
+		ORDER BY a.id
+	`
+
+	rows, err := r.db.Query(ctx, query, model.AccountStatusClosed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interest accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []model.InterestAccount
+	for rows.Next() {
+		var a model.InterestAccount
+		if err := rows.Scan(&a.ID, &a.AccountType, &a.Currency, &a.CreatedAt, &a.AccruedThrough); err != nil {
+			return nil, fmt.Errorf("failed to scan interest account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+
+	return accounts, rows.Err()
+}
+
+// RecordAccruals stores daily accruals atomically
+// A day that already has an accrual keeps it, so running the accrual twice changes nothing
+func (r *InterestRepository) RecordAccruals(ctx context.Context, accruals []model.InterestAccrual) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	for _, a := range accruals {
+		_, err := dbTx.Exec(ctx, `
+			INSERT INTO interest_accruals (account_id, accrual_date, balance, annual_rate, amount, created_at)
+			VALUES ($1, $2, $3::numeric, $4::numeric, $5::numeric, NOW())
+			ON CONFLICT (account_id, accrual_date) DO NOTHING
+		`, a.AccountID, a.AccrualDate, a.Balance.String(), a.AnnualRate, a.Amount)
+		if err != nil {
+			return fmt.Errorf("failed to record interest accrual: %w", err)
+		}
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListDueCapitalizations returns the months before `before` whose interest is accrued but not yet paid
+// A month is due once its last day has been accrued; accounts closed since then are left out.
+// Only AccountID, Period and Accrued are set.
+func (r *InterestRepository) ListDueCapitalizations(ctx context.Context, before time.Time) ([]model.InterestCapitalization, error) {
+	query := `
+		SELECT ia.account_id, date_trunc('month', ia.accrual_date)::date AS period, SUM(ia.amount)::text
+		FROM interest_accruals ia
+		JOIN accounts a ON a.id = ia.account_id
+		WHERE ia.accrual_date < $1::date
+		  AND a.status <> $2
+		  AND NOT EXISTS (
+		    SELECT 1 FROM interest_capitalizations ic
+		    WHERE ic.account_id = ia.account_id AND ic.period = date_trunc('month', ia.accrual_date)::date
+		  )
+		GROUP BY ia.account_id, period
+		HAVING MAX(ia.accrual_date) = (date_trunc('month', MIN(ia.accrual_date)) + INTERVAL '1 month - 1 day')::date
+		ORDER BY period, ia.account_id
+	`
+
+	rows, err := r.db.Query(ctx, query, before, model.AccountStatusClosed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due interest capitalizations: %w", err)
+	}
+	defer rows.Close()
+
+	var due []model.InterestCapitalization
+	for rows.Next() {
+		var c model.InterestCapitalization
+		if err := rows.Scan(&c.AccountID, &c.Period, &c.Accrued); err != nil {
+			return nil, fmt.Errorf("failed to scan due interest capitalization: %w", err)
+		}
+		due = append(due, c)
+	}
+
+	return due, rows.Err()
+}
+
+// Capitalize pays a month's accrued interest into an account as a completed deposit from bank equity
+// The month's interest is summed and rounded half up to the currency's minor unit. Frozen accounts are
+// paid too, since the interest is owed; closed accounts return ErrAccountClosed, as Close pays what they are owed.
+// Each account and month is paid at most once: returns nil if the month was already capitalized.
+func (r *InterestRepository) Capitalize(ctx context.Context, accountID uuid.UUID, period time.Time) (*model.InterestCapitalization, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	account, err := lockAccount(ctx, dbTx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Status == model.AccountStatusClosed {
+		return nil, model.ErrAccountClosed
+	}
+
+	c := &model.InterestCapitalization{AccountID: accountID, Period: model.InterestPeriod(period), CreatedAt: time.Now()}
+	err = dbTx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0)::text
+		FROM interest_accruals
+		WHERE account_id = $1 AND accrual_date >= $2 AND accrual_date < $3
+	`, accountID, c.Period, c.Period.AddDate(0, 1, 0)).Scan(&c.Accrued)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum interest accruals: %w", err)
+	}
+	c.Amount, err = model.RoundAccrued(c.Accrued, account.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to round accrued interest: %w", err)
+	}
+
+	paid, err := payInterest(ctx, dbTx, account, c)
+	if err != nil || !paid {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return c, nil
+}
+
+// payInterest records a month's capitalization and, if its amount is positive, books the deposit
+// Returns false if the month was already capitalized. The account row must be locked.
+func payInterest(ctx context.Context, dbTx pgx.Tx, account *model.Account, c *model.InterestCapitalization) (bool, error) {
+	result, err := dbTx.Exec(ctx, `
+		INSERT INTO interest_capitalizations (account_id, period, accrued, amount, created_at)
+		VALUES ($1, $2, $3::numeric, $4::numeric, $5)
+		ON CONFLICT (account_id, period) DO NOTHING
+	`, c.AccountID, c.Period, c.Accrued, c.Amount.String(), c.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record interest capitalization: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if c.Amount.IsPositive() {
+		tx, err := postInterest(ctx, dbTx, account, c)
+		if err != nil {
+			return false, err
+		}
+		c.TransactionID = &tx.ID
+
+		_, err = dbTx.Exec(ctx, `
+			UPDATE interest_capitalizations SET transaction_id = $1
+			WHERE account_id = $2 AND period = $3
+		`, tx.ID, c.AccountID, c.Period)
+		if err != nil {
+			return false, fmt.Errorf("failed to link interest capitalization: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+// listUnpaidAccruals returns an account's accruals in months that have not been capitalized, oldest first
+// Only AccountID, AccrualDate and Amount are set
+func listUnpaidAccruals(ctx context.Context, dbTx pgx.Tx, accountID uuid.UUID) ([]model.InterestAccrual, error) {
+	rows, err := dbTx.Query(ctx, `
+		SELECT ia.accrual_date, ia.amount::text
+		FROM interest_accruals ia
+		WHERE ia.account_id = $1
+		  AND NOT EXISTS (
+		    SELECT 1 FROM interest_capitalizations ic
+		    WHERE ic.account_id = ia.account_id AND ic.period = date_trunc('month', ia.accrual_date)::date
+		  )
+		ORDER BY ia.accrual_date
+	`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpaid interest accruals: %w", err)
+	}
+	defer rows.Close()
+
+	var accruals []model.InterestAccrual
+	for rows.Next() {
+		a := model.InterestAccrual{AccountID: accountID}
+		if err := rows.Scan(&a.AccrualDate, &a.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan interest accrual: %w", err)
+		}
+		accruals = append(accruals, a)
+	}
+
+	return accruals, rows.Err()
+}
+
+// equityAccountID returns the ID of the bank equity account interest is paid from
+func equityAccountID(ctx context.Context, dbTx pgx.Tx, currency string) (uuid.UUID, error) {
+	var equityID uuid.UUID
+	err := dbTx.QueryRow(ctx, `SELECT id FROM accounts WHERE account_number = $1`, model.EquityAccountNumber(currency)).Scan(&equityID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("no equity account %s to pay interest from", model.EquityAccountNumber(currency))
+		}
+		return uuid.Nil, fmt.Errorf("failed to get equity account: %w", err)
+	}
+	return equityID, nil
+}
+
+// postInterest books a month's interest as a completed deposit from the bank equity account
+func postInterest(ctx context.Context, dbTx pgx.Tx, account *model.Account, c *model.InterestCapitalization) (*model.Transaction, error) {
+	equityID, err := equityAccountID(ctx, dbTx, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tx := &model.Transaction{
+		ID:             uuid.New(),
+		IdempotencyKey: model.InterestIdempotencyKey(account.ID, c.Period),
+		Type:           model.TransactionTypeDeposit,
+		Status:         model.TransactionStatusCompleted,
+		Reference:      "Interest " + c.Period.Format("January 2006"),
+		InitiatedAt:    now,
+		ProcessedAt:    &now,
+		CompletedAt:    &now,
+		Metadata:       map[string]any{"interest_period": c.Period.Format("2006-01")},
+		Amount:         c.Amount.String(),
+		Currency:       account.Currency,
+		FromAccountID:  &equityID,
+		ToAccountID:    &account.ID,
+	}
+
+	if err := bookTransaction(ctx, dbTx, tx, model.TransferLegs(equityID, account.ID, c.Amount)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
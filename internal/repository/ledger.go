@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/simonkvalheim/hm9-banking/internal/journal"
 	"github.com/simonkvalheim/hm9-banking/internal/model"
 )
 
@@ -22,58 +24,44 @@ func NewLedgerRepository(db *pgxpool.Pool) *LedgerRepository {
 	return &LedgerRepository{db: db}
 }
 
-// CreateEntries inserts multiple ledger entries within a database transaction
-// This is used by the worker to create balanced entries atomically
-func (r *LedgerRepository) CreateEntries(ctx context.Context, dbTx pgx.Tx, entries []model.LedgerEntry) error {
-	query := `
-		INSERT INTO ledger_entries (id, transaction_id, account_id, amount, entry_type, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
-
-	for _, entry := range entries {
-		_, err := dbTx.Exec(ctx, query,
-			entry.ID,
-			entry.TransactionID,
-			entry.AccountID,
-			entry.Amount,
-			entry.EntryType,
-			entry.CreatedAt,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to create ledger entry: %w", err)
-		}
-	}
-
-	return nil
-}
-
-// CreateEntriesStandalone inserts ledger entries using the pool connection
-// Use this when you don't have an existing transaction
-func (r *LedgerRepository) CreateEntriesStandalone(ctx context.Context, entries []model.LedgerEntry) error {
-	dbTx, err := r.db.Begin(ctx)
+// bookTransaction inserts a transaction that is completed as it is created, with its parties, and books its legs
+// Used for postings the bank makes itself, such as a closing sweep, a reversal or interest, which skip the
+// processor's business rules. The legs go through journal.Book like every other posting, so they are
+// locked and checked to balance per currency before the caller commits.
+func bookTransaction(ctx context.Context, dbTx pgx.Tx, tx *model.Transaction, legs []model.PostingLeg) error {
+	_, err := dbTx.Exec(ctx, `
+		INSERT INTO transactions (id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, metadata, amount, currency, from_account_id, to_account_id, reversal_of)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')::numeric, $11, $12, $13, $14)
+	`, tx.ID, tx.IdempotencyKey, tx.Type, tx.Status, tx.Reference, tx.InitiatedAt, tx.ProcessedAt, tx.CompletedAt,
+		tx.Metadata, tx.Amount, tx.Currency, tx.FromAccountID, tx.ToAccountID, tx.ReversalOf)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to create %s transaction: %w", tx.Type, err)
 	}
-	defer dbTx.Rollback(ctx)
 
-	if err := r.CreateEntries(ctx, dbTx, entries); err != nil {
-		return err
+	for _, party := range model.PartiesFor(tx.ID, legs) {
+		_, err := dbTx.Exec(ctx, `
+			INSERT INTO transaction_parties (id, transaction_id, account_id, role)
+			VALUES ($1, $2, $3, $4)
+		`, party.ID, party.TransactionID, party.AccountID, party.Role)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction party: %w", err)
+		}
 	}
 
-	if err := dbTx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit ledger entries: %w", err)
+	if _, err := journal.Book(ctx, dbTx, journal.Posting{TransactionID: tx.ID, Legs: legs}); err != nil {
+		return fmt.Errorf("failed to book %s transaction: %w", tx.Type, err)
 	}
-
 	return nil
 }
 
 // GetByTransactionID retrieves all ledger entries for a transaction
 func (r *LedgerRepository) GetByTransactionID(ctx context.Context, transactionID uuid.UUID) ([]model.LedgerEntry, error) {
 	query := `
-		SELECT id, transaction_id, account_id, amount, entry_type, created_at
-		FROM ledger_entries
-		WHERE transaction_id = $1
-		ORDER BY created_at
+		SELECT le.id, le.transaction_id, le.account_id, le.amount::text, a.currency, le.entry_type, le.created_at
+		FROM ledger_entries le
+		JOIN accounts a ON a.id = le.account_id
+		WHERE le.transaction_id = $1
+		ORDER BY le.created_at
 	`
 
 	rows, err := r.db.Query(ctx, query, transactionID)
@@ -82,24 +70,7 @@ func (r *LedgerRepository) GetByTransactionID(ctx context.Context, transactionID
 	}
 	defer rows.Close()
 
-	var entries []model.LedgerEntry
-	for rows.Next() {
-		var entry model.LedgerEntry
-		err := rows.Scan(
-			&entry.ID,
-			&entry.TransactionID,
-			&entry.AccountID,
-			&entry.Amount,
-			&entry.EntryType,
-			&entry.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
-		}
-		entries = append(entries, entry)
-	}
-
-	return entries, nil
+	return scanLedgerEntries(rows)
 }
 
 // GetByAccountID retrieves all ledger entries for an account
@@ -109,10 +80,11 @@ func (r *LedgerRepository) GetByAccountID(ctx context.Context, accountID uuid.UU
 	}
 
 	query := `
-		SELECT id, transaction_id, account_id, amount, entry_type, created_at
-		FROM ledger_entries
-		WHERE account_id = $1
-		ORDER BY created_at DESC
+		SELECT le.id, le.transaction_id, le.account_id, le.amount::text, a.currency, le.entry_type, le.created_at
+		FROM ledger_entries le
+		JOIN accounts a ON a.id = le.account_id
+		WHERE le.account_id = $1
+		ORDER BY le.created_at DESC
 		LIMIT $2
 	`
 
@@ -122,50 +94,155 @@ func (r *LedgerRepository) GetByAccountID(ctx context.Context, accountID uuid.UU
 	}
 	defer rows.Close()
 
-	var entries []model.LedgerEntry
+	return scanLedgerEntries(rows)
+}
+
+// ListAccountHistory returns a page of an account's ledger lines joined with their transactions
+// Lines are ordered newest first. The running balance is each entry's balance_after, written when it
+// was booked, so it is the balance right after the line whatever the filters, and a page only reads its own rows.
+func (r *LedgerRepository) ListAccountHistory(ctx context.Context, accountID uuid.UUID, filter model.TransactionHistoryFilter) (*model.TransactionHistoryPage, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > model.MaxHistoryLimit {
+		limit = model.DefaultHistoryLimit
+	}
+
+	query := `
+		SELECT le.id, le.transaction_id, le.amount::text, le.balance_after::text, a.currency,
+		       le.entry_type, le.created_at,
+		       t.type, t.status, t.reference,
+		       cp.id, cp.account_number
+		FROM ledger_entries le
+		JOIN accounts a ON a.id = le.account_id
+		JOIN transactions t ON t.id = le.transaction_id
+		LEFT JOIN accounts cp ON cp.id = CASE
+			WHEN t.from_account_id = $1 THEN t.to_account_id
+			ELSE t.from_account_id
+		END
+		WHERE le.account_id = $1
+	`
+	args := []any{accountID}
+
+	addArg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.From != nil {
+		query += " AND le.created_at >= " + addArg(*filter.From)
+	}
+	if filter.To != nil {
+		query += " AND le.created_at <= " + addArg(*filter.To)
+	}
+	if filter.Direction != "" {
+		query += " AND le.entry_type = " + addArg(filter.Direction)
+	}
+	if filter.Status != "" {
+		query += " AND t.status = " + addArg(filter.Status)
+	}
+	if filter.MinAmount != nil {
+		query += " AND ABS(le.amount) >= " + addArg(filter.MinAmount.String()) + "::numeric"
+	}
+	if filter.MaxAmount != nil {
+		query += " AND ABS(le.amount) <= " + addArg(filter.MaxAmount.String()) + "::numeric"
+	}
+	if filter.Cursor != nil {
+		query += " AND (le.created_at, le.id) < (" + addArg(filter.Cursor.CreatedAt) + ", " + addArg(filter.Cursor.EntryID) + ")"
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	query += " ORDER BY le.created_at DESC, le.id DESC LIMIT " + addArg(limit+1)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account history: %w", err)
+	}
+	defer rows.Close()
+
+	page := &model.TransactionHistoryPage{Entries: []model.TransactionHistoryEntry{}}
 	for rows.Next() {
-		var entry model.LedgerEntry
+		var entry model.TransactionHistoryEntry
+		var amount, runningBalance, currency string
+		var reference, counterpartyNumber *string
 		err := rows.Scan(
-			&entry.ID,
+			&entry.EntryID,
 			&entry.TransactionID,
-			&entry.AccountID,
-			&entry.Amount,
-			&entry.EntryType,
+			&amount,
+			&runningBalance,
+			&currency,
+			&entry.Direction,
 			&entry.CreatedAt,
+			&entry.Type,
+			&entry.Status,
+			&reference,
+			&entry.CounterpartyAccountID,
+			&counterpartyNumber,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+			return nil, fmt.Errorf("failed to scan history entry: %w", err)
 		}
-		entries = append(entries, entry)
+
+		if entry.Amount, err = model.ParseMoney(amount, currency); err != nil {
+			return nil, fmt.Errorf("failed to parse history amount: %w", err)
+		}
+		if entry.RunningBalance, err = model.ParseMoney(runningBalance, currency); err != nil {
+			return nil, fmt.Errorf("failed to parse running balance: %w", err)
+		}
+		if reference != nil {
+			entry.Reference = *reference
+		}
+		if counterpartyNumber != nil {
+			entry.CounterpartyAccountNumber = *counterpartyNumber
+		}
+
+		page.Entries = append(page.Entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list account history: %w", err)
+	}
+
+	if len(page.Entries) > limit {
+		page.Entries = page.Entries[:limit]
+		last := page.Entries[limit-1]
+		page.NextCursor = model.HistoryCursor{CreatedAt: last.CreatedAt, EntryID: last.EntryID}.Encode()
 	}
 
-	return entries, nil
+	return page, nil
 }
 
 // GetBalanceAtTime calculates the balance for an account at a specific point in time
-func (r *LedgerRepository) GetBalanceAtTime(ctx context.Context, accountID uuid.UUID, asOf time.Time) (string, error) {
+func (r *LedgerRepository) GetBalanceAtTime(ctx context.Context, accountID uuid.UUID, asOf time.Time) (model.Money, error) {
 	query := `
-		SELECT COALESCE(SUM(amount), 0) AS balance
-		FROM ledger_entries
-		WHERE account_id = $1
-		  AND created_at <= $2
+		SELECT a.currency, COALESCE(SUM(le.amount), 0)::text AS balance
+		FROM accounts a
+		LEFT JOIN ledger_entries le ON le.account_id = a.id AND le.created_at <= $2
+		WHERE a.id = $1
+		GROUP BY a.currency
 	`
 
-	var balance string
-	err := r.db.QueryRow(ctx, query, accountID, asOf).Scan(&balance)
+	var currency, balance string
+	err := r.db.QueryRow(ctx, query, accountID, asOf).Scan(&currency, &balance)
 	if err != nil {
-		return "", fmt.Errorf("failed to get balance at time: %w", err)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.Money{}, model.ErrAccountNotFound
+		}
+		return model.Money{}, fmt.Errorf("failed to get balance at time: %w", err)
 	}
 
-	return balance, nil
+	return model.ParseMoney(balance, currency)
 }
 
-// VerifyTransactionBalance checks that a transaction's ledger entries sum to zero
+// VerifyTransactionBalance checks that a transaction's ledger entries sum to zero in each currency
+// A multi-leg posting may span currencies, so a single sum over all of them could hide an imbalance
 func (r *LedgerRepository) VerifyTransactionBalance(ctx context.Context, transactionID uuid.UUID) (bool, error) {
 	query := `
-		SELECT COALESCE(SUM(amount), 0) = 0 AS is_balanced
-		FROM ledger_entries
-		WHERE transaction_id = $1
+		SELECT NOT EXISTS (
+			SELECT 1
+			FROM ledger_entries le
+			JOIN accounts a ON a.id = le.account_id
+			WHERE le.transaction_id = $1
+			GROUP BY a.currency
+			HAVING SUM(le.amount) <> 0
+		) AS is_balanced
 	`
 
 	var isBalanced bool
@@ -177,27 +254,31 @@ func (r *LedgerRepository) VerifyTransactionBalance(ctx context.Context, transac
 	return isBalanced, nil
 }
 
-// BuildTransferEntries creates the ledger entries for a transfer
-// Returns two entries that sum to zero (double-entry bookkeeping)
-func BuildTransferEntries(transactionID, fromAccountID, toAccountID uuid.UUID, amount string) []model.LedgerEntry {
-	now := time.Now()
-
-	return []model.LedgerEntry{
-		{
-			ID:            uuid.New(),
-			TransactionID: transactionID,
-			AccountID:     fromAccountID,
-			Amount:        "-" + amount, // Debit (negative)
-			EntryType:     model.LedgerEntryTypeDebit,
-			CreatedAt:     now,
-		},
-		{
-			ID:            uuid.New(),
-			TransactionID: transactionID,
-			AccountID:     toAccountID,
-			Amount:        amount, // Credit (positive)
-			EntryType:     model.LedgerEntryTypeCredit,
-			CreatedAt:     now,
-		},
+// scanLedgerEntries reads rows of (id, transaction_id, account_id, amount, currency, entry_type, created_at)
+// and parses each amount exactly in its account's currency
+func scanLedgerEntries(rows pgx.Rows) ([]model.LedgerEntry, error) {
+	var entries []model.LedgerEntry
+	for rows.Next() {
+		var entry model.LedgerEntry
+		var amount, currency string
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TransactionID,
+			&entry.AccountID,
+			&amount,
+			&currency,
+			&entry.EntryType,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entry.Amount, err = model.ParseMoney(amount, currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ledger entry amount: %w", err)
+		}
+		entries = append(entries, entry)
 	}
+
+	return entries, rows.Err()
 }
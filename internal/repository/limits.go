@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/limits"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// LimitRepository handles the transfer limit tables
+// The limits themselves are enforced by the processor; see the limits package
+type LimitRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewLimitRepository creates a new LimitRepository
+func NewLimitRepository(db *pgxpool.Pool) *LimitRepository {
+	return &LimitRepository{db: db}
+}
+
+// GetHeadroom reports the account's limits and how much of them is left
+// In-flight transactions count against the limits, as they do when the processor checks them
+func (r *LimitRepository) GetHeadroom(ctx context.Context, account *model.Account) (*model.LimitHeadroom, error) {
+	accountLimits, err := limits.Load(ctx, r.db, account)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := limits.LoadUsage(ctx, r.db, account, uuid.Nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	headroom := limits.Headroom(account, accountLimits, usage)
+	return &headroom, nil
+}
+
+// ListDefaults returns the default limits per account type and currency
+func (r *LimitRepository) ListDefaults(ctx context.Context) ([]model.TransferLimitDefault, error) {
+	query := `
+		SELECT account_type, currency, per_transaction::text, daily::text, monthly::text, updated_at
+		FROM transfer_limits
+		ORDER BY account_type, currency
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfer limits: %w", err)
+	}
+	defer rows.Close()
+
+	var defaults []model.TransferLimitDefault
+	for rows.Next() {
+		var d model.TransferLimitDefault
+		var perTransaction, daily, monthly *string
+		if err := rows.Scan(&d.AccountType, &d.Currency, &perTransaction, &daily, &monthly, &d.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer limits: %w", err)
+		}
+		if d.Limits, err = limits.ParseLimits(d.Currency, perTransaction, daily, monthly); err != nil {
+			return nil, err
+		}
+		defaults = append(defaults, d)
+	}
+
+	return defaults, rows.Err()
+}
+
+// SetDefault sets the default limits for an account type and currency
+func (r *LimitRepository) SetDefault(ctx context.Context, accountType model.AccountType, currency string, l model.TransferLimits) (*model.TransferLimitDefault, error) {
+	query := `
+		INSERT INTO transfer_limits (account_type, currency, per_transaction, daily, monthly, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (account_type, currency) DO UPDATE
+		SET per_transaction = EXCLUDED.per_transaction, daily = EXCLUDED.daily,
+			monthly = EXCLUDED.monthly, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+
+	d := &model.TransferLimitDefault{AccountType: accountType, Currency: currency, Limits: l}
+	err := r.db.QueryRow(ctx, query,
+		accountType,
+		currency,
+		nullableAmount(l.PerTransaction),
+		nullableAmount(l.Daily),
+		nullableAmount(l.Monthly),
+	).Scan(&d.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set transfer limits: %w", err)
+	}
+
+	return d, nil
+}
+
+// ListOverrides returns a customer's limit overrides, one per currency
+func (r *LimitRepository) ListOverrides(ctx context.Context, customerID uuid.UUID) ([]model.TransferLimitOverride, error) {
+	query := `
+		SELECT currency, per_transaction::text, daily::text, monthly::text, COALESCE(reason, ''), updated_at
+		FROM customer_transfer_limits
+		WHERE customer_id = $1
+		ORDER BY currency
+	`
+
+	rows, err := r.db.Query(ctx, query, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfer limit overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []model.TransferLimitOverride
+	for rows.Next() {
+		o := model.TransferLimitOverride{CustomerID: customerID}
+		var perTransaction, daily, monthly *string
+		if err := rows.Scan(&o.Currency, &perTransaction, &daily, &monthly, &o.Reason, &o.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transfer limit override: %w", err)
+		}
+		if o.Limits, err = limits.ParseLimits(o.Currency, perTransaction, daily, monthly); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, o)
+	}
+
+	return overrides, rows.Err()
+}
+
+// SetOverride sets a customer's limits in one currency, replacing any earlier override
+// Returns ErrCustomerNotFound if the customer does not exist
+func (r *LimitRepository) SetOverride(ctx context.Context, customerID uuid.UUID, currency string, l model.TransferLimits, reason string) (*model.TransferLimitOverride, error) {
+	query := `
+		INSERT INTO customer_transfer_limits (customer_id, currency, per_transaction, daily, monthly, reason, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NOW())
+		ON CONFLICT (customer_id, currency) DO UPDATE
+		SET per_transaction = EXCLUDED.per_transaction, daily = EXCLUDED.daily,
+			monthly = EXCLUDED.monthly, reason = EXCLUDED.reason, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at
+	`
+
+	o := &model.TransferLimitOverride{CustomerID: customerID, Currency: currency, Limits: l, Reason: reason}
+	err := r.db.QueryRow(ctx, query,
+		customerID,
+		currency,
+		nullableAmount(l.PerTransaction),
+		nullableAmount(l.Daily),
+		nullableAmount(l.Monthly),
+		reason,
+	).Scan(&o.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, model.ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to set transfer limit override: %w", err)
+	}
+
+	return o, nil
+}
+
+// DeleteOverride removes a customer's override in one currency, returning them to the defaults
+// Returns ErrTransferLimitNotFound if there was none
+func (r *LimitRepository) DeleteOverride(ctx context.Context, customerID uuid.UUID, currency string) error {
+	result, err := r.db.Exec(ctx, `
+		DELETE FROM customer_transfer_limits
+		WHERE customer_id = $1 AND currency = $2
+	`, customerID, currency)
+	if err != nil {
+		return fmt.Errorf("failed to delete transfer limit override: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrTransferLimitNotFound
+	}
+	return nil
+}
+
+// nullableAmount converts an optional amount, such as a limit, to a nullable database amount
+func nullableAmount(m *model.Money) *string {
+	if m == nil {
+		return nil
+	}
+	s := m.String()
+	return &s
+}
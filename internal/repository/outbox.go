@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// OutboxRepository handles database operations for the transaction outbox
+type OutboxRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewOutboxRepository creates a new OutboxRepository
+func NewOutboxRepository(db *pgxpool.Pool) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// ProcessUnsent locks up to limit due, unsent outbox entries and calls publish for each
+// Entries are locked with FOR UPDATE SKIP LOCKED, so several relays can run concurrently
+// without publishing the same entry twice. Successful entries are marked published;
+// failed ones are rescheduled after retryDelay(attempts).
+func (r *OutboxRepository) ProcessUnsent(ctx context.Context, limit int, publish func(model.OutboxEntry) error, retryDelay func(attempts int) time.Duration) (published, failed int, err error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	rows, err := dbTx.Query(ctx, `
+		SELECT id, transaction_id, transaction_type, attempts, created_at
+		FROM transaction_outbox
+		WHERE published_at IS NULL AND next_attempt_at <= NOW()
+		ORDER BY created_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch outbox entries: %w", err)
+	}
+
+	var entries []model.OutboxEntry
+	for rows.Next() {
+		var entry model.OutboxEntry
+		if err := rows.Scan(&entry.ID, &entry.TransactionID, &entry.TransactionType, &entry.Attempts, &entry.CreatedAt); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan outbox entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch outbox entries: %w", err)
+	}
+
+	for _, entry := range entries {
+		now := time.Now()
+		if publishErr := publish(entry); publishErr != nil {
+			attempts := entry.Attempts + 1
+			_, err := dbTx.Exec(ctx, `
+				UPDATE transaction_outbox
+				SET attempts = $1, next_attempt_at = $2, last_error = $3
+				WHERE id = $4
+			`, attempts, now.Add(retryDelay(attempts)), truncate(publishErr.Error(), 500), entry.ID)
+			if err != nil {
+				return published, failed, fmt.Errorf("failed to reschedule outbox entry: %w", err)
+			}
+			failed++
+			continue
+		}
+
+		_, err := dbTx.Exec(ctx, `
+			UPDATE transaction_outbox
+			SET published_at = $1, attempts = attempts + 1, last_error = NULL
+			WHERE id = $2
+		`, now, entry.ID)
+		if err != nil {
+			return published, failed, fmt.Errorf("failed to mark outbox entry published: %w", err)
+		}
+		published++
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit outbox batch: %w", err)
+	}
+
+	return published, failed, nil
+}
+
+// CountUnsent returns the number of outbox entries not yet published
+func (r *OutboxRepository) CountUnsent(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM transaction_outbox WHERE published_at IS NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unsent outbox entries: %w", err)
+	}
+	return count, nil
+}
+
+// truncate shortens s to at most n bytes to fit VARCHAR columns
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
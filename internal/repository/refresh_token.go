@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// RefreshTokenRepository handles database operations for refresh tokens
+type RefreshTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRefreshTokenRepository creates a new RefreshTokenRepository
+func NewRefreshTokenRepository(db *pgxpool.Pool) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// GetByHash retrieves a refresh token by the hash of its value
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, customer_id, family_id, token_hash, expires_at, created_at, used_at, replaced_by, revoked_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	token := &model.RefreshToken{}
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.CustomerID,
+		&token.FamilyID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UsedAt,
+		&token.ReplacedBy,
+		&token.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, model.ErrRefreshTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Rotate marks a token as used and stores its replacement atomically
+// The token's session is extended and its last-used time and client updated.
+// Returns ErrRefreshTokenReused if the old token was already used or revoked,
+// which also covers two concurrent refreshes racing with the same token
+func (r *RefreshTokenRepository) Rotate(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken, client model.ClientInfo) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	_, err = dbTx.Exec(ctx, `
+		INSERT INTO refresh_tokens (id, customer_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, next.ID, next.CustomerID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	result, err := dbTx.Exec(ctx, `
+		UPDATE refresh_tokens
+		SET used_at = $1, replaced_by = $2
+		WHERE id = $3 AND used_at IS NULL AND revoked_at IS NULL
+	`, next.CreatedAt, next.ID, oldID)
+	if err != nil {
+		return fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrRefreshTokenReused
+	}
+
+	client = client.Truncated()
+	_, err = dbTx.Exec(ctx, `
+		UPDATE sessions
+		SET last_used_at = $1, expires_at = $2, user_agent = $3, ip_address = $4
+		WHERE id = $5
+	`, next.CreatedAt, next.ExpiresAt, client.UserAgent, client.IPAddress, next.FamilyID)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/simonkvalheim/hm9-banking/internal/journal"
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// Cancel withdraws a transaction that is still pending
+// The processor only claims pending transactions, so a cancelled one is never booked even if
+// its queue message is still on the way. Returns ErrTransactionNotPending once processing has started.
+func (r *TransactionRepository) Cancel(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `
+		UPDATE transactions
+		SET status = $1
+		WHERE id = $2 AND status = $3
+	`, model.TransactionStatusCancelled, id, model.TransactionStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to cancel transaction: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return err
+		}
+		return model.ErrTransactionNotPending
+	}
+	return nil
+}
+
+// Reverse undoes a completed transaction by booking a reversal whose ledger entries mirror the original's
+// Existing ledger entries are never changed: the reversal adds an opposite entry for each of them,
+// linked to the original through reversal_of, and the original moves to status reversed.
+// Frozen accounts are included; closed accounts are not, since they must stay at zero.
+// A customer account taken beyond its available balance, e.g. a destination that has already spent
+// the money, returns ErrInsufficientFunds unless force is set. A forced reversal records the accounts
+// it overdraws in its metadata.
+// Returns ErrNotReversible unless the transaction is completed and not itself a reversal.
+func (r *TransactionRepository) Reverse(ctx context.Context, id uuid.UUID, reason string, force bool) (*model.Transaction, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	original := &model.Transaction{ID: id}
+	var reference *string
+	err = dbTx.QueryRow(ctx, `
+		SELECT type, status, reference, COALESCE(amount::text, ''), from_account_id, to_account_id
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&original.Type, &original.Status, &reference, &original.Amount, &original.FromAccountID, &original.ToAccountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, model.ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to lock transaction: %w", err)
+	}
+	if !original.IsReversible() {
+		return nil, model.ErrNotReversible
+	}
+	if reference != nil {
+		original.Reference = *reference
+	}
+
+	rows, err := dbTx.Query(ctx, `
+		SELECT le.id, le.transaction_id, le.account_id, le.amount::text, a.currency, le.entry_type, le.created_at
+		FROM ledger_entries le
+		JOIN accounts a ON a.id = le.account_id
+		WHERE le.transaction_id = $1
+		ORDER BY le.created_at
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entries: %w", err)
+	}
+	entries, err := scanLedgerEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, model.ErrNotReversible
+	}
+
+	roles, err := partyRoles(ctx, dbTx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock the accounts in journal.LockOrder, as closing an account does, so a reversal and a close
+	// touching the same accounts cannot deadlock; journal.Book then locks their balances in the same order
+	accountIDs := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		accountIDs[i] = entry.AccountID
+	}
+	accounts := make(map[uuid.UUID]*model.Account, len(accountIDs))
+	for _, accountID := range journal.LockOrder(accountIDs) {
+		account, err := lockAccount(ctx, dbTx, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if account.Status == model.AccountStatusClosed {
+			return nil, model.ErrAccountClosed
+		}
+		accounts[accountID] = account
+	}
+
+	// Every entry is mirrored, so a fee charged on the original is refunded with it
+	legs := reversedLegs(entries, roles)
+
+	balances, err := journal.LockBalances(ctx, dbTx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	overdrawn, err := overdrawnByReversal(legs, accounts, balances)
+	if err != nil {
+		return nil, err
+	}
+	if len(overdrawn) > 0 && !force {
+		return nil, model.ErrInsufficientFunds
+	}
+
+	now := time.Now()
+	reversal := &model.Transaction{
+		ID:             uuid.New(),
+		IdempotencyKey: "reversal:" + id.String(),
+		Type:           model.TransactionTypeReversal,
+		Status:         model.TransactionStatusCompleted,
+		Reference:      reversalReference(original.Reference),
+		InitiatedAt:    now,
+		ProcessedAt:    &now,
+		CompletedAt:    &now,
+		Metadata:       reversalMetadata(reason, overdrawn),
+		FromAccountID:  original.ToAccountID,
+		ToAccountID:    original.FromAccountID,
+		Amount:         original.Amount,
+		Currency:       entries[0].Amount.Currency(),
+		ReversalOf:     &id,
+	}
+
+	if err := bookTransaction(ctx, dbTx, reversal, legs); err != nil {
+		if isUniqueViolation(err) {
+			return nil, model.ErrNotReversible
+		}
+		return nil, err
+	}
+
+	_, err = dbTx.Exec(ctx, `UPDATE transactions SET status = $1 WHERE id = $2`, model.TransactionStatusReversed, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark transaction reversed: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return reversal, nil
+}
+
+// partyRoles returns the roles each account has on a transaction
+func partyRoles(ctx context.Context, dbTx pgx.Tx, transactionID uuid.UUID) (map[uuid.UUID][]string, error) {
+	rows, err := dbTx.Query(ctx, `
+		SELECT account_id, role
+		FROM transaction_parties
+		WHERE transaction_id = $1
+		ORDER BY role
+	`, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction parties: %w", err)
+	}
+	defer rows.Close()
+
+	roles := make(map[uuid.UUID][]string)
+	for rows.Next() {
+		var accountID uuid.UUID
+		var role string
+		if err := rows.Scan(&accountID, &role); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction party: %w", err)
+		}
+		roles[accountID] = append(roles[accountID], role)
+	}
+
+	return roles, rows.Err()
+}
+
+// reversedLegs returns the legs that cancel out a transaction's ledger entries: same accounts, opposite amounts
+// Each leg keeps the role its account had on the original, so reversing a transfer with a fee gives the
+// same source, destination and fee parties. Where an account had several roles, a debit belongs to
+// "source"; entries without a party fall back to "source" for debits and "destination" for credits.
+func reversedLegs(entries []model.LedgerEntry, roles map[uuid.UUID][]string) []model.PostingLeg {
+	legs := make([]model.PostingLeg, len(entries))
+	for i, entry := range entries {
+		legs[i] = model.PostingLeg{
+			AccountID: entry.AccountID,
+			Amount:    entry.Amount.Neg(),
+			Role:      entryRole(roles[entry.AccountID], entry),
+		}
+	}
+	return legs
+}
+
+// entryRole picks the party role a ledger entry was booked under
+func entryRole(roles []string, entry model.LedgerEntry) string {
+	debit := entry.EntryType == model.LedgerEntryTypeDebit
+	for _, role := range roles {
+		if (role == "source") == debit {
+			return role
+		}
+	}
+	if len(roles) > 0 {
+		return roles[0]
+	}
+	if debit {
+		return "source"
+	}
+	return "destination"
+}
+
+// overdrawnByReversal returns the customer accounts a reversal would take beyond their available balance
+// balances are locked, so the result holds until commit. System accounts may go negative.
+func overdrawnByReversal(legs []model.PostingLeg, accounts map[uuid.UUID]*model.Account, balances map[uuid.UUID]model.Money) ([]uuid.UUID, error) {
+	accountIDs, net, err := model.NetByAccount(legs)
+	if err != nil {
+		return nil, err
+	}
+
+	var overdrawn []uuid.UUID
+	for _, id := range accountIDs {
+		account := accounts[id]
+		if account.IsSystemAccount() || !net[id].IsNegative() {
+			continue
+		}
+		cmp, err := account.AvailableBalance(balances[id]).Cmp(net[id].Neg())
+		if err != nil {
+			return nil, err
+		}
+		if cmp < 0 {
+			overdrawn = append(overdrawn, id)
+		}
+	}
+	return overdrawn, nil
+}
+
+// reversalMetadata is stored on a reversal: the operator's reason and any accounts a forced reversal overdrew
+func reversalMetadata(reason string, overdrawn []uuid.UUID) map[string]any {
+	metadata := map[string]any{"reason": strings.TrimSpace(reason)}
+	if len(overdrawn) > 0 {
+		ids := make([]string, len(overdrawn))
+		for i, id := range overdrawn {
+			ids[i] = id.String()
+		}
+		metadata["overdrawn_accounts"] = ids
+	}
+	return metadata
+}
+
+// reversalReference is the reference shown on a reversal, e.g. "Reversal of Rent March"
+func reversalReference(original string) string {
+	if original == "" {
+		return "Reversal"
+	}
+	ref := []rune("Reversal of " + original)
+	if len(ref) > 255 {
+		ref = ref[:255]
+	}
+	return string(ref)
+}
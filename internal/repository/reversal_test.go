@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+func mustMoney(t *testing.T, amount string) model.Money {
+	t.Helper()
+	m, err := model.ParseMoney(amount, "NOK")
+	if err != nil {
+		t.Fatalf("ParseMoney(%q) error = %v", amount, err)
+	}
+	return m
+}
+
+func TestReversedLegs(t *testing.T) {
+	txID := uuid.New()
+	source, destination, revenue := uuid.New(), uuid.New(), uuid.New()
+	legs := append(model.TransferLegs(source, destination, mustMoney(t, "250.00")),
+		model.FeeLegs(source, revenue, mustMoney(t, "5.00"))...)
+	original := model.NewLedgerEntries(txID, legs, time.Now())
+
+	roles := make(map[uuid.UUID][]string)
+	for _, party := range model.PartiesFor(txID, legs) {
+		roles[party.AccountID] = append(roles[party.AccountID], party.Role)
+	}
+
+	reversed := reversedLegs(original, roles)
+	if len(reversed) != len(original) {
+		t.Fatalf("reversedLegs() returned %d legs, want %d", len(reversed), len(original))
+	}
+	for i, leg := range reversed {
+		if leg.AccountID != original[i].AccountID {
+			t.Errorf("leg %d: account ID = %v, want %v", i, leg.AccountID, original[i].AccountID)
+		}
+		if leg.Amount.String() != original[i].Amount.Neg().String() {
+			t.Errorf("leg %d: amount = %s, want %s", i, leg.Amount, original[i].Amount.Neg())
+		}
+		if leg.Role != legs[i].Role {
+			t.Errorf("leg %d: role = %q, want %q", i, leg.Role, legs[i].Role)
+		}
+	}
+
+	// The original and its reversal together leave every balance unchanged
+	if err := model.ValidateLegs(reversed); err != nil {
+		t.Errorf("ValidateLegs(reversed) error = %v", err)
+	}
+
+	want := map[uuid.UUID]string{source: "source", destination: "destination", revenue: "fee"}
+	parties := model.PartiesFor(uuid.New(), reversed)
+	if len(parties) != len(want) {
+		t.Fatalf("reversal has %d parties, want %d", len(parties), len(want))
+	}
+	for _, party := range parties {
+		if party.Role != want[party.AccountID] {
+			t.Errorf("party %v: role = %q, want %q", party.AccountID, party.Role, want[party.AccountID])
+		}
+	}
+}
+
+func TestEntryRole_WithoutParties(t *testing.T) {
+	entries := model.NewLedgerEntries(uuid.New(), model.TransferLegs(uuid.New(), uuid.New(), mustMoney(t, "10.00")), time.Now())
+
+	if got := entryRole(nil, entries[0]); got != "source" {
+		t.Errorf("entryRole(debit) = %q, want source", got)
+	}
+	if got := entryRole(nil, entries[1]); got != "destination" {
+		t.Errorf("entryRole(credit) = %q, want destination", got)
+	}
+}
+
+func TestReversalReference(t *testing.T) {
+	if got := reversalReference(""); got != "Reversal" {
+		t.Errorf("reversalReference(\"\") = %q, want %q", got, "Reversal")
+	}
+	if got := reversalReference("Rent March"); got != "Reversal of Rent March" {
+		t.Errorf("reversalReference() = %q, want %q", got, "Reversal of Rent March")
+	}
+
+	long := reversalReference(strings.Repeat("ø", 255))
+	if n := utf8.RuneCountInString(long); n != 255 || !utf8.ValidString(long) {
+		t.Errorf("reversalReference(255 runes) has %d runes, valid UTF-8 %v; want 255 and valid", n, utf8.ValidString(long))
+	}
+}
+
+func TestOverdrawnByReversal(t *testing.T) {
+	source := &model.Account{ID: uuid.New(), AccountType: model.AccountTypeChecking, Currency: "NOK"}
+	destination := &model.Account{ID: uuid.New(), AccountType: model.AccountTypeChecking, Currency: "NOK"}
+	revenue := &model.Account{ID: uuid.New(), AccountType: model.AccountTypeRevenue, Currency: "NOK"}
+	accounts := map[uuid.UUID]*model.Account{source.ID: source, destination.ID: destination, revenue.ID: revenue}
+
+	// 250.00 was sent with a fee of 5.00; reversing it debits the destination and the revenue account
+	legs := append(model.TransferLegs(source.ID, destination.ID, mustMoney(t, "250.00")),
+		model.FeeLegs(source.ID, revenue.ID, mustMoney(t, "5.00"))...)
+	reversal := reversedLegs(model.NewLedgerEntries(uuid.New(), legs, time.Now()), nil)
+
+	tests := []struct {
+		name           string
+		destination    string
+		overdraftLimit string
+		want           bool
+	}{
+		{"money still there", "250.00", "0.00", false},
+		{"destination has spent the money", "40.00", "0.00", true},
+		{"overdraft covers it", "40.00", "210.00", false},
+		{"overdraft falls short", "40.00", "209.99", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination.OverdraftLimit = mustMoney(t, tt.overdraftLimit)
+			balances := map[uuid.UUID]model.Money{
+				source.ID:      mustMoney(t, "0.00"),
+				destination.ID: mustMoney(t, tt.destination),
+				revenue.ID:     mustMoney(t, "0.00"), // system accounts may go negative
+			}
+
+			overdrawn, err := overdrawnByReversal(reversal, accounts, balances)
+			if err != nil {
+				t.Fatalf("overdrawnByReversal() error = %v", err)
+			}
+			if got := len(overdrawn) == 1 && overdrawn[0] == destination.ID; got != tt.want || (!tt.want && len(overdrawn) > 0) {
+				t.Errorf("overdrawnByReversal() = %v, want destination overdrawn: %v", overdrawn, tt.want)
+			}
+		})
+	}
+}
+
+func TestReversalMetadata(t *testing.T) {
+	if got := reversalMetadata(" Duplicate payment ", nil); len(got) != 1 || got["reason"] != "Duplicate payment" {
+		t.Errorf("reversalMetadata() = %v, want only the trimmed reason", got)
+	}
+
+	id := uuid.New()
+	got := reversalMetadata("Fraud", []uuid.UUID{id})
+	ids, ok := got["overdrawn_accounts"].([]string)
+	if !ok || len(ids) != 1 || ids[0] != id.String() {
+		t.Errorf("reversalMetadata(forced) = %v, want overdrawn_accounts [%s]", got, id)
+	}
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// scheduledTransferColumns is the column list scanned by scanScheduledTransfer
+const scheduledTransferColumns = `id, customer_id, from_account_id, to_account_id, amount::text, currency, reference,
+	frequency, start_date, end_date, timezone, status, occurrences, next_run_date, next_run_at,
+	created_at, updated_at, cancelled_at`
+
+// ScheduledTransferRepository handles database operations for standing orders and their occurrences
+type ScheduledTransferRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewScheduledTransferRepository creates a new ScheduledTransferRepository
+func NewScheduledTransferRepository(db *pgxpool.Pool) *ScheduledTransferRepository {
+	return &ScheduledTransferRepository{db: db}
+}
+
+// Create stores a new standing order in the customer's current time zone
+// Returns ErrScheduleStartInPast if the start date is already over there
+func (r *ScheduledTransferRepository) Create(ctx context.Context, schedule *model.ScheduledTransfer) error {
+	err := r.db.QueryRow(ctx, `SELECT timezone FROM customers WHERE id = $1`, schedule.CustomerID).Scan(&schedule.Timezone)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.ErrCustomerNotFound
+		}
+		return fmt.Errorf("failed to get customer timezone: %w", err)
+	}
+
+	now := time.Now()
+	if err := schedule.Activate(now); err != nil {
+		return err
+	}
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO scheduled_transfers (id, customer_id, from_account_id, to_account_id, amount, currency, reference,
+			frequency, start_date, end_date, timezone, status, occurrences, next_run_date, next_run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $16)
+	`, schedule.ID, schedule.CustomerID, schedule.FromAccountID, schedule.ToAccountID, schedule.Amount.String(),
+		schedule.Currency, schedule.Reference, schedule.Frequency, schedule.StartDate, schedule.EndDate,
+		schedule.Timezone, schedule.Status, schedule.Occurrences, schedule.NextRunDate, schedule.NextRunAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create scheduled transfer: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a standing order by its ID
+func (r *ScheduledTransferRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + ` FROM scheduled_transfers WHERE id = $1`
+
+	schedule, err := scanScheduledTransfer(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, model.ErrScheduledTransferNotFound
+		}
+		return nil, fmt.Errorf("failed to get scheduled transfer: %w", err)
+	}
+	return schedule, nil
+}
+
+// ListByCustomer returns a customer's standing orders, newest first
+func (r *ScheduledTransferRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]model.ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + `
+		FROM scheduled_transfers
+		WHERE customer_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []model.ScheduledTransfer
+	for rows.Next() {
+		schedule, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled transfer: %w", err)
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// Cancel stops a standing order; transfers already made for it are not affected
+// Returns ErrScheduleNotActive if it has completed or was cancelled before
+func (r *ScheduledTransferRepository) Cancel(ctx context.Context, id uuid.UUID) (*model.ScheduledTransfer, error) {
+	query := `
+		UPDATE scheduled_transfers
+		SET status = $1, next_run_date = NULL, next_run_at = NULL, cancelled_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status = $3
+		RETURNING ` + scheduledTransferColumns
+
+	schedule, err := scanScheduledTransfer(r.db.QueryRow(ctx, query,
+		model.ScheduledTransferStatusCancelled, id, model.ScheduledTransferStatusActive))
+	if err == nil {
+		return schedule, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to cancel scheduled transfer: %w", err)
+	}
+
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, model.ErrScheduleNotActive
+}
+
+// ListOccurrences returns the materialized occurrences of a standing order, newest first,
+// with the status of the transfer each one created
+func (r *ScheduledTransferRepository) ListOccurrences(ctx context.Context, scheduleID uuid.UUID) ([]model.ScheduledOccurrence, error) {
+	query := `
+		SELECT o.id, o.scheduled_transfer_id, o.occurrence_date, o.transaction_id, t.status, t.error_message, o.created_at
+		FROM scheduled_transfer_occurrences o
+		JOIN transactions t ON t.id = o.transaction_id
+		WHERE o.scheduled_transfer_id = $1
+		ORDER BY o.occurrence_date DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled transfer occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	var occurrences []model.ScheduledOccurrence
+	for rows.Next() {
+		var o model.ScheduledOccurrence
+		var errorMessage *string
+		if err := rows.Scan(&o.ID, &o.ScheduledTransferID, &o.OccurrenceDate, &o.TransactionID, &o.Status, &errorMessage, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled transfer occurrence: %w", err)
+		}
+		if errorMessage != nil {
+			o.ErrorMessage = *errorMessage
+		}
+		occurrences = append(occurrences, o)
+	}
+
+	return occurrences, rows.Err()
+}
+
+// ListFailed returns occurrences materialized since the given time whose transfer failed, newest first
+// If customerID is set, only that customer's standing orders are included
+func (r *ScheduledTransferRepository) ListFailed(ctx context.Context, customerID *uuid.UUID, since time.Time, limit int) ([]model.FailedScheduledOccurrence, error) {
+	query := `
+		SELECT o.id, o.scheduled_transfer_id, o.occurrence_date, o.transaction_id, t.status, t.error_message, o.created_at,
+			s.customer_id, s.from_account_id, s.to_account_id, s.amount::text, s.currency, s.reference
+		FROM scheduled_transfer_occurrences o
+		JOIN transactions t ON t.id = o.transaction_id
+		JOIN scheduled_transfers s ON s.id = o.scheduled_transfer_id
+		WHERE t.status = $1 AND o.created_at >= $2 AND ($3::uuid IS NULL OR s.customer_id = $3)
+		ORDER BY o.created_at DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(ctx, query, model.TransactionStatusFailed, since, customerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed scheduled transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var failed []model.FailedScheduledOccurrence
+	for rows.Next() {
+		var f model.FailedScheduledOccurrence
+		var errorMessage, reference *string
+		var amount string
+		err := rows.Scan(
+			&f.ID, &f.ScheduledTransferID, &f.OccurrenceDate, &f.TransactionID, &f.Status, &errorMessage, &f.CreatedAt,
+			&f.CustomerID, &f.FromAccountID, &f.ToAccountID, &amount, &f.Currency, &reference,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan failed scheduled transfer: %w", err)
+		}
+		if errorMessage != nil {
+			f.ErrorMessage = *errorMessage
+		}
+		if reference != nil {
+			f.Reference = *reference
+		}
+		if f.Amount, err = model.ParseMoney(amount, f.Currency); err != nil {
+			return nil, fmt.Errorf("failed to parse scheduled transfer amount: %w", err)
+		}
+		failed = append(failed, f)
+	}
+
+	return failed, rows.Err()
+}
+
+// MaterializeNext turns the earliest due occurrence of any active standing order into a pending transfer
+// The transfer, its occurrence record and the schedule's next run are written in one database transaction,
+// and the transfer reaches the queue through the outbox. The schedule row is locked with SKIP LOCKED,
+// so several workers can run the scheduler side by side.
+// Returns a nil schedule when nothing is due, and a nil transaction when the schedule was cancelled
+// instead because its source account has been closed
+func (r *ScheduledTransferRepository) MaterializeNext(ctx context.Context, now time.Time) (*model.ScheduledTransfer, *model.Transaction, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	query := `SELECT ` + scheduledTransferColumns + `
+		FROM scheduled_transfers
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`
+	schedule, err := scanScheduledTransfer(dbTx.QueryRow(ctx, query, model.ScheduledTransferStatusActive, now))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to get due scheduled transfer: %w", err)
+	}
+
+	source := &model.Account{ID: schedule.FromAccountID}
+	err = dbTx.QueryRow(ctx, `SELECT account_type, status FROM accounts WHERE id = $1`, schedule.FromAccountID).
+		Scan(&source.AccountType, &source.Status)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get source account status: %w", err)
+	}
+
+	// A closed account never reopens, so the order could only keep failing
+	if source.Status == model.AccountStatusClosed {
+		_, err = dbTx.Exec(ctx, `
+			UPDATE scheduled_transfers
+			SET status = $1, next_run_date = NULL, next_run_at = NULL, cancelled_at = $2, updated_at = $2
+			WHERE id = $3
+		`, model.ScheduledTransferStatusCancelled, now, schedule.ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to cancel scheduled transfer: %w", err)
+		}
+		if err := dbTx.Commit(ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		schedule.Status = model.ScheduledTransferStatusCancelled
+		return schedule, nil, nil
+	}
+
+	date := *schedule.NextRunDate
+	tx, parties := schedule.Transfer(date, now)
+
+	// The idempotency key is deterministic, so a transfer already made for this date is linked rather than repeated
+	var existingID uuid.UUID
+	err = dbTx.QueryRow(ctx, `SELECT id FROM transactions WHERE idempotency_key = $1`, tx.IdempotencyKey).Scan(&existingID)
+	switch {
+	case err == nil:
+		tx.ID = existingID
+	case errors.Is(err, pgx.ErrNoRows):
+		// Each occurrence pays the fee in the schedule on the day it is made
+		fee, revenueID, err := quoteFee(ctx, dbTx, source, schedule.Amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fee.IsPositive() {
+			tx.FeeAmount = fee.String()
+			parties = append(parties, model.TransactionParty{ID: uuid.New(), TransactionID: tx.ID, AccountID: revenueID, Role: "fee"})
+		}
+		if err := insertTransaction(ctx, dbTx, tx, parties); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("failed to check scheduled transfer idempotency: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, `
+		INSERT INTO scheduled_transfer_occurrences (id, scheduled_transfer_id, occurrence_date, transaction_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scheduled_transfer_id, occurrence_date) DO NOTHING
+	`, uuid.New(), schedule.ID, date, tx.ID, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to record scheduled transfer occurrence: %w", err)
+	}
+
+	schedule.Advance(schedule.Occurrences + 1)
+	_, err = dbTx.Exec(ctx, `
+		UPDATE scheduled_transfers
+		SET status = $1, occurrences = $2, next_run_date = $3, next_run_at = $4, updated_at = $5
+		WHERE id = $6
+	`, schedule.Status, schedule.Occurrences, schedule.NextRunDate, schedule.NextRunAt, now, schedule.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to advance scheduled transfer: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return schedule, &tx, nil
+}
+
+// scanScheduledTransfer scans a row selected with scheduledTransferColumns
+func scanScheduledTransfer(row pgx.Row) (*model.ScheduledTransfer, error) {
+	s := &model.ScheduledTransfer{}
+	var amount string
+	var reference *string
+	err := row.Scan(
+		&s.ID,
+		&s.CustomerID,
+		&s.FromAccountID,
+		&s.ToAccountID,
+		&amount,
+		&s.Currency,
+		&reference,
+		&s.Frequency,
+		&s.StartDate,
+		&s.EndDate,
+		&s.Timezone,
+		&s.Status,
+		&s.Occurrences,
+		&s.NextRunDate,
+		&s.NextRunAt,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+		&s.CancelledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if reference != nil {
+		s.Reference = *reference
+	}
+	if s.Amount, err = model.ParseMoney(amount, s.Currency); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduled transfer amount: %w", err)
+	}
+	return s, nil
+}
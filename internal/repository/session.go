@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// sessionTouchInterval limits how often an authenticated request updates last_used_at
+const sessionTouchInterval = time.Minute
+
+// SessionRepository handles database operations for login sessions
+type SessionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSessionRepository creates a new SessionRepository
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create stores a new session together with its first refresh token
+func (r *SessionRepository) Create(ctx context.Context, session *model.Session, token *model.RefreshToken) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	_, err = dbTx.Exec(ctx, `
+		INSERT INTO sessions (id, customer_id, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $5, $6)
+	`, session.ID, session.CustomerID, session.UserAgent, session.IPAddress, session.CreatedAt, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, `
+		INSERT INTO refresh_tokens (id, customer_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, token.ID, token.CustomerID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Touch reports whether a session is still active and records that it was used
+// last_used_at is written at most once per sessionTouchInterval to keep requests cheap
+func (r *SessionRepository) Touch(ctx context.Context, id uuid.UUID) (bool, error) {
+	now := time.Now()
+
+	query := `
+		WITH touched AS (
+			UPDATE sessions
+			SET last_used_at = $2
+			WHERE id = $1 AND revoked_at IS NULL AND expires_at > $2 AND last_used_at < $3
+		)
+		SELECT revoked_at IS NULL AND expires_at > $2
+		FROM sessions
+		WHERE id = $1
+	`
+
+	var active bool
+	err := r.db.QueryRow(ctx, query, id, now, now.Add(-sessionTouchInterval)).Scan(&active)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return active, nil
+}
+
+// ListActive returns a customer's sessions that are neither revoked nor expired, most recently used first
+func (r *SessionRepository) ListActive(ctx context.Context, customerID uuid.UUID) ([]model.Session, error) {
+	query := `
+		SELECT id, customer_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_used_at, expires_at
+		FROM sessions
+		WHERE customer_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, customerID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []model.Session{}
+	for rows.Next() {
+		var session model.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.CustomerID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Revoke ends a session and revokes its refresh tokens
+func (r *SessionRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	_, err := r.revoke(ctx, `id = $2`, id)
+	return err
+}
+
+// RevokeForCustomer ends one of a customer's sessions
+// Returns ErrSessionNotFound if the session does not belong to the customer or is already revoked
+func (r *SessionRepository) RevokeForCustomer(ctx context.Context, customerID, id uuid.UUID) error {
+	revoked, err := r.revoke(ctx, `id = $2 AND customer_id = $3`, id, customerID)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return model.ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllForCustomer ends every session a customer has (log out everywhere)
+// Returns the number of sessions revoked
+func (r *SessionRepository) RevokeAllForCustomer(ctx context.Context, customerID uuid.UUID) (int64, error) {
+	return r.revoke(ctx, `customer_id = $2`, customerID)
+}
+
+// revoke marks matching active sessions revoked along with their refresh tokens
+// where is a condition on sessions whose parameters start at $2 ($1 is the revocation time)
+func (r *SessionRepository) revoke(ctx context.Context, where string, args ...any) (int64, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	revoked, err := revokeSessions(ctx, dbTx, where, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return revoked, nil
+}
+
+// revokeSessions revokes sessions and their refresh tokens within an existing transaction
+// Other repositories use it when revoking sessions is part of a larger change, such as a password reset
+func revokeSessions(ctx context.Context, dbTx pgx.Tx, where string, args ...any) (int64, error) {
+	params := append([]any{time.Now()}, args...)
+
+	rows, err := dbTx.Query(ctx, `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE revoked_at IS NULL AND `+where+`
+		RETURNING id
+	`, params...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	if len(ids) > 0 {
+		_, err = dbTx.Exec(ctx, `
+			UPDATE refresh_tokens
+			SET revoked_at = $1
+			WHERE family_id = ANY($2) AND revoked_at IS NULL
+		`, params[0], ids)
+		if err != nil {
+			return 0, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+	}
+
+	return int64(len(ids)), nil
+}
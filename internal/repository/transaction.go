@@ -23,7 +23,7 @@ func NewTransactionRepository(db *pgxpool.Pool) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-// Create inserts a new transaction and its parties into the database
+// Create inserts a new transaction, its parties and its outbox entry into the database
 func (r *TransactionRepository) Create(ctx context.Context, tx model.Transaction, parties []model.TransactionParty) (*model.Transaction, error) {
 	// Start a database transaction
 	dbTx, err := r.db.Begin(ctx)
@@ -32,10 +32,24 @@ func (r *TransactionRepository) Create(ctx context.Context, tx model.Transaction
 	}
 	defer dbTx.Rollback(ctx)
 
+	if err := insertTransaction(ctx, dbTx, tx, parties); err != nil {
+		return nil, err
+	}
+
+	if err = dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &tx, nil
+}
+
+// insertTransaction inserts a transaction, its parties and its outbox entry within dbTx
+// Returns ErrTransactionExists if the idempotency key is taken
+func insertTransaction(ctx context.Context, dbTx pgx.Tx, tx model.Transaction, parties []model.TransactionParty) error {
 	// Insert the transaction
 	query := `
-		INSERT INTO transactions (id, idempotency_key, type, status, reference, initiated_at, metadata, amount, currency, from_account_id, to_account_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO transactions (id, idempotency_key, type, status, reference, initiated_at, metadata, amount, currency, from_account_id, to_account_id, fee_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')::numeric)
 	`
 
 	metadata := tx.Metadata
@@ -43,7 +57,7 @@ func (r *TransactionRepository) Create(ctx context.Context, tx model.Transaction
 		metadata = map[string]any{}
 	}
 
-	_, err = dbTx.Exec(ctx, query,
+	_, err := dbTx.Exec(ctx, query,
 		tx.ID,
 		tx.IdempotencyKey,
 		tx.Type,
@@ -55,13 +69,14 @@ func (r *TransactionRepository) Create(ctx context.Context, tx model.Transaction
 		tx.Currency,
 		tx.FromAccountID,
 		tx.ToAccountID,
+		tx.FeeAmount,
 	)
 	if err != nil {
 		// Check for unique constraint violation on idempotency_key
 		if isUniqueViolation(err) {
-			return nil, model.ErrTransactionExists
+			return model.ErrTransactionExists
 		}
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+		return fmt.Errorf("failed to create transaction: %w", err)
 	}
 
 	// Insert transaction parties
@@ -77,27 +92,48 @@ func (r *TransactionRepository) Create(ctx context.Context, tx model.Transaction
 			party.Role,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create transaction party: %w", err)
+			return fmt.Errorf("failed to create transaction party: %w", err)
 		}
 	}
 
-	if err = dbTx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	// Record the transaction in the outbox in the same database transaction,
+	// so it is guaranteed to reach the queue even if the caller's publish fails
+	_, err = dbTx.Exec(ctx, `
+		INSERT INTO transaction_outbox (transaction_id, transaction_type, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $3)
+	`, tx.ID, tx.Type, tx.InitiatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox entry: %w", err)
 	}
 
-	return &tx, nil
+	return nil
+}
+
+// MarkOutboxPublished marks a transaction's outbox entry as published
+// Used after a successful direct publish so the outbox relay does not send it again
+func (r *TransactionRepository) MarkOutboxPublished(ctx context.Context, transactionID uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE transaction_outbox
+		SET published_at = NOW(), attempts = attempts + 1
+		WHERE transaction_id = $1 AND published_at IS NULL
+	`, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox entry published: %w", err)
+	}
+	return nil
 }
 
 // GetByID retrieves a transaction by its ID
 func (r *TransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Transaction, error) {
 	query := `
-		SELECT id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, error_message, metadata, amount, currency, from_account_id, to_account_id
-		FROM transactions
+		SELECT id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, error_message, metadata, amount, currency, from_account_id, to_account_id, fee_amount,
+			reversal_of, (SELECT r.id FROM transactions r WHERE r.reversal_of = t.id)
+		FROM transactions t
 		WHERE id = $1
 	`
 
 	tx := &model.Transaction{}
-	var reference, errorMessage, amount, currency *string
+	var reference, errorMessage, amount, currency, feeAmount *string
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&tx.ID,
 		&tx.IdempotencyKey,
@@ -113,6 +149,9 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 		&currency,
 		&tx.FromAccountID,
 		&tx.ToAccountID,
+		&feeAmount,
+		&tx.ReversalOf,
+		&tx.ReversedBy,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -133,6 +172,9 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 	if currency != nil {
 		tx.Currency = *currency
 	}
+	if feeAmount != nil {
+		tx.FeeAmount = *feeAmount
+	}
 
 	return tx, nil
 }
@@ -140,13 +182,14 @@ func (r *TransactionRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 // GetByIdempotencyKey retrieves a transaction by its idempotency key
 func (r *TransactionRepository) GetByIdempotencyKey(ctx context.Context, key string) (*model.Transaction, error) {
 	query := `
-		SELECT id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, error_message, metadata, amount, currency, from_account_id, to_account_id
-		FROM transactions
+		SELECT id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, error_message, metadata, amount, currency, from_account_id, to_account_id, fee_amount,
+			reversal_of, (SELECT r.id FROM transactions r WHERE r.reversal_of = t.id)
+		FROM transactions t
 		WHERE idempotency_key = $1
 	`
 
 	tx := &model.Transaction{}
-	var reference, errorMessage, amount, currency *string
+	var reference, errorMessage, amount, currency, feeAmount *string
 	err := r.db.QueryRow(ctx, query, key).Scan(
 		&tx.ID,
 		&tx.IdempotencyKey,
@@ -162,6 +205,9 @@ func (r *TransactionRepository) GetByIdempotencyKey(ctx context.Context, key str
 		&currency,
 		&tx.FromAccountID,
 		&tx.ToAccountID,
+		&feeAmount,
+		&tx.ReversalOf,
+		&tx.ReversedBy,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -182,6 +228,9 @@ func (r *TransactionRepository) GetByIdempotencyKey(ctx context.Context, key str
 	if currency != nil {
 		tx.Currency = *currency
 	}
+	if feeAmount != nil {
+		tx.FeeAmount = *feeAmount
+	}
 
 	return tx, nil
 }
@@ -275,11 +324,11 @@ func (r *TransactionRepository) ClaimForProcessing(ctx context.Context, id uuid.
 		UPDATE transactions
 		SET status = $1, processed_at = $2
 		WHERE id = $3 AND status = $4
-		RETURNING id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, error_message, metadata, amount, currency, from_account_id, to_account_id
+		RETURNING id, idempotency_key, type, status, reference, initiated_at, processed_at, completed_at, error_message, metadata, amount, currency, from_account_id, to_account_id, fee_amount
 	`
 
 	tx := &model.Transaction{}
-	var reference, errorMessage, amount, currency *string
+	var reference, errorMessage, amount, currency, feeAmount *string
 	err := r.db.QueryRow(ctx, query,
 		model.TransactionStatusProcessing,
 		now,
@@ -300,6 +349,7 @@ func (r *TransactionRepository) ClaimForProcessing(ctx context.Context, id uuid.
 		&currency,
 		&tx.FromAccountID,
 		&tx.ToAccountID,
+		&feeAmount,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -321,10 +371,47 @@ func (r *TransactionRepository) ClaimForProcessing(ctx context.Context, id uuid.
 	if currency != nil {
 		tx.Currency = *currency
 	}
+	if feeAmount != nil {
+		tx.FeeAmount = *feeAmount
+	}
 
 	return tx, nil
 }
 
+// ListStuckPending returns transactions that have been pending since before olderThan, oldest first
+// Transactions in exclude are left out before the limit applies, so they cannot fill every batch.
+func (r *TransactionRepository) ListStuckPending(ctx context.Context, olderThan time.Time, limit int, exclude []uuid.UUID) ([]model.Transaction, error) {
+	query := `
+		SELECT id, type, status, initiated_at, processed_at
+		FROM transactions
+		WHERE status = $1 AND initiated_at < $2 AND id <> ALL($4)
+		ORDER BY initiated_at
+		LIMIT $3
+	`
+
+	// A NULL array would exclude every row
+	if exclude == nil {
+		exclude = []uuid.UUID{}
+	}
+
+	rows, err := r.db.Query(ctx, query, model.TransactionStatusPending, olderThan, limit, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stuck transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []model.Transaction
+	for rows.Next() {
+		var tx model.Transaction
+		if err := rows.Scan(&tx.ID, &tx.Type, &tx.Status, &tx.InitiatedAt, &tx.ProcessedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stuck transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, rows.Err()
+}
+
 // isUniqueViolation checks if the error is a unique constraint violation
 func isUniqueViolation(err error) bool {
 	// PostgreSQL error code for unique_violation is 23505
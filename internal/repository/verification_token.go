@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+)
+
+// VerificationTokenRepository handles database operations for email verification and password reset tokens
+type VerificationTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewVerificationTokenRepository creates a new VerificationTokenRepository
+func NewVerificationTokenRepository(db *pgxpool.Pool) *VerificationTokenRepository {
+	return &VerificationTokenRepository{db: db}
+}
+
+// Create stores a new token, invalidating the customer's unused tokens for the same purpose
+// Only the most recently sent link works
+func (r *VerificationTokenRepository) Create(ctx context.Context, token *model.VerificationToken) error {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	_, err = dbTx.Exec(ctx, `
+		UPDATE verification_tokens
+		SET used_at = $1
+		WHERE customer_id = $2 AND purpose = $3 AND used_at IS NULL
+	`, token.CreatedAt, token.CustomerID, token.Purpose)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate previous tokens: %w", err)
+	}
+
+	_, err = dbTx.Exec(ctx, `
+		INSERT INTO verification_tokens (id, customer_id, purpose, email, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, token.ID, token.CustomerID, token.Purpose, token.Email, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create token: %w", err)
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail consumes an email verification token and marks the customer's email verified
+// Returns ErrVerificationTokenInvalid if the token is unknown, used or expired,
+// or if the customer's email changed since it was sent
+func (r *VerificationTokenRepository) VerifyEmail(ctx context.Context, tokenHash string) (*model.VerificationToken, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	token, err := consumeVerificationToken(ctx, dbTx, tokenHash, model.TokenPurposeEmailVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := dbTx.Exec(ctx, `
+		UPDATE customers
+		SET email_verified = TRUE, updated_at = NOW()
+		WHERE id = $1 AND email = $2
+	`, token.CustomerID, token.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, model.ErrVerificationTokenInvalid
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return token, nil
+}
+
+// ResetPassword consumes a password reset token and sets the new password hash
+// The account is unlocked, the email counts as verified (the customer received the link),
+// and every session is revoked so a stolen session does not survive the reset.
+// Returns ErrVerificationTokenInvalid if the token is unknown, used or expired
+func (r *VerificationTokenRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*model.VerificationToken, error) {
+	dbTx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer dbTx.Rollback(ctx)
+
+	token, err := consumeVerificationToken(ctx, dbTx, tokenHash, model.TokenPurposePasswordReset)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := dbTx.Exec(ctx, `
+		UPDATE customers
+		SET password_hash = $1, password_changed_at = NOW(),
+			failed_login_attempts = 0, locked_until = NULL,
+			email_verified = TRUE, updated_at = NOW()
+		WHERE id = $2 AND email = $3
+	`, passwordHash, token.CustomerID, token.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, model.ErrVerificationTokenInvalid
+	}
+
+	if _, err := revokeSessions(ctx, dbTx, `customer_id = $2`, token.CustomerID); err != nil {
+		return nil, err
+	}
+
+	if err := dbTx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return token, nil
+}
+
+// consumeVerificationToken marks an unused, unexpired token used and returns it
+func consumeVerificationToken(ctx context.Context, dbTx pgx.Tx, tokenHash string, purpose model.TokenPurpose) (*model.VerificationToken, error) {
+	query := `
+		UPDATE verification_tokens
+		SET used_at = $1
+		WHERE token_hash = $2 AND purpose = $3 AND used_at IS NULL AND expires_at > $1
+		RETURNING id, customer_id, purpose, email, token_hash, expires_at, created_at, used_at
+	`
+
+	token := &model.VerificationToken{}
+	err := dbTx.QueryRow(ctx, query, time.Now(), tokenHash, purpose).Scan(
+		&token.ID,
+		&token.CustomerID,
+		&token.Purpose,
+		&token.Email,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, model.ErrVerificationTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to consume token: %w", err)
+	}
+
+	return token, nil
+}
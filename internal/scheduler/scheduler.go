@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/repository"
+)
+
+// DefaultBatchSize is the max number of occurrences materialized in one run
+const DefaultBatchSize = 500
+
+// Scheduler periodically turns due occurrences of standing orders into normal pending transfers
+// Each transfer gets the idempotency key scheduled:<schedule id>:<date> and is queued through the
+// outbox, so the processor checks funds, account status and limits exactly as for any other transfer.
+// Occurrences missed while the worker was down are all made on the next run.
+type Scheduler struct {
+	repo      *repository.ScheduledTransferRepository
+	interval  time.Duration
+	batchSize int
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewScheduler creates a new Scheduler that looks for due occurrences every interval
+func NewScheduler(repo *repository.ScheduledTransferRepository, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		repo:      repo,
+		interval:  interval,
+		batchSize: DefaultBatchSize,
+	}
+}
+
+// Report is the outcome of a single run
+type Report struct {
+	RanAt        time.Time
+	Materialized int // Occurrences turned into pending transfers
+	Completed    int // Standing orders whose last occurrence was materialized
+	Cancelled    int // Standing orders cancelled because their source account was closed
+}
+
+// Touched returns the number of standing orders the run changed
+func (r *Report) Touched() int {
+	return r.Materialized + r.Cancelled
+}
+
+// Stats are cumulative counters across all runs since the scheduler started
+type Stats struct {
+	Runs         int64
+	Materialized int64
+	Completed    int64
+	Cancelled    int64
+}
+
+// Stats returns a snapshot of the cumulative counters
+func (s *Scheduler) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// RunOnce materializes occurrences that are due, up to the batch size
+func (s *Scheduler) RunOnce(ctx context.Context) (*Report, error) {
+	report := &Report{RanAt: time.Now()}
+
+	for report.Touched() < s.batchSize {
+		schedule, tx, err := s.repo.MaterializeNext(ctx, report.RanAt)
+		if err != nil {
+			s.record(report)
+			return nil, err
+		}
+		if schedule == nil {
+			break
+		}
+
+		if tx == nil {
+			log.Printf("Scheduler: scheduled transfer %s cancelled, source account %s is closed", schedule.ID, schedule.FromAccountID)
+			report.Cancelled++
+			continue
+		}
+
+		report.Materialized++
+		if schedule.Status == model.ScheduledTransferStatusCompleted {
+			report.Completed++
+		}
+	}
+
+	s.record(report)
+	return report, nil
+}
+
+// record adds a run's counts to the cumulative stats
+func (s *Scheduler) record(report *Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Runs++
+	s.stats.Materialized += int64(report.Materialized)
+	s.stats.Completed += int64(report.Completed)
+	s.stats.Cancelled += int64(report.Cancelled)
+}
+
+// Start runs the scheduler on every interval until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	log.Printf("Transfer scheduler started (interval: %s)", s.interval)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Transfer scheduler stopping")
+			return
+		case <-ticker.C:
+		}
+
+		report, err := s.RunOnce(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Transfer scheduler run failed: %v", err)
+			continue
+		}
+
+		if report.Touched() > 0 {
+			stats := s.Stats()
+			log.Printf("Transfer scheduler: %d occurrences materialized, %d standing orders completed, %d cancelled (totals: %d materialized over %d runs)",
+				report.Materialized, report.Completed, report.Cancelled, stats.Materialized, stats.Runs)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package scheduler
+
+import "testing"
+
+func TestReportTouched(t *testing.T) {
+	report := &Report{Materialized: 3, Completed: 1, Cancelled: 2}
+
+	if got := report.Touched(); got != 5 {
+		t.Errorf("Touched() = %d, want 5", got)
+	}
+}
+
+func TestSchedulerRecord(t *testing.T) {
+	s := &Scheduler{}
+
+	s.record(&Report{Materialized: 2, Completed: 1})
+	s.record(&Report{Materialized: 1, Cancelled: 1})
+
+	want := Stats{Runs: 2, Materialized: 3, Completed: 1, Cancelled: 1}
+	if got := s.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package sweeper
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/queue"
+	"github.com/simonkvalheim/hm9-banking/internal/repository"
+)
+
+// DefaultBatchSize is the max number of transactions per state handled in one sweep
+const DefaultBatchSize = 100
+
+// Sweeper periodically re-enqueues transactions stuck in pending
+// A pending transaction has no message left when publishing failed or synchronous processing
+// errored. Transactions never stay in processing: the processor claims, books and completes
+// or fails a transaction in one database transaction, so a failure rolls it back to pending.
+type Sweeper struct {
+	txRepo    transactionStore
+	publisher transactionQueue
+	interval  time.Duration
+	maxAge    time.Duration
+	batchSize int
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// transactionStore is the part of repository.TransactionRepository the sweeper uses
+type transactionStore interface {
+	ListStuckPending(ctx context.Context, olderThan time.Time, limit int, exclude []uuid.UUID) ([]model.Transaction, error)
+}
+
+// transactionQueue is the part of queue.Publisher the sweeper uses
+type transactionQueue interface {
+	PublishTransaction(ctx context.Context, transactionID uuid.UUID, txType string) error
+	HeldTransactionIDs(ctx context.Context) (*queue.HeldTransactions, error)
+}
+
+// NewSweeper creates a new Sweeper that touches transactions older than maxAge every interval
+func NewSweeper(txRepo *repository.TransactionRepository, publisher *queue.Publisher, interval, maxAge time.Duration) *Sweeper {
+	return &Sweeper{
+		txRepo:    txRepo,
+		publisher: publisher,
+		interval:  interval,
+		maxAge:    maxAge,
+		batchSize: DefaultBatchSize,
+	}
+}
+
+// Report is the outcome of a single sweep
+type Report struct {
+	RanAt                  time.Time
+	PendingRequeued        int // Pending transactions published to the queue again
+	PendingSkippedInFlight int // Transactions a worker holds or that wait for a retry; their message is still queued
+	PendingSkippedDead     int // Dead-lettered transactions left out of the sweep; they wait for an operator
+	Errors                 int
+}
+
+// Touched returns the number of transactions the sweep re-enqueued
+func (r *Report) Touched() int {
+	return r.PendingRequeued
+}
+
+// Stats are cumulative counters across all sweeps since the sweeper started
+type Stats struct {
+	Runs            int64
+	PendingRequeued int64
+	Errors          int64
+}
+
+// Stats returns a snapshot of the cumulative sweep counters
+func (s *Sweeper) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// RunOnce performs a single sweep of stale pending transactions
+func (s *Sweeper) RunOnce(ctx context.Context) (*Report, error) {
+	report := &Report{RanAt: time.Now()}
+	cutoff := report.RanAt.Add(-s.maxAge)
+
+	// A transaction that still has a message outside the pending list is left alone: publishing it
+	// again would process it twice over. Dead-lettered ones wait for an operator to requeue them.
+	// They are left out by the query rather than skipped here: they are the oldest, and would
+	// otherwise fill every batch.
+	held, err := s.publisher.HeldTransactionIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exclude := make([]uuid.UUID, 0, len(held.InFlight)+len(held.Dead))
+	for id := range held.InFlight {
+		exclude = append(exclude, id)
+	}
+	for id := range held.Dead {
+		if !held.InFlight[id] {
+			exclude = append(exclude, id)
+		}
+	}
+	report.PendingSkippedInFlight = len(held.InFlight)
+	report.PendingSkippedDead = len(held.Dead)
+
+	pending, err := s.txRepo.ListStuckPending(ctx, cutoff, s.batchSize, exclude)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range pending {
+		if err := s.publisher.PublishTransaction(ctx, tx.ID, string(tx.Type)); err != nil {
+			log.Printf("Sweeper: failed to re-enqueue transaction %s: %v", tx.ID, err)
+			report.Errors++
+			continue
+		}
+		report.PendingRequeued++
+	}
+
+	s.record(report)
+	return report, nil
+}
+
+// record adds a sweep's counts to the cumulative stats
+func (s *Sweeper) record(report *Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Runs++
+	s.stats.PendingRequeued += int64(report.PendingRequeued)
+	s.stats.Errors += int64(report.Errors)
+}
+
+// Start sweeps on every interval until ctx is cancelled
+func (s *Sweeper) Start(ctx context.Context) {
+	log.Printf("Transaction sweeper started (interval: %s, max age: %s)", s.interval, s.maxAge)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Transaction sweeper stopping")
+			return
+		case <-ticker.C:
+		}
+
+		report, err := s.RunOnce(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Transaction sweep failed: %v", err)
+			continue
+		}
+
+		if report.Touched() > 0 || report.Errors > 0 {
+			stats := s.Stats()
+			log.Printf("Transaction sweep: %d pending requeued, %d in flight skipped, %d dead-lettered skipped, %d errors (totals: %d requeued, %d errors over %d runs)",
+				report.PendingRequeued, report.PendingSkippedInFlight, report.PendingSkippedDead, report.Errors,
+				stats.PendingRequeued, stats.Errors, stats.Runs)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/simonkvalheim/hm9-banking/internal/model"
+	"github.com/simonkvalheim/hm9-banking/internal/queue"
+)
+
+func TestReportTouched(t *testing.T) {
+	report := &Report{
+		PendingRequeued:        3,
+		PendingSkippedInFlight: 4,
+		PendingSkippedDead:     2,
+		Errors:                 5,
+	}
+
+	if got := report.Touched(); got != 3 {
+		t.Errorf("Touched() = %d, want 3", got)
+	}
+}
+
+func TestSweeperRecord(t *testing.T) {
+	s := &Sweeper{}
+
+	s.record(&Report{PendingRequeued: 2, PendingSkippedDead: 3})
+	s.record(&Report{PendingRequeued: 1, Errors: 1})
+
+	want := Stats{Runs: 2, PendingRequeued: 3, Errors: 1}
+	if got := s.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+// fakeStore holds stuck pending transactions oldest first
+type fakeStore struct {
+	pending []model.Transaction
+}
+
+func (f *fakeStore) ListStuckPending(ctx context.Context, olderThan time.Time, limit int, exclude []uuid.UUID) ([]model.Transaction, error) {
+	excluded := make(map[uuid.UUID]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+
+	var stuck []model.Transaction
+	for _, tx := range f.pending {
+		if len(stuck) == limit {
+			break
+		}
+		if !excluded[tx.ID] {
+			stuck = append(stuck, tx)
+		}
+	}
+	return stuck, nil
+}
+
+// fakeQueue records published transactions
+type fakeQueue struct {
+	inFlight  map[uuid.UUID]bool
+	dead      map[uuid.UUID]bool
+	failFor   map[uuid.UUID]bool
+	published []uuid.UUID
+}
+
+func (f *fakeQueue) PublishTransaction(ctx context.Context, transactionID uuid.UUID, txType string) error {
+	if f.failFor[transactionID] {
+		return errors.New("redis unavailable")
+	}
+	f.published = append(f.published, transactionID)
+	return nil
+}
+
+func (f *fakeQueue) HeldTransactionIDs(ctx context.Context) (*queue.HeldTransactions, error) {
+	return &queue.HeldTransactions{InFlight: f.inFlight, Dead: f.dead}, nil
+}
+
+func stuckTransactions(n int) []model.Transaction {
+	txs := make([]model.Transaction, n)
+	for i := range txs {
+		txs[i] = model.Transaction{ID: uuid.New(), Type: model.TransactionTypeTransfer, Status: model.TransactionStatusPending}
+	}
+	return txs
+}
+
+func TestSweeperRunOnce_SkipsHeldTransactions(t *testing.T) {
+	// The held transactions are the oldest and would fill a batch of two on their own:
+	// one dead-lettered, one leased by a worker and one waiting out its retry backoff
+	pending := stuckTransactions(5)
+	store := &fakeStore{pending: pending}
+	publisher := &fakeQueue{
+		inFlight: map[uuid.UUID]bool{pending[1].ID: true, pending[2].ID: true},
+		dead:     map[uuid.UUID]bool{pending[0].ID: true},
+	}
+	s := &Sweeper{txRepo: store, publisher: publisher, batchSize: 2}
+
+	report, err := s.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	if report.PendingRequeued != 2 || report.PendingSkippedInFlight != 2 || report.PendingSkippedDead != 1 {
+		t.Errorf("RunOnce() = %d requeued, %d in flight, %d dead; want 2, 2, 1", report.PendingRequeued, report.PendingSkippedInFlight, report.PendingSkippedDead)
+	}
+	want := []uuid.UUID{pending[3].ID, pending[4].ID}
+	if len(publisher.published) != len(want) || publisher.published[0] != want[0] || publisher.published[1] != want[1] {
+		t.Errorf("published %v, want %v", publisher.published, want)
+	}
+}
+
+func TestSweeperRunOnce_PublishFailure(t *testing.T) {
+	pending := stuckTransactions(2)
+	store := &fakeStore{pending: pending}
+	publisher := &fakeQueue{failFor: map[uuid.UUID]bool{pending[0].ID: true}}
+	s := &Sweeper{txRepo: store, publisher: publisher, batchSize: DefaultBatchSize}
+
+	report, err := s.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	// A failed publish is counted and left for the next sweep; the rest still go out
+	if report.PendingRequeued != 1 || report.Errors != 1 {
+		t.Errorf("RunOnce() = %d requeued, %d errors; want 1, 1", report.PendingRequeued, report.Errors)
+	}
+	if got := s.Stats(); got.Runs != 1 || got.PendingRequeued != 1 || got.Errors != 1 {
+		t.Errorf("Stats() = %+v, want 1 run, 1 requeued, 1 error", got)
+	}
+}
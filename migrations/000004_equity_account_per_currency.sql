@@ -0,0 +1,16 @@
+-- +goose Up
+-- Equity accounts are now keyed by currency (BANK-EQUITY-NOK, BANK-EQUITY-EUR, ...)
+UPDATE accounts
+SET account_number = 'BANK-EQUITY-' || currency, updated_at = NOW()
+WHERE account_number = 'BANK-EQUITY-001';
+
+-- At most one equity account per currency
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_equity_currency
+  ON accounts (currency) WHERE account_type = 'equity';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_accounts_equity_currency;
+
+UPDATE accounts
+SET account_number = 'BANK-EQUITY-001', updated_at = NOW()
+WHERE account_number = 'BANK-EQUITY-NOK';
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Supports keyset pagination of an account's history: WHERE account_id = $1 ORDER BY created_at DESC, id DESC
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_created_id
+  ON ledger_entries (account_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_ledger_entries_account_created_id;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- Materialized current balance per account, maintained by the processor in the same
+-- database transaction that writes ledger_entries. ledger_entries remains the source of truth.
+CREATE TABLE IF NOT EXISTS account_balances (
+  account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE RESTRICT,
+  balance DECIMAL(19,4) NOT NULL DEFAULT 0,
+  version BIGINT NOT NULL DEFAULT 0,           -- Incremented on every applied ledger entry
+  last_entry_id UUID REFERENCES ledger_entries(id),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Backfill from the existing ledger
+INSERT INTO account_balances (account_id, balance, version, last_entry_id)
+SELECT
+  a.id,
+  COALESCE(SUM(le.amount), 0),
+  COUNT(le.id),
+  (SELECT le2.id FROM ledger_entries le2
+   WHERE le2.account_id = a.id
+   ORDER BY le2.created_at DESC, le2.id DESC
+   LIMIT 1)
+FROM accounts a
+LEFT JOIN ledger_entries le ON le.account_id = a.id
+GROUP BY a.id
+ON CONFLICT (account_id) DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS account_balances;
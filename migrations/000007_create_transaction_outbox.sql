@@ -0,0 +1,21 @@
+-- +goose Up
+-- Transactional outbox: written in the same database transaction as the transaction row,
+-- then relayed to the Redis queue so no accepted transfer can be lost between Postgres and Redis
+CREATE TABLE IF NOT EXISTS transaction_outbox (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  transaction_id UUID NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE CASCADE,
+  transaction_type VARCHAR(20) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  published_at TIMESTAMPTZ,
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_error VARCHAR(500)
+);
+
+-- The relay only ever scans unsent rows
+CREATE INDEX IF NOT EXISTS idx_transaction_outbox_unsent
+  ON transaction_outbox (next_attempt_at) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transaction_outbox_unsent;
+DROP TABLE IF EXISTS transaction_outbox;
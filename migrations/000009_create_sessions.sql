@@ -0,0 +1,32 @@
+-- +goose Up
+-- A session is one login on one device. Its ID is the family_id shared by
+-- every refresh token issued from that login, and it is carried in access tokens
+-- so revoking a session takes effect immediately.
+CREATE TABLE IF NOT EXISTS sessions (
+  id UUID PRIMARY KEY,
+  customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+  user_agent VARCHAR(500),
+  ip_address VARCHAR(45),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_customer ON sessions (customer_id) WHERE revoked_at IS NULL;
+
+-- Every existing refresh token family becomes a session
+INSERT INTO sessions (id, customer_id, created_at, last_used_at, expires_at, revoked_at)
+SELECT family_id, customer_id, MIN(created_at), MAX(created_at), MAX(expires_at),
+       CASE WHEN BOOL_AND(revoked_at IS NOT NULL) THEN MAX(revoked_at) END
+FROM refresh_tokens
+GROUP BY family_id, customer_id
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE refresh_tokens
+  ADD CONSTRAINT fk_refresh_tokens_session FOREIGN KEY (family_id) REFERENCES sessions(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE refresh_tokens DROP CONSTRAINT IF EXISTS fk_refresh_tokens_session;
+DROP INDEX IF EXISTS idx_sessions_customer;
+DROP TABLE IF EXISTS sessions;
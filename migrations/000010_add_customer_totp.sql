@@ -0,0 +1,19 @@
+-- +goose Up
+-- Optional TOTP (RFC 6238) two-factor authentication, stored on the customer row.
+-- totp_secret is set at enrollment and totp_enabled only once a first code is verified.
+-- totp_last_step blocks replaying a code within its validity window.
+-- Recovery codes are stored as SHA-256 hashes and removed from the array when used.
+ALTER TABLE customers
+  ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64),
+  ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS totp_last_step BIGINT,
+  ADD COLUMN IF NOT EXISTS totp_recovery_codes TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE customers
+  DROP COLUMN IF EXISTS totp_recovery_codes,
+  DROP COLUMN IF EXISTS totp_last_step,
+  DROP COLUMN IF EXISTS totp_enabled_at,
+  DROP COLUMN IF EXISTS totp_enabled,
+  DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Single-use tokens sent by email: address verification and password reset.
+-- Only a SHA-256 hash of each token is stored. email records the address the token
+-- was sent to, so a verification link stops working if the address changes.
+CREATE TABLE IF NOT EXISTS verification_tokens (
+  id UUID PRIMARY KEY,
+  customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+  purpose VARCHAR(32) NOT NULL CHECK (purpose IN ('email_verification', 'password_reset')),
+  email VARCHAR(255) NOT NULL,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  expires_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_verification_tokens_customer ON verification_tokens (customer_id, purpose) WHERE used_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_verification_tokens_customer;
+DROP TABLE IF EXISTS verification_tokens;
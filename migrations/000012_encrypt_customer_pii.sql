@@ -0,0 +1,20 @@
+-- +goose Up
+-- National ID numbers and dates of birth are envelope-encrypted by the application
+-- (see internal/pii). Ciphertexts are text: v1:<key id>:<sealed data key>:<sealed value>.
+-- national_id_index is a keyed hash (blind index) for exact-match lookup without decrypting.
+-- Existing plaintext values stay readable until `go run ./cmd/piiadmin reencrypt` encrypts them;
+-- after that, date_of_birth is always NULL.
+ALTER TABLE customers ALTER COLUMN national_id_number TYPE TEXT;
+ALTER TABLE customers
+  ADD COLUMN IF NOT EXISTS national_id_index CHAR(64),
+  ADD COLUMN IF NOT EXISTS date_of_birth_encrypted TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_customers_national_id_index ON customers (national_id_index);
+
+-- +goose Down
+-- Encrypted values cannot be decrypted in SQL; run this only before any data was encrypted
+DROP INDEX IF EXISTS idx_customers_national_id_index;
+ALTER TABLE customers
+  DROP COLUMN IF EXISTS date_of_birth_encrypted,
+  DROP COLUMN IF EXISTS national_id_index;
+ALTER TABLE customers ALTER COLUMN national_id_number TYPE VARCHAR(50);
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Every freeze, unfreeze and close of an account, with who did it and why.
+-- sweep_transaction_id is the transfer that moved the remaining balance when an account was closed.
+CREATE TABLE IF NOT EXISTS account_status_history (
+  id UUID PRIMARY KEY,
+  account_id UUID NOT NULL REFERENCES accounts(id),
+  from_status VARCHAR(20) NOT NULL,
+  to_status VARCHAR(20) NOT NULL,
+  actor VARCHAR(20) NOT NULL CHECK (actor IN ('customer', 'admin')),
+  actor_customer_id UUID REFERENCES customers(id),
+  reason TEXT,
+  sweep_transaction_id UUID REFERENCES transactions(id),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_status_history_account ON account_status_history (account_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_account_status_history_account;
+DROP TABLE IF EXISTS account_status_history;
@@ -0,0 +1,91 @@
+-- +goose Up
+-- Account numbers become 11-digit Norwegian BBANs (bank code 9710, a six-digit serial from
+-- account_number_seq, MOD11 check digit) with the matching IBAN stored alongside.
+-- Existing customer accounts without a valid BBAN are renumbered, and their old number is kept in
+-- previous_account_number so it still resolves and can be restored. Accounts that already have a
+-- valid BBAN keep it. Bank equity accounts keep their BANK-EQUITY-<CCY> numbers and have no IBAN.
+-- Keep the bank code in sync with accountnumber.BankCode.
+CREATE SEQUENCE IF NOT EXISTS account_number_seq MINVALUE 1 MAXVALUE 999999 NO CYCLE;
+
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS iban VARCHAR(34);
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS previous_account_number VARCHAR(34);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_iban ON accounts (iban);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_previous_account_number ON accounts (previous_account_number)
+  WHERE previous_account_number IS NOT NULL;
+
+-- +goose StatementBegin
+DO $$
+DECLARE
+  acc RECORD;
+  next_serial BIGINT;
+  max_serial BIGINT;
+  first10 TEXT;
+  weighted INT;
+  check_digit INT;
+  bban TEXT;
+  weights INT[] := ARRAY[5, 4, 3, 2, 7, 6, 5, 4, 3, 2];
+BEGIN
+  -- Serials already used by this bank's numbers are not handed out again
+  SELECT MAX(substr(account_number, 5, 6)::bigint) INTO max_serial
+  FROM accounts WHERE account_number ~ '^9710[0-9]{7}$';
+  IF max_serial > 0 THEN
+    PERFORM setval('account_number_seq', GREATEST(max_serial, (SELECT last_value FROM account_number_seq)));
+  END IF;
+
+  FOR acc IN SELECT id, account_number FROM accounts WHERE account_type <> 'equity' AND iban IS NULL ORDER BY created_at, id LOOP
+    bban := NULL;
+
+    -- An account that already has a valid BBAN keeps it
+    IF acc.account_number ~ '^[0-9]{11}$' THEN
+      weighted := 0;
+      FOR i IN 1..10 LOOP
+        weighted := weighted + substr(acc.account_number, i, 1)::int * weights[i];
+      END LOOP;
+      check_digit := 11 - weighted % 11;
+      IF check_digit = 11 THEN
+        check_digit := 0;
+      END IF;
+      IF check_digit::text = substr(acc.account_number, 11, 1) THEN
+        bban := acc.account_number;
+      END IF;
+    END IF;
+
+    IF bban IS NULL THEN
+      -- Serials whose MOD11 remainder would need a check digit of 10 are skipped
+      LOOP
+        next_serial := nextval('account_number_seq');
+        first10 := '9710' || lpad(next_serial::text, 6, '0');
+        weighted := 0;
+        FOR i IN 1..10 LOOP
+          weighted := weighted + substr(first10, i, 1)::int * weights[i];
+        END LOOP;
+        check_digit := 11 - weighted % 11;
+        IF check_digit = 11 THEN
+          check_digit := 0;
+        END IF;
+        EXIT WHEN check_digit <> 10;
+      END LOOP;
+      bban := first10 || check_digit::text;
+    END IF;
+
+    -- ISO 7064 MOD 97-10 over BBAN || 'NO00', with N = 23 and O = 24
+    UPDATE accounts
+    SET previous_account_number = CASE WHEN account_number <> bban THEN account_number END,
+        account_number = bban,
+        iban = 'NO' || lpad((98 - ((bban || '232400')::numeric % 97))::text, 2, '0') || bban,
+        updated_at = NOW()
+    WHERE id = acc.id;
+  END LOOP;
+END $$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Renumbered accounts get their previous numbers back; accounts opened since keep their BBANs
+UPDATE accounts
+SET account_number = previous_account_number, updated_at = NOW()
+WHERE previous_account_number IS NOT NULL;
+DROP INDEX IF EXISTS idx_accounts_previous_account_number;
+DROP INDEX IF EXISTS idx_accounts_iban;
+ALTER TABLE accounts DROP COLUMN IF EXISTS previous_account_number;
+ALTER TABLE accounts DROP COLUMN IF EXISTS iban;
+DROP SEQUENCE IF EXISTS account_number_seq;
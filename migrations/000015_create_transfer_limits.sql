@@ -0,0 +1,54 @@
+-- +goose Up
+-- Outgoing transfer limits. transfer_limits holds the defaults per account type and currency;
+-- customer_transfer_limits overrides them for one customer in one currency.
+-- A NULL limit means no cap (in the overrides: fall back to the default).
+-- Daily and monthly limits follow calendar days and months in Europe/Oslo.
+CREATE TABLE IF NOT EXISTS transfer_limits (
+  account_type VARCHAR(20) NOT NULL,
+  currency VARCHAR(3) NOT NULL,
+  per_transaction DECIMAL(19,4) CHECK (per_transaction >= 0),
+  daily DECIMAL(19,4) CHECK (daily >= 0),
+  monthly DECIMAL(19,4) CHECK (monthly >= 0),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (account_type, currency)
+);
+
+CREATE TABLE IF NOT EXISTS customer_transfer_limits (
+  customer_id UUID NOT NULL REFERENCES customers(id),
+  currency VARCHAR(3) NOT NULL,
+  per_transaction DECIMAL(19,4) CHECK (per_transaction >= 0),
+  daily DECIMAL(19,4) CHECK (daily >= 0),
+  monthly DECIMAL(19,4) CHECK (monthly >= 0),
+  reason TEXT,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (customer_id, currency)
+);
+
+-- Usage sums outgoing transactions of one account since the start of the month
+CREATE INDEX IF NOT EXISTS idx_transactions_from_account_initiated ON transactions (from_account_id, initiated_at);
+
+INSERT INTO transfer_limits (account_type, currency, per_transaction, daily, monthly) VALUES
+  ('checking', 'NOK', 100000, 200000, 1000000),
+  ('checking', 'SEK', 100000, 200000, 1000000),
+  ('checking', 'DKK', 75000, 150000, 750000),
+  ('checking', 'EUR', 10000, 20000, 100000),
+  ('checking', 'USD', 10000, 20000, 100000),
+  ('checking', 'GBP', 10000, 20000, 100000),
+  ('checking', 'CHF', 10000, 20000, 100000),
+  ('checking', 'ISK', 1500000, 3000000, 15000000),
+  ('checking', 'JPY', 1500000, 3000000, 15000000),
+  ('savings', 'NOK', 50000, 100000, 500000),
+  ('savings', 'SEK', 50000, 100000, 500000),
+  ('savings', 'DKK', 35000, 75000, 350000),
+  ('savings', 'EUR', 5000, 10000, 50000),
+  ('savings', 'USD', 5000, 10000, 50000),
+  ('savings', 'GBP', 5000, 10000, 50000),
+  ('savings', 'CHF', 5000, 10000, 50000),
+  ('savings', 'ISK', 750000, 1500000, 7500000),
+  ('savings', 'JPY', 750000, 1500000, 7500000)
+ON CONFLICT (account_type, currency) DO NOTHING;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_from_account_initiated;
+DROP TABLE IF EXISTS customer_transfer_limits;
+DROP TABLE IF EXISTS transfer_limits;
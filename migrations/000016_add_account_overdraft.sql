@@ -0,0 +1,14 @@
+-- +goose Up
+-- Arranged overdraft: the processor lets the balance go down to -overdraft_limit.
+-- Only checking accounts can have one.
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS overdraft_limit DECIMAL(19,4) NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD CONSTRAINT accounts_overdraft_limit_check
+  CHECK (overdraft_limit >= 0 AND (overdraft_limit = 0 OR account_type = 'checking'));
+
+-- The overdraft report reads only the negative balances
+CREATE INDEX IF NOT EXISTS idx_account_balances_overdrawn ON account_balances (balance) WHERE balance < 0;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_account_balances_overdrawn;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_overdraft_limit_check;
+ALTER TABLE accounts DROP COLUMN IF EXISTS overdraft_limit;
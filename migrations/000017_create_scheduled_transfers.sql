@@ -0,0 +1,51 @@
+-- +goose Up
+-- Standing orders: a transfer on a future date, optionally repeated weekly or monthly until end_date.
+-- Dates are local dates in the customer's time zone when the order was created; an occurrence
+-- becomes due at midnight at the start of its date (next_run_at) and the worker's scheduler
+-- turns it into a normal pending transfer.
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+  id UUID PRIMARY KEY,
+  customer_id UUID NOT NULL REFERENCES customers(id),
+  from_account_id UUID NOT NULL REFERENCES accounts(id),
+  to_account_id UUID NOT NULL REFERENCES accounts(id),
+  amount DECIMAL(19,4) NOT NULL CHECK (amount > 0),
+  currency VARCHAR(3) NOT NULL,
+  reference TEXT,
+  frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('once', 'weekly', 'monthly')),
+  start_date DATE NOT NULL,
+  end_date DATE CHECK (end_date >= start_date),
+  timezone VARCHAR(64) NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed', 'cancelled')),
+  occurrences INT NOT NULL DEFAULT 0,
+  next_run_date DATE,
+  next_run_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  cancelled_at TIMESTAMPTZ,
+  CHECK (from_account_id <> to_account_id),
+  CHECK (status <> 'active' OR next_run_at IS NOT NULL)
+);
+
+-- The scheduler polls for active orders that are due
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers (next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_customer ON scheduled_transfers (customer_id, created_at DESC);
+
+-- One row per materialized occurrence, linking it to the transfer it created.
+-- Whether the occurrence succeeded is the status of that transaction.
+CREATE TABLE IF NOT EXISTS scheduled_transfer_occurrences (
+  id UUID PRIMARY KEY,
+  scheduled_transfer_id UUID NOT NULL REFERENCES scheduled_transfers(id),
+  occurrence_date DATE NOT NULL,
+  transaction_id UUID NOT NULL REFERENCES transactions(id),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (scheduled_transfer_id, occurrence_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfer_occurrences_created ON scheduled_transfer_occurrences (created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_scheduled_transfer_occurrences_created;
+DROP TABLE IF EXISTS scheduled_transfer_occurrences;
+DROP INDEX IF EXISTS idx_scheduled_transfers_customer;
+DROP INDEX IF EXISTS idx_scheduled_transfers_due;
+DROP TABLE IF EXISTS scheduled_transfers;
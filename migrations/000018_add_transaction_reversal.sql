@@ -0,0 +1,26 @@
+-- +goose Up
+-- Reversals: an operator undoes a completed transaction by booking a new 'reversal' transaction
+-- whose ledger entries mirror the original's. reversal_of links it to the original, which moves
+-- to status 'reversed'. A transaction can be reversed at most once.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_of UUID REFERENCES transactions(id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversal_of ON transactions (reversal_of) WHERE reversal_of IS NOT NULL;
+
+-- The ledger is append-only: corrections are new entries, never edits
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION reject_ledger_entry_change() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'ledger_entries are append-only; post a reversal instead';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS ledger_entries_append_only ON ledger_entries;
+CREATE TRIGGER ledger_entries_append_only
+  BEFORE UPDATE OR DELETE ON ledger_entries
+  FOR EACH ROW EXECUTE FUNCTION reject_ledger_entry_change();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS ledger_entries_append_only ON ledger_entries;
+DROP FUNCTION IF EXISTS reject_ledger_entry_change();
+DROP INDEX IF EXISTS idx_transactions_reversal_of;
+ALTER TABLE transactions DROP COLUMN IF EXISTS reversal_of;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- Transfer fees per account type and currency. A flat fee charges flat_amount on every transfer;
+-- a percentage fee charges a share of the amount, kept between min_fee and max_fee where set.
+-- No row means transfers are free.
+CREATE TABLE IF NOT EXISTS fee_schedules (
+  account_type VARCHAR(20) NOT NULL,
+  currency VARCHAR(3) NOT NULL,
+  fee_type VARCHAR(20) NOT NULL CHECK (fee_type IN ('flat', 'percentage')),
+  flat_amount DECIMAL(19,4) CHECK (flat_amount >= 0),
+  percentage DECIMAL(7,4) CHECK (percentage >= 0 AND percentage <= 100),
+  min_fee DECIMAL(19,4) CHECK (min_fee >= 0),
+  max_fee DECIMAL(19,4) CHECK (max_fee >= 0),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (account_type, currency),
+  CHECK ((fee_type = 'flat' AND flat_amount IS NOT NULL AND percentage IS NULL)
+      OR (fee_type = 'percentage' AND percentage IS NOT NULL AND flat_amount IS NULL))
+);
+
+-- The fee is fixed when a transfer is created and booked with it to the revenue account
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee_amount DECIMAL(19,4) CHECK (fee_amount > 0);
+
+-- At most one revenue account per currency (BANK-REVENUE-<CCY>), created on startup
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_revenue_currency
+  ON accounts (currency) WHERE account_type = 'revenue';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_accounts_revenue_currency;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee_amount;
+DROP TABLE IF EXISTS fee_schedules;
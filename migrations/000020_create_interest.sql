@@ -0,0 +1,40 @@
+-- +goose Up
+-- Interest rates per account type and currency. A rate applies from effective_from until the next
+-- rate for the same account type and currency takes effect.
+CREATE TABLE IF NOT EXISTS interest_rates (
+  account_type VARCHAR(20) NOT NULL,
+  currency VARCHAR(3) NOT NULL,
+  annual_rate DECIMAL(7,4) NOT NULL CHECK (annual_rate >= 0 AND annual_rate <= 100),
+  effective_from DATE NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (account_type, currency, effective_from)
+);
+
+-- One row per account and day: the day-end balance, the rate in effect and the interest it earned.
+-- Amounts keep 10 decimals; they are only rounded to the currency when a month is capitalized.
+CREATE TABLE IF NOT EXISTS interest_accruals (
+  account_id UUID NOT NULL REFERENCES accounts(id),
+  accrual_date DATE NOT NULL,
+  balance DECIMAL(19,4) NOT NULL,
+  annual_rate DECIMAL(7,4) NOT NULL,
+  amount DECIMAL(28,10) NOT NULL CHECK (amount >= 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (account_id, accrual_date)
+);
+
+-- One row per account and month once its interest has been paid. The primary key makes
+-- capitalization idempotent; transaction_id is the deposit, NULL if the interest rounded to zero.
+CREATE TABLE IF NOT EXISTS interest_capitalizations (
+  account_id UUID NOT NULL REFERENCES accounts(id),
+  period DATE NOT NULL CHECK (EXTRACT(DAY FROM period) = 1),
+  accrued DECIMAL(28,10) NOT NULL,
+  amount DECIMAL(19,4) NOT NULL CHECK (amount >= 0),
+  transaction_id UUID REFERENCES transactions(id),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (account_id, period)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS interest_capitalizations;
+DROP TABLE IF EXISTS interest_accruals;
+DROP TABLE IF EXISTS interest_rates;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- balance_after is the account's balance right after each entry. journal.Book writes it under the
+-- balance lock, so an account's history reads the running balance from the rows on the page instead
+-- of summing the account's whole ledger. Existing entries are backfilled in (created_at, id) order,
+-- the order the history lists them in.
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS balance_after DECIMAL(19,4);
+
+-- The backfill is the only update the append-only trigger lets through
+ALTER TABLE ledger_entries DISABLE TRIGGER ledger_entries_append_only;
+UPDATE ledger_entries le
+SET balance_after = r.running_balance
+FROM (
+  SELECT id, SUM(amount) OVER (PARTITION BY account_id ORDER BY created_at, id) AS running_balance
+  FROM ledger_entries
+) r
+WHERE r.id = le.id;
+ALTER TABLE ledger_entries ENABLE TRIGGER ledger_entries_append_only;
+
+ALTER TABLE ledger_entries ALTER COLUMN balance_after SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS balance_after;